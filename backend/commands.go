@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"food-app/database"
+	"food-app/repository"
+	"food-app/services"
+)
+
+const commandUsage = `usage:
+  food-app                                     run the API server
+  food-app catalog export [-format json|csv] [-o file]
+  food-app catalog import [-format json|csv] [-dry-run] file|-
+`
+
+// runCommand runs a command-line subcommand and returns the process exit code
+func runCommand(args []string) int {
+	if len(args) < 2 || args[0] != "catalog" {
+		fmt.Fprint(os.Stderr, commandUsage)
+		return 2
+	}
+
+	var err error
+	switch args[1] {
+	case "export":
+		err = exportCatalogCommand(args[2:])
+	case "import":
+		err = importCatalogCommand(args[2:])
+	default:
+		fmt.Fprint(os.Stderr, commandUsage)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		var invalid *services.CatalogError
+		if errors.As(err, &invalid) {
+			for _, problem := range invalid.Problems {
+				fmt.Fprintf(os.Stderr, "  %s: %s\n", problem.Path, problem.Message)
+			}
+		}
+		return 1
+	}
+	return 0
+}
+
+// exportCatalogCommand writes the catalog to a file or standard output
+func exportCatalogCommand(args []string) error {
+	flags := flag.NewFlagSet("catalog export", flag.ContinueOnError)
+	format := flags.String("format", "", "json or csv (default: from the file extension, else json)")
+	output := flags.String("o", "-", "file to write, - for standard output")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	catalog, err := repository.New(database.DB).ExportCatalog()
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "-" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	if err := services.WriteCatalog(w, catalogFormat(*format, *output), catalog); err != nil {
+		return err
+	}
+
+	if *output != "-" {
+		fmt.Fprintf(os.Stderr, "exported %d ingredients and %d meals to %s\n", len(catalog.Ingredients), len(catalog.Meals), *output)
+	}
+	return nil
+}
+
+// importCatalogCommand upserts a catalog file and prints the counts
+func importCatalogCommand(args []string) error {
+	flags := flag.NewFlagSet("catalog import", flag.ContinueOnError)
+	format := flags.String("format", "", "json or csv (default: from the file extension, else json)")
+	dryRun := flags.Bool("dry-run", false, "validate and report changes without saving")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("give one catalog file, or - for standard input")
+	}
+	input := flags.Arg(0)
+
+	var r io.Reader = os.Stdin
+	if input != "-" {
+		file, err := os.Open(input)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	catalog, err := services.ReadCatalog(r, catalogFormat(*format, input))
+	if err != nil {
+		return err
+	}
+	result, err := repository.New(database.DB).ImportCatalog(catalog, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	summary, _ := json.MarshalIndent(result, "", "  ")
+	fmt.Println(string(summary))
+	return nil
+}
+
+// catalogFormat picks the explicit format, else the file's extension, else JSON
+func catalogFormat(format, path string) string {
+	if format != "" {
+		return format
+	}
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return services.CatalogCSV
+	}
+	return services.CatalogJSON
+}
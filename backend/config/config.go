@@ -0,0 +1,331 @@
+// Package config loads the server settings. Defaults are overridden by an
+// optional YAML or TOML file, named by CONFIG_FILE, and then by environment
+// variables, so a deployment can keep secrets in the environment only.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Environments
+const (
+	Development = "development"
+	Test        = "test"
+	Production  = "production"
+)
+
+// DefaultJWTSecret signs tokens in development when JWT_SECRET is unset. It is
+// public, so the server refuses it anywhere else, including when APP_ENV is unset.
+const DefaultJWTSecret = "your-secret-key"
+
+// placeholderSecrets are sample values from the docs and compose files, refused like DefaultJWTSecret
+var placeholderSecrets = map[string]bool{
+	DefaultJWTSecret: true,
+	"your-super-secret-jwt-key-change-in-production": true,
+}
+
+// Config holds all server settings
+type Config struct {
+	Env            string         `yaml:"env" toml:"env"`   // APP_ENV: development, test or production
+	Port           int            `yaml:"port" toml:"port"` // PORT
+	JWTSecret      string         `yaml:"jwt_secret" toml:"jwt_secret"`
+	CORSOrigins    []string       `yaml:"cors_origins" toml:"cors_origins"`       // "*" allows every origin
+	CatalogEditors []string       `yaml:"catalog_editors" toml:"catalog_editors"` // emails allowed to import and export the catalog
+	Database       DatabaseConfig `yaml:"database" toml:"database"`
+	OAuth          OAuthConfig    `yaml:"oauth" toml:"oauth"`
+	Recipes        RecipesConfig  `yaml:"recipes" toml:"recipes"`
+}
+
+// DatabaseConfig selects SQLite (Path) or PostgreSQL (the other fields)
+type DatabaseConfig struct {
+	Type     string `yaml:"type" toml:"type"` // sqlite or postgres
+	Path     string `yaml:"path" toml:"path"`
+	Host     string `yaml:"host" toml:"host"`
+	Port     int    `yaml:"port" toml:"port"`
+	User     string `yaml:"user" toml:"user"`
+	Password string `yaml:"password" toml:"password"`
+	Name     string `yaml:"name" toml:"name"`
+	SSLMode  string `yaml:"sslmode" toml:"sslmode"`
+}
+
+// DSN is the PostgreSQL connection string
+func (d DatabaseConfig) DSN() string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		d.Host, d.Port, d.User, d.Password, d.Name, d.SSLMode)
+}
+
+// OAuthConfig holds the external login providers; a provider without a client ID is disabled
+type OAuthConfig struct {
+	RedirectBaseURL string            `yaml:"redirect_base_url" toml:"redirect_base_url"`
+	Google          OAuthClientConfig `yaml:"google" toml:"google"`
+	GitHub          OAuthClientConfig `yaml:"github" toml:"github"`
+	OIDC            OAuthClientConfig `yaml:"oidc" toml:"oidc"`
+}
+
+// OAuthClientConfig is one login provider's client registration
+type OAuthClientConfig struct {
+	IssuerURL    string `yaml:"issuer_url" toml:"issuer_url"` // generic OIDC only
+	ClientID     string `yaml:"client_id" toml:"client_id"`
+	ClientSecret string `yaml:"client_secret" toml:"client_secret"`
+}
+
+// RecipesConfig holds the external recipe sources
+type RecipesConfig struct {
+	Providers       []string           `yaml:"providers" toml:"providers"`                 // spoonacular, edamam, themealdb, local
+	CacheTTLSeconds int                `yaml:"cache_ttl_seconds" toml:"cache_ttl_seconds"` // 0 disables caching
+	Spoonacular     RecipeAPIConfig    `yaml:"spoonacular" toml:"spoonacular"`
+	Edamam          RecipeAPIConfig    `yaml:"edamam" toml:"edamam"`
+	TheMealDB       RecipeAPIConfig    `yaml:"themealdb" toml:"themealdb"`
+	Local           LocalRecipesConfig `yaml:"local" toml:"local"`
+}
+
+// RecipeAPIConfig is one remote recipe provider
+type RecipeAPIConfig struct {
+	AppID      string `yaml:"app_id" toml:"app_id"` // Edamam only
+	APIKey     string `yaml:"api_key" toml:"api_key"`
+	APIURL     string `yaml:"api_url" toml:"api_url"`         // overrides the public API root
+	DailyQuota int    `yaml:"daily_quota" toml:"daily_quota"` // requests per UTC day, 0 for unlimited
+}
+
+// LocalRecipesConfig is the catalog file served by the local recipe provider
+type LocalRecipesConfig struct {
+	File string `yaml:"file" toml:"file"`
+}
+
+// Defaults returns the settings used when nothing overrides them. Env is
+// production, so development leniency has to be asked for with APP_ENV.
+func Defaults() Config {
+	return Config{
+		Env:       Production,
+		Port:      8080,
+		JWTSecret: DefaultJWTSecret,
+		CORSOrigins: []string{
+			"http://localhost:3000", "http://localhost:3001", "http://localhost:5173", "http://localhost:5174",
+			"http://127.0.0.1:3000", "http://127.0.0.1:3001", "http://127.0.0.1:5173", "http://127.0.0.1:5174",
+		},
+		Database: DatabaseConfig{
+			Type:     "sqlite",
+			Path:     "./food_app.db",
+			Host:     "localhost",
+			Port:     5432,
+			User:     "postgres",
+			Password: "password",
+			Name:     "food_app",
+			SSLMode:  "disable",
+		},
+		OAuth: OAuthConfig{
+			RedirectBaseURL: "http://localhost:8080/api/v1/auth",
+			Google:          OAuthClientConfig{IssuerURL: "https://accounts.google.com"},
+		},
+		Recipes: RecipesConfig{
+			CacheTTLSeconds: 600,
+			Spoonacular:     RecipeAPIConfig{DailyQuota: 150}, // the free plan's daily points
+		},
+	}
+}
+
+// Load reads the defaults, then the file at path (when not empty), then the
+// environment, and validates the result
+func Load(path string) (Config, error) {
+	config := Defaults()
+	if path != "" {
+		if err := config.readFile(path); err != nil {
+			return Config{}, err
+		}
+	}
+	problems := append(config.readEnv(), config.problems()...)
+	if len(problems) > 0 {
+		return Config{}, invalid(problems)
+	}
+	return config, nil
+}
+
+// readFile decodes a .yaml, .yml or .toml file. Unknown keys are errors, so a
+// misspelt setting is not silently ignored.
+func (c *Config) readFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	case ".toml":
+		decoder := toml.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(c); err != nil {
+			var strict *toml.StrictMissingError
+			if errors.As(err, &strict) {
+				return fmt.Errorf("invalid config file %s: unknown keys\n%s", path, strict.String())
+			}
+			return fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("config file %s must be .yaml, .yml or .toml", path)
+	}
+	return nil
+}
+
+// readEnv applies the environment variables that are set and not empty,
+// returning those that could not be parsed
+func (c *Config) readEnv() []string {
+	env := envReader{}
+	env.string(&c.Env, "APP_ENV")
+	env.int(&c.Port, "PORT")
+	env.string(&c.JWTSecret, "JWT_SECRET")
+	env.list(&c.CORSOrigins, "CORS_ORIGINS")
+	env.list(&c.CatalogEditors, "CATALOG_EDITORS")
+
+	env.string(&c.Database.Type, "DB_TYPE")
+	env.string(&c.Database.Path, "DB_PATH")
+	env.string(&c.Database.Host, "DB_HOST")
+	env.int(&c.Database.Port, "DB_PORT")
+	env.string(&c.Database.User, "DB_USER")
+	env.string(&c.Database.Password, "DB_PASSWORD")
+	env.string(&c.Database.Name, "DB_NAME")
+	env.string(&c.Database.SSLMode, "DB_SSLMODE")
+
+	env.string(&c.OAuth.RedirectBaseURL, "OAUTH_REDIRECT_BASE_URL")
+	env.string(&c.OAuth.Google.ClientID, "GOOGLE_CLIENT_ID")
+	env.string(&c.OAuth.Google.ClientSecret, "GOOGLE_CLIENT_SECRET")
+	env.string(&c.OAuth.GitHub.ClientID, "GITHUB_CLIENT_ID")
+	env.string(&c.OAuth.GitHub.ClientSecret, "GITHUB_CLIENT_SECRET")
+	env.string(&c.OAuth.OIDC.IssuerURL, "OIDC_ISSUER_URL")
+	env.string(&c.OAuth.OIDC.ClientID, "OIDC_CLIENT_ID")
+	env.string(&c.OAuth.OIDC.ClientSecret, "OIDC_CLIENT_SECRET")
+
+	env.list(&c.Recipes.Providers, "RECIPE_PROVIDERS")
+	env.int(&c.Recipes.CacheTTLSeconds, "RECIPE_CACHE_TTL_SECONDS")
+	env.string(&c.Recipes.Spoonacular.APIKey, "SPOONACULAR_API_KEY")
+	env.string(&c.Recipes.Spoonacular.APIURL, "SPOONACULAR_API_URL")
+	env.int(&c.Recipes.Spoonacular.DailyQuota, "SPOONACULAR_DAILY_QUOTA")
+	env.string(&c.Recipes.Edamam.AppID, "EDAMAM_APP_ID")
+	env.string(&c.Recipes.Edamam.APIKey, "EDAMAM_APP_KEY")
+	env.string(&c.Recipes.Edamam.APIURL, "EDAMAM_API_URL")
+	env.int(&c.Recipes.Edamam.DailyQuota, "EDAMAM_DAILY_QUOTA")
+	env.string(&c.Recipes.TheMealDB.APIKey, "THEMEALDB_API_KEY")
+	env.string(&c.Recipes.TheMealDB.APIURL, "THEMEALDB_API_URL")
+	env.int(&c.Recipes.TheMealDB.DailyQuota, "THEMEALDB_DAILY_QUOTA")
+	env.string(&c.Recipes.Local.File, "LOCAL_RECIPES_FILE")
+
+	return env.problems
+}
+
+func (c *Config) problems() []string {
+	problems := []string{}
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	switch c.Env {
+	case Development, Test, Production:
+	default:
+		add("APP_ENV must be %s, %s or %s, not %q", Development, Test, Production, c.Env)
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		add("PORT must be between 1 and 65535")
+	}
+	if c.JWTSecret == "" {
+		add("JWT_SECRET is required")
+	} else if placeholderSecrets[c.JWTSecret] && c.Env != Development {
+		add("JWT_SECRET must be changed from the sample value outside development")
+	}
+
+	switch c.Database.Type {
+	case "sqlite":
+		if c.Database.Path == "" {
+			add("DB_PATH is required for sqlite")
+		}
+	case "postgres":
+		if c.Database.Host == "" || c.Database.Name == "" {
+			add("DB_HOST and DB_NAME are required for postgres")
+		}
+		if c.Database.Port < 1 || c.Database.Port > 65535 {
+			add("DB_PORT must be between 1 and 65535")
+		}
+	default:
+		add("DB_TYPE must be sqlite or postgres, not %q", c.Database.Type)
+	}
+
+	for _, provider := range c.Recipes.Providers {
+		switch provider {
+		case "spoonacular", "edamam", "themealdb", "local":
+		default:
+			add("unknown recipe provider %q in RECIPE_PROVIDERS", provider)
+		}
+	}
+	for name, api := range map[string]RecipeAPIConfig{
+		"SPOONACULAR": c.Recipes.Spoonacular,
+		"EDAMAM":      c.Recipes.Edamam,
+		"THEMEALDB":   c.Recipes.TheMealDB,
+	} {
+		if api.DailyQuota < 0 {
+			add("%s_DAILY_QUOTA must not be negative", name)
+		}
+	}
+
+	return problems
+}
+
+func invalid(problems []string) error {
+	return fmt.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
+}
+
+// IsDevelopment reports whether the server runs in development mode
+func (c Config) IsDevelopment() bool {
+	return c.Env == Development
+}
+
+// envReader reads typed environment variables, collecting parse problems
+type envReader struct {
+	problems []string
+}
+
+func (r *envReader) string(target *string, key string) {
+	if value := os.Getenv(key); value != "" {
+		*target = value
+	}
+}
+
+func (r *envReader) int(target *int, key string) {
+	value := os.Getenv(key)
+	if value == "" {
+		return
+	}
+	number, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		r.problems = append(r.problems, fmt.Sprintf("%s must be a whole number, not %q", key, value))
+		return
+	}
+	*target = number
+}
+
+// list reads a comma-separated value, dropping blank entries
+func (r *envReader) list(target *[]string, key string) {
+	value := os.Getenv(key)
+	if value == "" {
+		return
+	}
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	*target = items
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// clearEnv unsets the variables these tests depend on; an empty value reads as unset
+func clearEnv(t *testing.T) {
+	for _, key := range []string{"APP_ENV", "JWT_SECRET", "PORT", "DB_TYPE", "DB_PATH", "RECIPE_PROVIDERS"} {
+		t.Setenv(key, "")
+	}
+}
+
+func TestLoadRefusesSampleSecretsUnlessInDevelopment(t *testing.T) {
+	tests := []struct {
+		name   string
+		env    string
+		secret string
+		ok     bool
+	}{
+		{"unset env, default secret", "", "", false},
+		{"unset env, compose secret", "", "your-super-secret-jwt-key-change-in-production", false},
+		{"unset env, real secret", "", "a-long-random-string", true},
+		{"test, default secret", Test, "", false},
+		{"development, default secret", Development, "", true},
+		{"development, compose secret", Development, "your-super-secret-jwt-key-change-in-production", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			t.Setenv("APP_ENV", tt.env)
+			t.Setenv("JWT_SECRET", tt.secret)
+
+			cfg, err := Load("")
+			if tt.ok != (err == nil) {
+				t.Fatalf("Load = %v, want ok %v", err, tt.ok)
+			}
+			if err != nil && !strings.Contains(err.Error(), "JWT_SECRET") {
+				t.Errorf("error %q does not name JWT_SECRET", err)
+			}
+			if err == nil && tt.env == "" && (cfg.Env != Production || cfg.IsDevelopment()) {
+				t.Errorf("Env = %q, want %q by default", cfg.Env, Production)
+			}
+		})
+	}
+}
+
+func TestLoadReportsEveryProblem(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("APP_ENV", "staging")
+	t.Setenv("PORT", "eighty")
+	t.Setenv("DB_TYPE", "mysql")
+	t.Setenv("RECIPE_PROVIDERS", "local, nowhere")
+
+	_, err := Load("")
+	if err == nil {
+		t.Fatal("Load accepted invalid settings")
+	}
+	for _, want := range []string{"APP_ENV", "PORT", "DB_TYPE", `"nowhere"`, "JWT_SECRET"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+}
+
+func TestLoadFileThenEnvironment(t *testing.T) {
+	clearEnv(t)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("env: test\nport: 9000\njwt_secret: from-the-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PORT", "9100")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Env != Test || cfg.Port != 9100 || cfg.JWTSecret != "from-the-file" {
+		t.Errorf("loaded env %q, port %d, secret %q", cfg.Env, cfg.Port, cfg.JWTSecret)
+	}
+
+	if err := os.WriteFile(path, []byte("prot: 9000\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("a misspelt key was accepted")
+	}
+}
@@ -1,43 +1,35 @@
 package database
 
 import (
-	"fmt"
+	"errors"
 	"log"
-	"os"
+	"time"
 
+	"food-app/config"
+	"food-app/ingredients"
 	"food-app/models"
 
-	"github.com/jinzhu/gorm"
-	_ "github.com/jinzhu/gorm/dialects/sqlite"
-	_ "github.com/lib/pq"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
 var DB *gorm.DB
 
-func Connect() {
+// Connect opens the database described by cfg
+func Connect(cfg config.DatabaseConfig) {
 	var err error
 	
 	// Check if we should use SQLite for development
-	if getEnv("DB_TYPE", "sqlite") == "sqlite" {
-		dbPath := getEnv("DB_PATH", "./food_app.db")
-		DB, err = gorm.Open("sqlite3", dbPath)
+	if cfg.Type == "sqlite" {
+		DB, err = Open(sqlite.Open(cfg.Path))
 		if err != nil {
 			log.Fatal("Failed to connect to SQLite database:", err)
 		}
 		log.Println("SQLite database connection established")
 	} else {
-		// PostgreSQL configuration
-		host := getEnv("DB_HOST", "localhost")
-		port := getEnv("DB_PORT", "5432")
-		user := getEnv("DB_USER", "postgres")
-		password := getEnv("DB_PASSWORD", "password")
-		dbname := getEnv("DB_NAME", "food_app")
-		sslmode := getEnv("DB_SSLMODE", "disable")
-
-		dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-			host, port, user, password, dbname, sslmode)
-
-		DB, err = gorm.Open("postgres", dsn)
+		DB, err = Open(postgres.Open(cfg.DSN()))
 		if err != nil {
 			log.Fatal("Failed to connect to PostgreSQL database:", err)
 		}
@@ -45,32 +37,77 @@ func Connect() {
 	}
 }
 
+// Open connects through dialector with the settings every database shares:
+// prepared statements are cached and lookups that match nothing are not
+// logged as errors
+func Open(dialector gorm.Dialector) (*gorm.DB, error) {
+	db, err := gorm.Open(dialector, &gorm.Config{
+		PrepareStmt: true,
+		// Manual shopping items and unshared plans use 0 and NULL as "none",
+		// so keep the schema free of foreign key constraints as before
+		DisableForeignKeyConstraintWhenMigrating: true,
+		Logger: logger.New(log.Default(), logger.Config{
+			SlowThreshold:             200 * time.Millisecond,
+			LogLevel:                  logger.Warn,
+			IgnoreRecordNotFoundError: true,
+		}),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
 func Migrate() {
 	// Auto-migrate all models
-	DB.AutoMigrate(
+	if err := AutoMigrate(DB); err != nil {
+		log.Fatal("Failed to migrate database:", err)
+	}
+
+	runDataMigrations()
+
+	log.Println("Database migration completed")
+}
+
+// AutoMigrate brings db's schema up to date with the models, without the data
+// migrations, so a fresh database such as an in-memory one can be set up alone
+func AutoMigrate(db *gorm.DB) error {
+	return db.AutoMigrate(
 		&models.User{},
+		&models.UserIdentity{},
 		&models.Meal{},
 		&models.Ingredient{},
 		&models.MealIngredient{},
+		&models.IngredientAlias{},
 		&models.UserMealInteraction{},
 		&models.MealReview{},
 		&models.MealPlan{},
 		&models.MealPlanEntry{},
-		&models.CurrentMealPlan{},
 		&models.ShoppingList{},
 		&models.ShoppingListItem{},
+		&models.Household{},
+		&models.HouseholdMember{},
+		&models.HouseholdInvitation{},
+		&models.IngredientPrice{},
+		&models.StoreLayout{},
+		&models.StoreAisle{},
+		&models.CalendarFeed{},
+		&models.ProviderRequestCount{},
 	)
-
-	log.Println("Database migration completed")
 }
 
-func SeedData() {
+// SeedData fills an empty database with sample ingredients and meals. It
+// returns the meal ingredient names that matched no ingredient or alias and so
+// were left out of their meals.
+func SeedData() []string {
+	unresolved := []string{}
+
 	// Check if data already exists
 	var userCount int64
 	DB.Model(&models.User{}).Count(&userCount)
 	if userCount > 0 {
 		log.Println("Database already contains data, skipping seed")
-		return
+		return unresolved
 	}
 
 	// Seed ingredients
@@ -90,15 +127,33 @@ func SeedData() {
 		{Name: "Black Beans", Category: "protein", Unit: "cup", CaloriesPer100g: 132},
 		{Name: "Avocado", Category: "fat", Unit: "piece", CaloriesPer100g: 160},
 		{Name: "Lemon", Category: "fruit", Unit: "piece", CaloriesPer100g: 29},
+		{Name: "Asparagus", Category: "vegetable", Unit: "bunch", CaloriesPer100g: 20},
 	}
 
 	for _, ingredient := range ingredients {
 		var existing models.Ingredient
-		if DB.Where("name = ?", ingredient.Name).First(&existing).RecordNotFound() {
+		if errors.Is(DB.Where("name = ?", ingredient.Name).First(&existing).Error, gorm.ErrRecordNotFound) {
 			DB.Create(&ingredient)
 		}
 	}
 
+	// Seed aliases, the other names recipes use for catalog ingredients
+	aliases := map[string]string{
+		"White Rice":    "Rice",
+		"Salmon Fillet": "Salmon",
+		"Capsicum":      "Bell Pepper",
+	}
+	for alias, name := range aliases {
+		var ingredient models.Ingredient
+		if DB.Where("name = ?", name).First(&ingredient).Error != nil {
+			continue
+		}
+		var existing models.IngredientAlias
+		if errors.Is(DB.Where("name = ?", alias).First(&existing).Error, gorm.ErrRecordNotFound) {
+			DB.Create(&models.IngredientAlias{IngredientID: ingredient.ID, Name: alias})
+		}
+	}
+
 	// Seed meals
 	meals := []models.Meal{
 		{
@@ -174,21 +229,23 @@ func SeedData() {
 
 			for _, meal := range meals {
 			var existing models.Meal
-			if DB.Where("name = ?", meal.Name).First(&existing).RecordNotFound() {
+			if errors.Is(DB.Where("name = ?", meal.Name).First(&existing).Error, gorm.ErrRecordNotFound) {
 				if err := DB.Create(&meal).Error; err != nil {
 					log.Printf("Error creating meal %s: %v", meal.Name, err)
 					continue
 				}
 
 				// Add meal ingredients with quantities
-				seedMealIngredients(meal.ID, meal.Name)
+				unresolved = append(unresolved, seedMealIngredients(meal.ID, meal.Name)...)
 			}
 		}
 
 	log.Println("Database seeded with initial data")
+	return unresolved
 }
 
-func seedMealIngredients(mealID uint, mealName string) {
+// seedMealIngredients adds a seeded meal's ingredient lines, returning the names it could not resolve
+func seedMealIngredients(mealID uint, mealName string) []string {
 	// Define ingredient mappings for each meal
 	mealIngredientMappings := map[string][]struct {
 		name     string
@@ -208,40 +265,47 @@ func seedMealIngredients(mealID uint, mealName string) {
 			{"Avocado", 1, "medium"},
 			{"Olive Oil", 1, "tbsp"},
 		},
-		"Salmon with Vegetables": {
+		"Baked Salmon with Sweet Potato": {
 			{"Salmon Fillet", 6, "oz"},
+			{"Sweet Potato", 1, "medium"},
 			{"Asparagus", 1, "bunch"},
 			{"Lemon", 1, "medium"},
 			{"Olive Oil", 1, "tbsp"},
 		},
 	}
 
-	ingredients, exists := mealIngredientMappings[mealName]
+	lines, exists := mealIngredientMappings[mealName]
 	if !exists {
-		return // Skip if no mapping defined
+		return nil // Skip if no mapping defined
 	}
 
-	for _, ing := range ingredients {
-		var ingredient models.Ingredient
-		if !DB.Where("name = ?", ing.name).First(&ingredient).RecordNotFound() {
-			// Create meal ingredient relationship
-			mealIngredient := models.MealIngredient{
-				MealID:       mealID,
-				IngredientID: ingredient.ID,
-				Quantity:     ing.quantity,
-				Unit:         ing.unit,
-			}
-			
-			if err := DB.Create(&mealIngredient).Error; err != nil {
-				log.Printf("Error creating meal ingredient for %s: %v", ing.name, err)
-			}
-		}
+	// Resolve names through the catalog and its aliases, so "White Rice" is Rice
+	var catalog []models.Ingredient
+	if err := DB.Preload("Aliases").Order("id").Find(&catalog).Error; err != nil {
+		log.Printf("Error loading ingredients for %s: %v", mealName, err)
+		return nil
 	}
-}
+	matcher := ingredients.NewMatcher(catalog)
+
+	var unresolved []string
+	for _, ing := range lines {
+		ingredient, ok := matcher.Match(ing.name)
+		if !ok {
+			unresolved = append(unresolved, ing.name)
+			continue
+		}
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+		// Create meal ingredient relationship
+		mealIngredient := models.MealIngredient{
+			MealID:       mealID,
+			IngredientID: ingredient.ID,
+			Quantity:     ing.quantity,
+			Unit:         ing.unit,
+		}
+
+		if err := DB.Create(&mealIngredient).Error; err != nil {
+			log.Printf("Error creating meal ingredient for %s: %v", ing.name, err)
+		}
 	}
-	return defaultValue
+	return unresolved
 }
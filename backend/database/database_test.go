@@ -0,0 +1,84 @@
+package database
+
+import (
+	"testing"
+
+	"food-app/models"
+
+	"gorm.io/driver/sqlite"
+)
+
+// useTestDB points DB at a fresh in-memory database with the full schema
+func useTestDB(t *testing.T) {
+	t.Helper()
+
+	db, err := Open(sqlite.Open(":memory:"))
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("database handle: %v", err)
+	}
+	// Every connection to ":memory:" is its own database, so keep to one
+	sqlDB.SetMaxOpenConns(1)
+	if err := AutoMigrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	previous := DB
+	DB = db
+	t.Cleanup(func() {
+		DB = previous
+		sqlDB.Close()
+	})
+}
+
+func TestSeedDataResolvesEveryIngredient(t *testing.T) {
+	useTestDB(t)
+
+	if unresolved := SeedData(); len(unresolved) != 0 {
+		t.Errorf("unresolved seed ingredients %q", unresolved)
+	}
+
+	var meal models.Meal
+	if err := DB.Preload("IngredientLines.Ingredient").Where("name = ?", "Baked Salmon with Sweet Potato").First(&meal).Error; err != nil {
+		t.Fatalf("load seeded meal: %v", err)
+	}
+	names := map[string]bool{}
+	for _, line := range meal.IngredientLines {
+		names[line.Ingredient.Name] = true
+	}
+	// "Salmon Fillet" resolves through its alias
+	for _, want := range []string{"Salmon", "Sweet Potato", "Asparagus", "Lemon", "Olive Oil"} {
+		if !names[want] {
+			t.Errorf("seeded salmon meal lacks %s, has %v", want, names)
+		}
+	}
+}
+
+func TestSeedMealIngredientsReturnsUnresolvedNames(t *testing.T) {
+	useTestDB(t)
+	SeedData()
+
+	var meal models.Meal
+	if err := DB.Where("name = ?", "Baked Salmon with Sweet Potato").First(&meal).Error; err != nil {
+		t.Fatalf("load seeded meal: %v", err)
+	}
+	DB.Where("meal_id = ?", meal.ID).Delete(&models.MealIngredient{})
+	DB.Where("name = ?", "Salmon Fillet").Delete(&models.IngredientAlias{})
+
+	unresolved := seedMealIngredients(meal.ID, meal.Name)
+	if len(unresolved) != 1 || unresolved[0] != "Salmon Fillet" {
+		t.Errorf("unresolved = %q, want the alias-less Salmon Fillet", unresolved)
+	}
+
+	var lines int64
+	DB.Model(&models.MealIngredient{}).Where("meal_id = ?", meal.ID).Count(&lines)
+	if lines != 4 {
+		t.Errorf("meal has %d lines, want the 4 that resolved", lines)
+	}
+	if again := SeedData(); len(again) != 0 {
+		t.Errorf("seeding again, with the meals in place, reported %q", again)
+	}
+}
@@ -0,0 +1,281 @@
+package database
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"food-app/models"
+
+	"gorm.io/gorm"
+)
+
+// schemaMigration records a data migration that has been applied
+type schemaMigration struct {
+	ID        string `gorm:"primaryKey"`
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// dataMigration transforms existing rows after AutoMigrate has updated the schema
+type dataMigration struct {
+	id  string
+	run func(tx *gorm.DB) error
+}
+
+// dataMigrations run once each, in order, inside a transaction
+var dataMigrations = []dataMigration{
+	{"20261019_merge_current_meal_plans", mergeCurrentMealPlans},
+	{"20261019_key_meal_ingredients", keyMealIngredients},
+}
+
+func runDataMigrations() {
+	DB.AutoMigrate(&schemaMigration{})
+
+	for _, migration := range dataMigrations {
+		var applied schemaMigration
+		if err := DB.Where("id = ?", migration.id).First(&applied).Error; !errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+
+		tx := DB.Begin()
+		if err := migration.run(tx); err != nil {
+			tx.Rollback()
+			log.Fatalf("Data migration %s failed: %v", migration.id, err)
+		}
+		if err := tx.Create(&schemaMigration{ID: migration.id, AppliedAt: time.Now()}).Error; err != nil {
+			tx.Rollback()
+			log.Fatalf("Failed to record data migration %s: %v", migration.id, err)
+		}
+		if err := tx.Commit().Error; err != nil {
+			log.Fatalf("Failed to commit data migration %s: %v", migration.id, err)
+		}
+		log.Printf("Applied data migration %s", migration.id)
+	}
+}
+
+// legacyCurrentMealPlan is a row of the retired current_meal_plans table
+type legacyCurrentMealPlan struct {
+	ID            uint
+	UserID        uint
+	HouseholdID   *uint
+	WeekStart     time.Time
+	HouseholdSize int
+	CreatedAt     time.Time
+}
+
+func (legacyCurrentMealPlan) TableName() string {
+	return "current_meal_plans"
+}
+
+// mergeCurrentMealPlans moves every current_meal_plans row into meal_plans and
+// makes it its user's active plan. Entries and shopping lists keyed by the old
+// ID move with it. Where the old ID also exists in meal_plans, the two plans
+// shared entries, so the entries are copied rather than moved and only lists
+// named like current-plan lists ("Week of ...") move. The old table is kept.
+func mergeCurrentMealPlans(tx *gorm.DB) error {
+	if !tx.Migrator().HasTable("current_meal_plans") {
+		return nil
+	}
+
+	var currentPlans []legacyCurrentMealPlan
+	if err := tx.Find(&currentPlans).Error; err != nil {
+		return err
+	}
+
+	var legacyIDs []uint
+	if err := tx.Model(&models.MealPlan{}).Pluck("id", &legacyIDs).Error; err != nil {
+		return err
+	}
+	collides := make(map[uint]bool, len(legacyIDs))
+	for _, id := range legacyIDs {
+		collides[id] = true
+	}
+
+	// Collect rows by old ID before inserting, since new IDs may equal later old IDs
+	entriesByPlan := map[uint][]models.MealPlanEntry{}
+	listIDsByPlan := map[uint][]uint{}
+	for _, current := range currentPlans {
+		var entries []models.MealPlanEntry
+		if err := tx.Where("meal_plan_id = ?", current.ID).Find(&entries).Error; err != nil {
+			return err
+		}
+		entriesByPlan[current.ID] = entries
+
+		lists := tx.Model(&models.ShoppingList{}).Where("meal_plan_id = ?", current.ID)
+		if collides[current.ID] {
+			lists = lists.Where("user_id = ? AND name LIKE ?", current.UserID, "Week of %")
+		}
+		var listIDs []uint
+		if err := lists.Pluck("id", &listIDs).Error; err != nil {
+			return err
+		}
+		listIDsByPlan[current.ID] = listIDs
+	}
+
+	for _, current := range currentPlans {
+		householdSize := current.HouseholdSize
+		if householdSize <= 0 {
+			householdSize = 1
+		}
+
+		plan := models.MealPlan{
+			UserID:        current.UserID,
+			HouseholdID:   current.HouseholdID,
+			Name:          "Week of " + current.WeekStart.Format("Jan 2, 2006"),
+			WeekStart:     current.WeekStart,
+			HouseholdSize: householdSize,
+		}
+		if err := tx.Create(&plan).Error; err != nil {
+			return err
+		}
+
+		entries := entriesByPlan[current.ID]
+		if collides[current.ID] {
+			if err := copyEntries(tx, entries, plan.ID); err != nil {
+				return err
+			}
+			log.Printf("Plan IDs collided for current plan %d; copied %d entries to plan %d", current.ID, len(entries), plan.ID)
+		} else if len(entries) > 0 {
+			ids := make([]uint, 0, len(entries))
+			for _, entry := range entries {
+				ids = append(ids, entry.ID)
+			}
+			if err := tx.Model(&models.MealPlanEntry{}).Where("id IN (?)", ids).
+				UpdateColumn("meal_plan_id", plan.ID).Error; err != nil {
+				return err
+			}
+		}
+
+		if listIDs := listIDsByPlan[current.ID]; len(listIDs) > 0 {
+			if err := tx.Model(&models.ShoppingList{}).Where("id IN (?)", listIDs).
+				UpdateColumn("meal_plan_id", plan.ID).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&models.User{}).Where("id = ?", current.UserID).
+			UpdateColumn("active_meal_plan_id", plan.ID).Error; err != nil {
+			return err
+		}
+	}
+
+	// is_active used to default to true on every plan; make it mirror the pointer
+	return tx.Exec(`UPDATE meal_plans SET is_active = (id IN
+		(SELECT active_meal_plan_id FROM users WHERE active_meal_plan_id IS NOT NULL))`).Error
+}
+
+// copyEntries duplicates entries into another plan, keeping leftover links within the copy
+func copyEntries(tx *gorm.DB, entries []models.MealPlanEntry, mealPlanID uint) error {
+	copied := map[uint]uint{}
+	var leftovers []models.MealPlanEntry
+
+	for _, entry := range entries {
+		oldID := entry.ID
+		entry.ID = 0
+		entry.MealPlanID = mealPlanID
+		if entry.LeftoverOfID != nil {
+			leftovers = append(leftovers, entry)
+			continue
+		}
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+		copied[oldID] = entry.ID
+	}
+
+	for _, entry := range leftovers {
+		if sourceID, ok := copied[*entry.LeftoverOfID]; ok {
+			entry.LeftoverOfID = &sourceID
+		} else {
+			entry.LeftoverOfID = nil
+		}
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unkeyedMealIngredient is a row of meal_ingredients from before it had a primary key
+type unkeyedMealIngredient struct {
+	MealID       uint
+	IngredientID uint
+	Quantity     float64
+	Unit         string
+	Note         string
+}
+
+// keyMealIngredients rebuilds a meal_ingredients table created without its
+// (meal_id, ingredient_id) primary key, which AutoMigrate does not add to an
+// existing table. Duplicate lines for one ingredient are merged into the first:
+// quantities in the same unit add up, and lines in another unit are logged and dropped.
+func keyMealIngredients(tx *gorm.DB) error {
+	columns, err := tx.Migrator().ColumnTypes(&models.MealIngredient{})
+	if err != nil {
+		return err
+	}
+	for _, column := range columns {
+		if primary, ok := column.PrimaryKey(); column.Name() == "meal_id" && ok && primary {
+			return nil
+		}
+	}
+
+	if err := tx.Migrator().RenameTable("meal_ingredients", "meal_ingredients_unkeyed"); err != nil {
+		return err
+	}
+	// The renamed table keeps its index, whose name the new table would reuse
+	if tx.Migrator().HasIndex("meal_ingredients_unkeyed", "idx_meal_ingredients_meal_id") {
+		if err := tx.Exec("DROP INDEX idx_meal_ingredients_meal_id").Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Migrator().CreateTable(&models.MealIngredient{}); err != nil {
+		return err
+	}
+
+	var rows []unkeyedMealIngredient
+	if err := tx.Table("meal_ingredients_unkeyed").Order("meal_id, ingredient_id").Find(&rows).Error; err != nil {
+		return err
+	}
+	for _, line := range mergeMealIngredients(rows) {
+		if err := tx.Create(&line).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Migrator().DropTable("meal_ingredients_unkeyed")
+}
+
+// mergeMealIngredients keeps one line per meal and ingredient from rows sorted by both
+func mergeMealIngredients(rows []unkeyedMealIngredient) []models.MealIngredient {
+	var lines []models.MealIngredient
+	for _, row := range rows {
+		last := len(lines) - 1
+		if last < 0 || lines[last].MealID != row.MealID || lines[last].IngredientID != row.IngredientID {
+			lines = append(lines, models.MealIngredient{
+				MealID:       row.MealID,
+				IngredientID: row.IngredientID,
+				Quantity:     row.Quantity,
+				Unit:         row.Unit,
+				Note:         row.Note,
+			})
+			continue
+		}
+
+		kept := &lines[last]
+		if strings.EqualFold(strings.TrimSpace(kept.Unit), strings.TrimSpace(row.Unit)) {
+			kept.Quantity += row.Quantity
+			if kept.Note == "" {
+				kept.Note = row.Note
+			}
+			continue
+		}
+		log.Printf("Dropped duplicate line for ingredient %d in meal %d: %g %s does not add to the kept %g %s",
+			row.IngredientID, row.MealID, row.Quantity, row.Unit, kept.Quantity, kept.Unit)
+	}
+	return lines
+}
@@ -0,0 +1,207 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"food-app/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// appliedMigration reports whether a data migration is recorded as applied
+func appliedMigration(t *testing.T, id string) bool {
+	t.Helper()
+
+	var count int64
+	if err := DB.Model(&schemaMigration{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		t.Fatalf("read schema_migrations: %v", err)
+	}
+	return count == 1
+}
+
+func TestKeyMealIngredientsMergesOnlyMatchingUnits(t *testing.T) {
+	useTestDB(t)
+
+	// The table as it was before the primary key, holding duplicate lines
+	DB.Migrator().DropTable(&models.MealIngredient{})
+	for _, statement := range []string{
+		`CREATE TABLE meal_ingredients (meal_id integer, ingredient_id integer, quantity real, unit text, note text)`,
+		`CREATE INDEX idx_meal_ingredients_meal_id ON meal_ingredients (meal_id)`,
+		`INSERT INTO meal_ingredients VALUES
+			(1, 10, 500, 'g', ''),
+			(1, 10, 2, 'tbsp', 'melted'),
+			(1, 10, 250, 'G', 'softened'),
+			(1, 11, 1, 'cup', 'rinsed'),
+			(2, 10, 3, 'tbsp', ''),
+			(2, 10, 1, 'tbsp', 'heaped')`,
+	} {
+		if err := DB.Exec(statement).Error; err != nil {
+			t.Fatalf("%s: %v", statement, err)
+		}
+	}
+
+	runDataMigrations()
+
+	var lines []models.MealIngredient
+	if err := DB.Order("meal_id, ingredient_id").Find(&lines).Error; err != nil {
+		t.Fatalf("read meal ingredients: %v", err)
+	}
+	want := []models.MealIngredient{
+		{MealID: 1, IngredientID: 10, Quantity: 750, Unit: "g", Note: "softened"},
+		{MealID: 1, IngredientID: 11, Quantity: 1, Unit: "cup", Note: "rinsed"},
+		{MealID: 2, IngredientID: 10, Quantity: 4, Unit: "tbsp", Note: "heaped"},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("lines = %+v, want %+v", lines, want)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(lines[i], want[i]) {
+			t.Errorf("line %d = %+v, want %+v", i, lines[i], want[i])
+		}
+	}
+
+	// The key is in place, so a second line for one ingredient is refused
+	if err := DB.Create(&models.MealIngredient{MealID: 1, IngredientID: 11, Quantity: 2, Unit: "cup"}).Error; err == nil {
+		t.Error("a duplicate line was stored after keying")
+	}
+	if !appliedMigration(t, "20261019_key_meal_ingredients") {
+		t.Error("the migration was not recorded")
+	}
+}
+
+func TestMergeMealIngredientsKeepsTheFirstWholeLine(t *testing.T) {
+	lines := mergeMealIngredients([]unkeyedMealIngredient{
+		{MealID: 1, IngredientID: 10, Quantity: 2, Unit: "tbsp", Note: "melted"},
+		{MealID: 1, IngredientID: 10, Quantity: 500, Unit: "g"},
+		{MealID: 1, IngredientID: 10, Quantity: 1, Unit: " TBSP "},
+	})
+	want := models.MealIngredient{MealID: 1, IngredientID: 10, Quantity: 3, Unit: "tbsp", Note: "melted"}
+	if len(lines) != 1 || !reflect.DeepEqual(lines[0], want) {
+		t.Errorf("lines = %+v, want %+v", lines, want)
+	}
+}
+
+// mustCreate inserts a row without its associations
+func mustCreate(t *testing.T, value interface{}) {
+	t.Helper()
+	if err := DB.Omit(clause.Associations).Create(value).Error; err != nil {
+		t.Fatalf("create %T: %v", value, err)
+	}
+}
+
+func TestMergeCurrentMealPlans(t *testing.T) {
+	useTestDB(t)
+	if err := DB.Migrator().CreateTable(&legacyCurrentMealPlan{}); err != nil {
+		t.Fatalf("create current_meal_plans: %v", err)
+	}
+	week := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+	ann := models.User{Email: "ann@example.com", Username: "ann"}
+	bob := models.User{Email: "bob@example.com", Username: "bob"}
+	mustCreate(t, &ann)
+	mustCreate(t, &bob)
+
+	// Ann's saved plan 1 shares its ID with Bob's current plan 1, so the entries
+	// under ID 1 belonged to both. Ann's current plan 5 collides with nothing.
+	mustCreate(t, &models.MealPlan{ID: 1, UserID: ann.ID, Name: "Dinner party", WeekStart: week, IsActive: true})
+	mustCreate(t, &legacyCurrentMealPlan{ID: 1, UserID: bob.ID, WeekStart: week, HouseholdSize: 3})
+	mustCreate(t, &legacyCurrentMealPlan{ID: 5, UserID: ann.ID, WeekStart: week.AddDate(0, 0, 7)})
+
+	cook := models.MealPlanEntry{MealPlanID: 1, MealID: 7, Day: "monday", MealType: "dinner", Servings: 1}
+	mustCreate(t, &cook)
+	leftover := models.MealPlanEntry{MealPlanID: 1, MealID: 7, Day: "tuesday", MealType: "lunch", Servings: 1, LeftoverOfID: &cook.ID}
+	mustCreate(t, &leftover)
+	annEntry := models.MealPlanEntry{MealPlanID: 5, MealID: 8, Day: "friday", MealType: "dinner", Servings: 1}
+	mustCreate(t, &annEntry)
+
+	bobList := models.ShoppingList{UserID: bob.ID, MealPlanID: 1, Name: "Week of Oct 19, 2026"}
+	partyList := models.ShoppingList{UserID: ann.ID, MealPlanID: 1, Name: "Dinner party list"}
+	annList := models.ShoppingList{UserID: ann.ID, MealPlanID: 5, Name: "Week of Oct 26, 2026"}
+	for _, list := range []*models.ShoppingList{&bobList, &partyList, &annList} {
+		mustCreate(t, list)
+	}
+
+	runDataMigrations()
+
+	activePlan := func(user models.User) models.MealPlan {
+		t.Helper()
+		if err := DB.First(&user, user.ID).Error; err != nil || user.ActiveMealPlanID == nil {
+			t.Fatalf("%s has no active plan: %v", user.Username, err)
+		}
+		var plan models.MealPlan
+		if err := DB.Preload("Meals", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).First(&plan, *user.ActiveMealPlanID).Error; err != nil {
+			t.Fatalf("load %s's plan: %v", user.Username, err)
+		}
+		return plan
+	}
+	listPlan := func(list models.ShoppingList) uint {
+		t.Helper()
+		DB.First(&list, list.ID)
+		return list.MealPlanID
+	}
+
+	t.Run("collision", func(t *testing.T) {
+		plan := activePlan(bob)
+		if plan.ID == 1 || plan.UserID != bob.ID || plan.HouseholdSize != 3 || !plan.IsActive || plan.Name != "Week of Oct 19, 2026" {
+			t.Errorf("bob's plan = %+v", plan)
+		}
+		// Bob gets copies; Ann's saved plan keeps the originals
+		if len(plan.Meals) != 2 {
+			t.Fatalf("bob's plan has %d entries, want 2 copies", len(plan.Meals))
+		}
+		copiedCook, copiedLeftover := plan.Meals[0], plan.Meals[1]
+		if copiedCook.ID == cook.ID || copiedLeftover.LeftoverOfID == nil || *copiedLeftover.LeftoverOfID != copiedCook.ID {
+			t.Errorf("copies %+v and %+v, want the leftover linked to the copied cook", copiedCook, copiedLeftover)
+		}
+		var originals int64
+		DB.Model(&models.MealPlanEntry{}).Where("meal_plan_id = ?", 1).Count(&originals)
+		if originals != 2 {
+			t.Errorf("plan 1 kept %d entries, want 2", originals)
+		}
+
+		// Only Bob's current-plan list moves
+		if got := listPlan(bobList); got != plan.ID {
+			t.Errorf("bob's list is on plan %d, want %d", got, plan.ID)
+		}
+		if got := listPlan(partyList); got != 1 {
+			t.Errorf("the party list moved to plan %d", got)
+		}
+
+		var party models.MealPlan
+		DB.First(&party, 1)
+		if party.IsActive {
+			t.Error("ann's saved plan is still flagged active")
+		}
+	})
+
+	t.Run("no collision", func(t *testing.T) {
+		plan := activePlan(ann)
+		if plan.ID == 1 || plan.UserID != ann.ID || plan.HouseholdSize != 1 || !plan.IsActive {
+			t.Errorf("ann's plan = %+v", plan)
+		}
+		// The entry moves rather than being copied
+		if len(plan.Meals) != 1 || plan.Meals[0].ID != annEntry.ID {
+			t.Errorf("ann's plan entries = %+v, want entry %d moved", plan.Meals, annEntry.ID)
+		}
+		if got := listPlan(annList); got != plan.ID {
+			t.Errorf("ann's list is on plan %d, want %d", got, plan.ID)
+		}
+	})
+
+	if !appliedMigration(t, "20261019_merge_current_meal_plans") {
+		t.Error("the migration was not recorded")
+	}
+
+	// A second run does nothing
+	var plans int64
+	DB.Model(&models.MealPlan{}).Count(&plans)
+	runDataMigrations()
+	var after int64
+	DB.Model(&models.MealPlan{}).Count(&after)
+	if plans != 3 || after != plans {
+		t.Errorf("%d plans after the migration and %d after running it again, want 3", plans, after)
+	}
+}
@@ -3,7 +3,6 @@ package handlers
 import (
 	"net/http"
 
-	"food-app/database"
 	"food-app/middleware"
 	"food-app/models"
 
@@ -28,7 +27,7 @@ type AuthResponse struct {
 	User  models.User `json:"user"`
 }
 
-func Register(c *gin.Context) {
+func (s *Server) Register(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -36,8 +35,12 @@ func Register(c *gin.Context) {
 	}
 
 	// Check if user already exists
-	var existingUser models.User
-	if !database.DB.Where("email = ? OR username = ?", req.Email, req.Username).First(&existingUser).RecordNotFound() {
+	exists, err := s.repo.UserExists(req.Email, req.Username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		return
+	}
+	if exists {
 		c.JSON(http.StatusConflict, gin.H{"error": "User with this email or username already exists"})
 		return
 	}
@@ -56,7 +59,7 @@ func Register(c *gin.Context) {
 		return
 	}
 
-	if err := database.DB.Create(&user).Error; err != nil {
+	if err := s.repo.CreateUser(&user); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
@@ -74,7 +77,7 @@ func Register(c *gin.Context) {
 	})
 }
 
-func Login(c *gin.Context) {
+func (s *Server) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -82,8 +85,8 @@ func Login(c *gin.Context) {
 	}
 
 	// Find user
-	var user models.User
-	if database.DB.Where("email = ?", req.Email).First(&user).RecordNotFound() {
+	user, err := s.repo.UserByEmail(req.Email)
+	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
@@ -107,11 +110,11 @@ func Login(c *gin.Context) {
 	})
 }
 
-func GetProfile(c *gin.Context) {
+func (s *Server) GetProfile(c *gin.Context) {
 	userID := c.GetUint("userID")
 	
-	var user models.User
-	if database.DB.First(&user, userID).RecordNotFound() {
+	user, err := s.repo.User(userID)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
@@ -119,11 +122,11 @@ func GetProfile(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
-func UpdateProfile(c *gin.Context) {
+func (s *Server) UpdateProfile(c *gin.Context) {
 	userID := c.GetUint("userID")
 	
-	var user models.User
-	if database.DB.First(&user, userID).RecordNotFound() {
+	user, err := s.repo.User(userID)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
@@ -135,7 +138,7 @@ func UpdateProfile(c *gin.Context) {
 	}
 
 	// Update user
-	if err := database.DB.Model(&user).Updates(updateData).Error; err != nil {
+	if err := s.repo.UpdateUser(&user, updateData); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
 		return
 	}
@@ -143,7 +146,7 @@ func UpdateProfile(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
-func UpdatePreferences(c *gin.Context) {
+func (s *Server) UpdatePreferences(c *gin.Context) {
 	userID := c.GetUint("userID")
 	
 	var preferences models.UserPreferences
@@ -160,9 +163,12 @@ func UpdatePreferences(c *gin.Context) {
 		"preferred_meal_types": preferences.PreferredMealTypes,
 		"allergies":           preferences.Allergies,
 		"calorie_goal":        preferences.CalorieGoal,
+		"weekday_time_budget": preferences.WeekdayTimeBudget,
+		"weekend_time_budget": preferences.WeekendTimeBudget,
+		"max_difficulty":      preferences.MaxDifficulty,
 	}
 
-	if err := database.DB.Model(&models.User{}).Where("id = ?", userID).Updates(updates).Error; err != nil {
+	if err := s.repo.UpdateUser(&models.User{ID: userID}, updates); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update preferences"})
 		return
 	}
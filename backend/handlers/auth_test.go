@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"food-app/models"
+)
+
+func TestRegisterAndLogin(t *testing.T) {
+	api := newTestAPI(t)
+
+	token, user := api.register("ann")
+	if token == "" || user.ID == 0 || user.Email != "ann@example.com" {
+		t.Fatalf("register returned token %q, user %+v", token, user)
+	}
+
+	api.expect(http.StatusConflict, "POST", "/register", "", RegisterRequest{
+		Email: "ann@example.com", Username: "other", Password: "secret1",
+	}, nil)
+	api.expect(http.StatusBadRequest, "POST", "/register", "", RegisterRequest{
+		Email: "not-an-email", Username: "bob", Password: "secret1",
+	}, nil)
+
+	var login AuthResponse
+	api.expect(http.StatusOK, "POST", "/login", "", LoginRequest{Email: "ann@example.com", Password: "secret1"}, &login)
+	if login.User.ID != user.ID || login.Token == "" {
+		t.Errorf("login returned %+v", login)
+	}
+	api.expect(http.StatusUnauthorized, "POST", "/login", "", LoginRequest{Email: "ann@example.com", Password: "wrong"}, nil)
+
+	var profile models.User
+	api.expect(http.StatusOK, "GET", "/profile", login.Token, nil, &profile)
+	if profile.ID != user.ID {
+		t.Errorf("profile is user %d, want %d", profile.ID, user.ID)
+	}
+}
+
+func TestProtectedRoutesNeedAValidToken(t *testing.T) {
+	api := newTestAPI(t)
+
+	api.expect(http.StatusUnauthorized, "GET", "/profile", "", nil, nil)
+	api.expect(http.StatusUnauthorized, "GET", "/profile", "not-a-token", nil, nil)
+	api.expect(http.StatusUnauthorized, "GET", "/current-meal-plan", "", nil, nil)
+}
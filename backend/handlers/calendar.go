@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	_ "time/tzdata" // feeds may name any IANA zone, even where the host has no zoneinfo
+
+	"food-app/models"
+	"food-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateCalendarFeedRequest changes feed settings; omitted fields are left as they are
+type UpdateCalendarFeedRequest struct {
+	Timezone      *string `json:"timezone"`
+	BreakfastTime *string `json:"breakfast_time"`
+	LunchTime     *string `json:"lunch_time"`
+	DinnerTime    *string `json:"dinner_time"`
+	SnackTime     *string `json:"snack_time"`
+	MealDuration  *int    `json:"meal_duration" binding:"omitempty,min=5,max=240"`
+	CookReminders *bool   `json:"cook_reminders"`
+}
+
+// CalendarFeedSettings is a feed's settings with its subscription URL
+type CalendarFeedSettings struct {
+	models.CalendarFeed
+	URL string `json:"url"`
+}
+
+// GetCalendarFeed returns the user's calendar feed settings and subscription URL,
+// creating the feed on first use
+func (s *Server) GetCalendarFeed(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	feed, err := s.getOrCreateCalendarFeed(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create calendar feed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, CalendarFeedSettings{CalendarFeed: feed, URL: calendarFeedURL(c, feed)})
+}
+
+// UpdateCalendarFeed sets the time zone, meal times, event length and cook reminders
+func (s *Server) UpdateCalendarFeed(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var req UpdateCalendarFeedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.Timezone != nil {
+		if _, err := time.LoadLocation(*req.Timezone); err != nil || *req.Timezone == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown time zone; use an IANA name such as Europe/Stockholm"})
+			return
+		}
+		updates["timezone"] = *req.Timezone
+	}
+
+	mealTimes := map[string]*string{
+		"breakfast_time": req.BreakfastTime,
+		"lunch_time":     req.LunchTime,
+		"dinner_time":    req.DinnerTime,
+		"snack_time":     req.SnackTime,
+	}
+	for column, value := range mealTimes {
+		if value == nil {
+			continue
+		}
+		if _, err := time.Parse("15:04", *value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time format for " + column + ". Use HH:MM"})
+			return
+		}
+		updates[column] = *value
+	}
+
+	if req.MealDuration != nil {
+		updates["meal_duration"] = *req.MealDuration
+	}
+	if req.CookReminders != nil {
+		updates["cook_reminders"] = *req.CookReminders
+	}
+
+	feed, err := s.getOrCreateCalendarFeed(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create calendar feed"})
+		return
+	}
+
+	if len(updates) > 0 {
+		if err := s.repo.UpdateCalendarFeed(&feed, updates); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update calendar feed"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, CalendarFeedSettings{CalendarFeed: feed, URL: calendarFeedURL(c, feed)})
+}
+
+// RotateCalendarFeedToken replaces the feed's secret, so the old URL stops working
+func (s *Server) RotateCalendarFeedToken(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	feed, err := s.getOrCreateCalendarFeed(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create calendar feed"})
+		return
+	}
+
+	token, err := invitationToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create calendar token"})
+		return
+	}
+
+	if err := s.repo.UpdateCalendarFeed(&feed, map[string]interface{}{"token": token}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update calendar feed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, CalendarFeedSettings{CalendarFeed: feed, URL: calendarFeedURL(c, feed)})
+}
+
+// ServeCalendarFeed serves the current meal plan as iCalendar to anyone holding
+// the feed's secret token. Each entry is a VEVENT at its meal type's time, with an
+// alarm when cooking should start.
+func (s *Server) ServeCalendarFeed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	feed, err := s.repo.CalendarFeedByToken(token)
+	if token == "" || err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Calendar feed not found"})
+		return
+	}
+
+	// Without an active plan the feed is an empty calendar
+	mealPlan, _ := s.repo.PlanWithMeals(s.activeMealPlanID(feed.UserID))
+
+	var body bytes.Buffer
+	if err := services.WriteMealCalendar(&body, "Meal plan", calendarEvents(feed, mealPlan)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build calendar feed"})
+		return
+	}
+
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", body.Bytes())
+}
+
+// calendarEvents turns a plan's entries into timed events in the feed's time zone
+func calendarEvents(feed models.CalendarFeed, mealPlan models.MealPlan) []services.CalendarEvent {
+	location := feed.Location()
+	year, month, day := mealPlan.WeekStart.Date()
+	duration := time.Duration(feed.MealDuration) * time.Minute
+
+	events := make([]services.CalendarEvent, 0, len(mealPlan.Meals))
+	for _, entry := range mealPlan.Meals {
+		dayIndex, ok := models.DayIndex(entry.Day)
+		if !ok {
+			continue
+		}
+		mealTime, err := time.Parse("15:04", feed.MealTime(entry.MealType))
+		if err != nil {
+			continue
+		}
+
+		start := time.Date(year, month, day+dayIndex, mealTime.Hour(), mealTime.Minute(), 0, 0, location)
+		event := services.CalendarEvent{
+			UID:     fmt.Sprintf("plan-entry-%d@food-app", entry.ID),
+			Summary: mealTypeLabel(entry.MealType) + ": " + entry.Meal.Name,
+			Start:   start,
+			End:     start.Add(duration),
+			Updated: mealPlan.UpdatedAt,
+		}
+
+		activeTime := entry.Meal.ActiveTime()
+		if entry.IsLeftover() {
+			event.Summary = mealTypeLabel(entry.MealType) + ": " + entry.Meal.Name + " (leftovers)"
+			event.Description = "Leftovers, nothing to cook"
+		} else {
+			event.Description = fmt.Sprintf("Prep %d min, cook %d min", entry.Meal.PrepTime, entry.Meal.CookTime)
+			if feed.CookReminders && activeTime > 0 {
+				event.ReminderBefore = time.Duration(activeTime) * time.Minute
+				event.ReminderText = "Start cooking " + entry.Meal.Name
+			}
+		}
+
+		events = append(events, event)
+	}
+	return events
+}
+
+func mealTypeLabel(mealType string) string {
+	if mealType == "" {
+		return "Meal"
+	}
+	return strings.ToUpper(mealType[:1]) + mealType[1:]
+}
+
+func (s *Server) getOrCreateCalendarFeed(userID uint) (models.CalendarFeed, error) {
+	feed, err := s.repo.CalendarFeed(userID)
+	if !isNotFound(err) {
+		return feed, err
+	}
+
+	token, err := invitationToken()
+	if err != nil {
+		return feed, err
+	}
+
+	feed = models.CalendarFeed{UserID: userID, Token: token}
+	err = s.repo.CreateCalendarFeed(&feed)
+	return feed, err
+}
+
+// calendarFeedURL is the address calendar apps subscribe to
+func calendarFeedURL(c *gin.Context, feed models.CalendarFeed) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if forwarded := c.GetHeader("X-Forwarded-Proto"); forwarded != "" {
+		scheme = forwarded
+	}
+	return fmt.Sprintf("%s://%s/api/v1/calendar/%s.ics", scheme, c.Request.Host, feed.Token)
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"food-app/models"
+)
+
+// feedPath is the API path of a feed, taken from its subscription URL
+func feedPath(t *testing.T, settings CalendarFeedSettings) string {
+	t.Helper()
+
+	_, path, ok := strings.Cut(settings.URL, "/api/v1")
+	if !ok || !strings.HasSuffix(path, ".ics") {
+		t.Fatalf("feed URL %q", settings.URL)
+	}
+	return path
+}
+
+// calendarEventsIn unfolds an iCalendar body and returns each VEVENT's lines
+func calendarEventsIn(body string) [][]string {
+	var events [][]string
+	var current []string
+	for _, line := range strings.Split(strings.ReplaceAll(body, "\r\n ", ""), "\r\n") {
+		switch {
+		case line == "BEGIN:VEVENT":
+			current = []string{}
+		case line == "END:VEVENT":
+			events = append(events, current)
+			current = nil
+		case current != nil:
+			current = append(current, line)
+		}
+	}
+	return events
+}
+
+// eventProperty returns the first value of a property in an event's lines
+func eventProperty(event []string, name string) string {
+	for _, line := range event {
+		if value, ok := strings.CutPrefix(line, name+":"); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+func TestCalendarFeedServesThePlanInTheFeedsZone(t *testing.T) {
+	api := newTestAPI(t)
+	meals := api.seedMeals()
+	token, _ := api.register("ann")
+	api.likeAll(token, meals)
+
+	var cook models.Meal
+	for _, meal := range meals {
+		if meal.MealType == "dinner" {
+			cook = meal
+			break
+		}
+	}
+	api.store.DB().Model(&cook).Updates(map[string]interface{}{"prep_time": 15, "cook_time": 40})
+
+	var plan models.MealPlan
+	api.expect(http.StatusOK, "GET", "/current-meal-plan", token, nil, &plan)
+	api.expect(http.StatusOK, "PUT", "/current-meal-plan/meals", token, payload{
+		"day": "monday", "meal_type": "dinner", "meal_id": cook.ID,
+	}, nil)
+	api.expect(http.StatusOK, "POST", "/current-meal-plan/leftovers", token, ScheduleLeftoverRequest{
+		SourceDay: "monday", SourceMealType: "dinner", Day: "tuesday", MealType: "lunch",
+	}, nil)
+
+	var settings CalendarFeedSettings
+	api.expect(http.StatusOK, "PUT", "/profile/calendar", token, payload{
+		"timezone": "Europe/Stockholm", "dinner_time": "18:30", "lunch_time": "11:45", "meal_duration": 60,
+	}, &settings)
+
+	rec := api.expect(http.StatusOK, "GET", feedPath(t, settings), "", nil, nil)
+	if contentType := rec.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/calendar") {
+		t.Errorf("content type %q", contentType)
+	}
+	events := calendarEventsIn(rec.Body.String())
+	if len(events) != 2 {
+		t.Fatalf("feed has %d events, want 2:\n%s", len(events), rec.Body.String())
+	}
+
+	stockholm, err := time.LoadLocation("Europe/Stockholm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	year, month, day := plan.WeekStart.Date()
+	at := func(dayOffset, hour, minute int) string {
+		return time.Date(year, month, day+dayOffset, hour, minute, 0, 0, stockholm).UTC().Format("20060102T150405Z")
+	}
+
+	dinner, lunch := events[0], events[1]
+	if strings.HasPrefix(eventProperty(dinner, "SUMMARY"), "Lunch") {
+		dinner, lunch = lunch, dinner
+	}
+	if got := eventProperty(dinner, "DTSTART"); got != at(0, 18, 30) {
+		t.Errorf("dinner starts %s, want %s", got, at(0, 18, 30))
+	}
+	if got := eventProperty(dinner, "DTEND"); got != at(0, 19, 30) {
+		t.Errorf("dinner ends %s, want %s", got, at(0, 19, 30))
+	}
+	if got := eventProperty(lunch, "DTSTART"); got != at(1, 11, 45) {
+		t.Errorf("leftover lunch starts %s, want %s", got, at(1, 11, 45))
+	}
+
+	// Cooking starts prep plus cook time before dinner; leftovers need no reminder
+	if got := eventProperty(dinner, "TRIGGER"); got != "-PT55M" {
+		t.Errorf("dinner reminder %q, want -PT55M", got)
+	}
+	if !strings.Contains(eventProperty(lunch, "SUMMARY"), "(leftovers)") || eventProperty(lunch, "TRIGGER") != "" {
+		t.Errorf("leftover event %q", lunch)
+	}
+
+	api.expect(http.StatusOK, "PUT", "/profile/calendar", token, payload{"cook_reminders": false}, nil)
+	rec = api.expect(http.StatusOK, "GET", feedPath(t, settings), "", nil, nil)
+	if strings.Contains(rec.Body.String(), "BEGIN:VALARM") {
+		t.Error("reminders are still set after turning them off")
+	}
+}
+
+func TestCalendarFeedNeedsTheCurrentToken(t *testing.T) {
+	api := newTestAPI(t)
+	token, _ := api.register("ann")
+
+	var settings CalendarFeedSettings
+	api.expect(http.StatusOK, "GET", "/profile/calendar", token, nil, &settings)
+	oldPath := feedPath(t, settings)
+	api.expect(http.StatusOK, "GET", oldPath, "", nil, nil)
+
+	api.expect(http.StatusNotFound, "GET", "/calendar/not-a-token.ics", "", nil, nil)
+	api.expect(http.StatusNotFound, "GET", "/calendar/.ics", "", nil, nil)
+
+	var rotated CalendarFeedSettings
+	api.expect(http.StatusOK, "POST", "/profile/calendar/token", token, nil, &rotated)
+	if rotated.URL == settings.URL {
+		t.Fatal("rotating kept the feed URL")
+	}
+	api.expect(http.StatusNotFound, "GET", oldPath, "", nil, nil)
+	api.expect(http.StatusOK, "GET", feedPath(t, rotated), "", nil, nil)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"food-app/repository"
+	"food-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// catalogContentTypes maps each catalog format to its content type
+var catalogContentTypes = map[string]string{
+	services.CatalogJSON: "application/json; charset=utf-8",
+	services.CatalogCSV:  "text/csv; charset=utf-8",
+}
+
+// ExportCatalog downloads every meal and ingredient as a JSON or CSV catalog
+func (s *Server) ExportCatalog(c *gin.Context) {
+	format := c.DefaultQuery("format", services.CatalogJSON)
+	contentType, ok := catalogContentTypes[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+		return
+	}
+
+	catalog, err := s.repo.ExportCatalog()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read catalog"})
+		return
+	}
+
+	var body bytes.Buffer
+	if err := services.WriteCatalog(&body, format, catalog); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export catalog"})
+		return
+	}
+
+	filename := fmt.Sprintf("catalog-%s.%s", time.Now().Format("2006-01-02"), format)
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, contentType, body.Bytes())
+}
+
+// ImportCatalog upserts meals and ingredients from a JSON or CSV catalog in the
+// request body. Invalid catalogs are rejected whole with every problem listed;
+// dry_run=true reports what would change without saving.
+func (s *Server) ImportCatalog(c *gin.Context) {
+	format := c.DefaultQuery("format", services.CatalogJSON)
+	if _, ok := catalogContentTypes[format]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+		return
+	}
+	dryRun := c.Query("dry_run") == "true"
+
+	catalog, err := services.ReadCatalog(c.Request.Body, format)
+	var result repository.CatalogImportResult
+	if err == nil {
+		result, err = s.repo.ImportCatalog(catalog, dryRun)
+	}
+
+	var invalid *services.CatalogError
+	if errors.As(err, &invalid) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid catalog", "problems": invalid.Problems})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import catalog"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"net/http"
+
+	"food-app/models"
+	"food-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DayCookingTime is the active cooking time of one day against the user's budget
+type DayCookingTime struct {
+	Day        string `json:"day"`
+	Minutes    int    `json:"minutes"`
+	Budget     int    `json:"budget"` // 0 means no limit
+	OverBudget bool   `json:"over_budget"`
+}
+
+// PlanCookingTime summarizes a plan's active cooking time
+type PlanCookingTime struct {
+	Days          []DayCookingTime `json:"days"`
+	TotalMinutes  int              `json:"total_minutes"`
+	MaxDifficulty string           `json:"max_difficulty"`
+}
+
+// GetCurrentPlanCookingTime reports active cooking minutes per day of the current plan
+func (s *Server) GetCurrentPlanCookingTime(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	user, err := s.repo.User(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	mealPlan, err := s.repo.PlanWithMeals(s.activeMealPlanID(userID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No active meal plan found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, calculateCookingTime(mealPlan.Meals, user))
+}
+
+// GetMealPlanCookingTime reports active cooking minutes per day of a saved plan
+func (s *Server) GetMealPlanCookingTime(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	user, err := s.repo.User(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	mealPlan, err := s.repo.PlanWithMeals(parseUint(c.Param("id")))
+	if err != nil || mealPlan.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Meal plan not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, calculateCookingTime(mealPlan.Meals, user))
+}
+
+// calculateCookingTime sums each day's active time against the user's budgets.
+// Leftover entries need no cooking and are not counted.
+func calculateCookingTime(entries []models.MealPlanEntry, user models.User) PlanCookingTime {
+	minutes := map[string]int{}
+	for _, entry := range entries {
+		if !entry.IsLeftover() {
+			minutes[entry.Day] += entry.Meal.ActiveTime()
+		}
+	}
+
+	budgets := user.TimeBudgets(services.PlanDays)
+	summary := PlanCookingTime{
+		Days:          make([]DayCookingTime, 0, len(services.PlanDays)),
+		MaxDifficulty: user.MaxDifficulty,
+	}
+	for _, day := range services.PlanDays {
+		budget := budgets[day]
+		summary.Days = append(summary.Days, DayCookingTime{
+			Day:        day,
+			Minutes:    minutes[day],
+			Budget:     budget,
+			OverBudget: budget > 0 && minutes[day] > budget,
+		})
+		summary.TotalMinutes += minutes[day]
+	}
+
+	return summary
+}
+
+// applyCookingPreferences fills the planner's time budgets and difficulty cap from
+// the user's preferences unless the request set them
+func (s *Server) applyCookingPreferences(userID uint, options *services.PlannerOptions) {
+	user, err := s.repo.User(userID)
+	if err != nil {
+		return
+	}
+
+	if options.TimeBudgets == nil {
+		options.TimeBudgets = user.TimeBudgets(services.PlanDays)
+	}
+	if options.MaxDifficulty == "" {
+		options.MaxDifficulty = user.MaxDifficulty
+	}
+}
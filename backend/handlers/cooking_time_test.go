@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"food-app/models"
+	"food-app/services"
+)
+
+func TestPlannerHonorsCookingPreferences(t *testing.T) {
+	api := newTestAPI(t)
+	meals := api.seedMeals()
+	token, _ := api.register("ann")
+	api.likeAll(token, meals)
+
+	// Indian meals take 90 minutes and Thai ones are hard; the rest take 20
+	db := api.store.DB()
+	db.Model(&models.Meal{}).Where("cuisine = ?", "indian").Updates(map[string]interface{}{"prep_time": 30, "cook_time": 60})
+	db.Model(&models.Meal{}).Where("cuisine = ?", "thai").Update("difficulty", models.DifficultyHard)
+	db.Model(&models.Meal{}).Where("cuisine NOT IN ?", []string{"indian", "thai"}).Update("difficulty", models.DifficultyEasy)
+
+	api.expect(http.StatusBadRequest, "PUT", "/profile/preferences", token, payload{"max_difficulty": "expert"}, nil)
+	api.expect(http.StatusBadRequest, "PUT", "/profile/preferences", token, payload{"weekday_time_budget": -1}, nil)
+	api.expect(http.StatusOK, "PUT", "/profile/preferences", token, models.UserPreferences{
+		WeekdayTimeBudget: 60, MaxDifficulty: models.DifficultyMedium,
+	}, nil)
+
+	var plan models.MealPlan
+	api.expect(http.StatusOK, "GET", "/current-meal-plan", token, nil, &plan)
+	seed := int64(7)
+	var regenerated PlanWithAlternatives
+	api.expect(http.StatusOK, "POST", "/current-meal-plan/regenerate", token, RegeneratePlanRequest{services.PlannerOptions{
+		Seed: &seed, MaxRepeatsPerWeek: 7, AllowConsecutiveDays: true,
+	}}, &regenerated)
+
+	minutes := map[string]int{}
+	for _, entry := range regenerated.MealPlan.Meals {
+		if entry.Meal.Cuisine == "thai" {
+			t.Errorf("%s %s is the hard %q", entry.Day, entry.MealType, entry.Meal.Name)
+		}
+		if entry.Meal.Cuisine == "indian" && !models.IsWeekend(entry.Day) {
+			t.Errorf("%s %s is the 90 minute %q", entry.Day, entry.MealType, entry.Meal.Name)
+		}
+		minutes[entry.Day] += entry.Meal.ActiveTime()
+	}
+
+	var cooking PlanCookingTime
+	api.expect(http.StatusOK, "GET", "/current-meal-plan/cooking-time", token, nil, &cooking)
+	if len(cooking.Days) != len(services.PlanDays) || cooking.MaxDifficulty != models.DifficultyMedium {
+		t.Fatalf("cooking time = %+v", cooking)
+	}
+	total := 0
+	for _, day := range cooking.Days {
+		wantBudget := 60
+		if models.IsWeekend(day.Day) {
+			wantBudget = 0
+		}
+		if day.Minutes != minutes[day.Day] || day.Budget != wantBudget || day.OverBudget {
+			t.Errorf("%s = %+v, want %d of %d minutes", day.Day, day, minutes[day.Day], wantBudget)
+		}
+		total += day.Minutes
+	}
+	if cooking.TotalMinutes != total {
+		t.Errorf("total = %d, want %d", cooking.TotalMinutes, total)
+	}
+
+	// A meal picked by hand can break the budget, which the report flags
+	var slow models.Meal
+	db.Where("cuisine = ? AND meal_type = ?", "indian", "dinner").First(&slow)
+	api.expect(http.StatusOK, "PUT", "/current-meal-plan/meals", token, payload{
+		"day": "monday", "meal_type": "dinner", "meal_id": slow.ID,
+	}, nil)
+	api.expect(http.StatusOK, "GET", fmt.Sprintf("/meal-plans/%d/cooking-time", plan.ID), token, nil, &cooking)
+	if monday := cooking.Days[0]; monday.Day != "monday" || !monday.OverBudget || monday.Minutes < 90 {
+		t.Errorf("monday = %+v, want over its 60 minute budget", monday)
+	}
+
+	other, _ := api.register("bob")
+	api.expect(http.StatusNotFound, "GET", fmt.Sprintf("/meal-plans/%d/cooking-time", plan.ID), other, nil, nil)
+}
+
+func TestLeftoversTakeNoCookingTime(t *testing.T) {
+	api := newTestAPI(t)
+	meals := api.seedMeals()
+	token, _ := api.register("ann")
+	api.expect(http.StatusOK, "GET", "/current-meal-plan", token, nil, nil)
+
+	var dinner models.Meal
+	for _, meal := range meals {
+		if meal.MealType == "dinner" {
+			dinner = meal
+			break
+		}
+	}
+	api.expect(http.StatusOK, "PUT", "/current-meal-plan/meals", token, payload{
+		"day": "monday", "meal_type": "dinner", "meal_id": dinner.ID,
+	}, nil)
+	api.expect(http.StatusOK, "POST", "/current-meal-plan/leftovers", token, ScheduleLeftoverRequest{
+		SourceDay: "monday", SourceMealType: "dinner", Day: "tuesday", MealType: "lunch",
+	}, nil)
+
+	var cooking PlanCookingTime
+	api.expect(http.StatusOK, "GET", "/current-meal-plan/cooking-time", token, nil, &cooking)
+	if cooking.Days[0].Minutes != 20 || cooking.Days[1].Minutes != 0 || cooking.TotalMinutes != 20 {
+		t.Errorf("cooking time = %+v, want 20 minutes on monday only", cooking.Days[:2])
+	}
+	if cooking.Days[0].Budget != 0 || cooking.Days[0].OverBudget {
+		t.Errorf("monday = %+v, want no budget without preferences", cooking.Days[0])
+	}
+}
@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"food-app/models"
+	"food-app/repository"
+	"food-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AddIngredientPriceRequest struct {
+	Price      float64 `json:"price" binding:"required,gt=0"`
+	Quantity   float64 `json:"quantity" binding:"required,gt=0"`
+	Unit       string  `json:"unit" binding:"required"`
+	Currency   string  `json:"currency"` // must be models.PriceCurrency when given
+	Store      string  `json:"store"`
+	ObservedAt string  `json:"observed_at"` // YYYY-MM-DD, defaults to today
+}
+
+// MealCost is the estimated cost of cooking a whole recipe
+type MealCost struct {
+	MealID             uint     `json:"meal_id"`
+	Currency           string   `json:"currency"`
+	Total              float64  `json:"total"`
+	PerServing         float64  `json:"per_serving"`
+	MissingIngredients []string `json:"missing_ingredients"` // no price, or price in an incompatible unit
+}
+
+// EntryCost is the estimated cost of one plan entry; leftovers cost nothing extra
+type EntryCost struct {
+	EntryID  uint    `json:"entry_id"`
+	Day      string  `json:"day"`
+	MealType string  `json:"meal_type"`
+	MealID   uint    `json:"meal_id"`
+	Cost     float64 `json:"cost"`
+}
+
+// PlanCost summarizes the estimated cost of a plan
+type PlanCost struct {
+	Currency           string             `json:"currency"`
+	Total              float64            `json:"total"`
+	ByDay              map[string]float64 `json:"by_day"`
+	Entries            []EntryCost        `json:"entries"`
+	ShoppingListTotal  float64            `json:"shopping_list_total"`
+	MissingIngredients []string           `json:"missing_ingredients"`
+}
+
+// AddIngredientPrice records an observed price for an ingredient. Prices are shared
+// by everyone's estimates, so only catalog editors may record them.
+func (s *Server) AddIngredientPrice(c *gin.Context) {
+	userID := c.GetUint("userID")
+	ingredientID := parseUint(c.Param("id"))
+
+	var req AddIngredientPriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ingredient, err := s.repo.Ingredient(ingredientID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ingredient not found"})
+		return
+	}
+
+	observedAt := time.Now()
+	if req.ObservedAt != "" {
+		parsed, err := time.Parse("2006-01-02", req.ObservedAt)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+			return
+		}
+		observedAt = parsed
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	if currency == "" {
+		currency = models.PriceCurrency
+	}
+	if currency != models.PriceCurrency {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Prices are recorded in " + models.PriceCurrency})
+		return
+	}
+
+	price := models.IngredientPrice{
+		IngredientID: ingredient.ID,
+		Price:        req.Price,
+		Quantity:     req.Quantity,
+		Unit:         services.NormalizeUnit(req.Unit),
+		Currency:     currency,
+		Store:        req.Store,
+		ObservedAt:   observedAt,
+		CreatedByID:  userID,
+	}
+
+	if err := s.repo.CreatePrice(&price); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add price"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, price)
+}
+
+// GetIngredientPrices lists known prices for an ingredient, newest first
+func (s *Server) GetIngredientPrices(c *gin.Context) {
+	prices, err := s.repo.IngredientPrices(parseUint(c.Param("id")), c.Query("store"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prices"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"prices": prices})
+}
+
+// GetMealCost estimates the cost of a meal from the latest ingredient prices
+func (s *Server) GetMealCost(c *gin.Context) {
+	mealID := parseUint(c.Param("id"))
+
+	meal, err := s.repo.Meal(mealID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Meal not found"})
+		return
+	}
+
+	costs, err := s.estimateMealCosts([]models.Meal{meal}, c.Query("store"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to estimate meal cost"})
+		return
+	}
+	c.JSON(http.StatusOK, costs[meal.ID])
+}
+
+// GetCurrentPlanCost estimates the cost of the current plan per entry, per day and in total
+func (s *Server) GetCurrentPlanCost(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	mealPlan, err := s.repo.PlanWithShoppingList(s.activeMealPlanID(userID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No active meal plan found"})
+		return
+	}
+
+	meals := make([]models.Meal, 0, len(mealPlan.Meals))
+	for _, entry := range mealPlan.Meals {
+		meals = append(meals, entry.Meal)
+	}
+	mealCosts, err := s.estimateMealCosts(meals, c.Query("store"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to estimate plan cost"})
+		return
+	}
+
+	summary := PlanCost{
+		Currency:           models.PriceCurrency,
+		ByDay:              map[string]float64{},
+		Entries:            []EntryCost{},
+		MissingIngredients: []string{},
+	}
+	missing := map[string]bool{}
+
+	cookScales := models.CookScales(mealPlan.Meals, mealPlan.HouseholdSize)
+	for _, entry := range mealPlan.Meals {
+		mealCost := mealCosts[entry.MealID]
+		cost := mealCost.Total * cookScales[entry.ID]
+
+		summary.Entries = append(summary.Entries, EntryCost{
+			EntryID:  entry.ID,
+			Day:      entry.Day,
+			MealType: entry.MealType,
+			MealID:   entry.MealID,
+			Cost:     cost,
+		})
+		summary.ByDay[entry.Day] += cost
+		summary.Total += cost
+
+		for _, name := range mealCost.MissingIngredients {
+			if !missing[name] {
+				missing[name] = true
+				summary.MissingIngredients = append(summary.MissingIngredients, name)
+			}
+		}
+	}
+
+	if mealPlan.ShoppingList != nil {
+		summary.ShoppingListTotal = mealPlan.ShoppingList.EstimatedTotal
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// estimateMealCosts prices each meal's full recipe from its ingredient quantities
+func (s *Server) estimateMealCosts(meals []models.Meal, store string) (map[uint]MealCost, error) {
+	costs := map[uint]MealCost{}
+
+	mealIDs := make([]uint, 0, len(meals))
+	servings := map[uint]int{}
+	for _, meal := range meals {
+		if _, seen := servings[meal.ID]; !seen {
+			mealIDs = append(mealIDs, meal.ID)
+		}
+		servings[meal.ID] = meal.Servings
+	}
+	if len(mealIDs) == 0 {
+		return costs, nil
+	}
+
+	mealIngredients, err := s.repo.MealIngredients(mealIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	ingredientIDs := make([]uint, 0, len(mealIngredients))
+	for _, mealIngredient := range mealIngredients {
+		ingredientIDs = append(ingredientIDs, mealIngredient.IngredientID)
+	}
+	prices, err := s.repo.LatestPrices(ingredientIDs, store)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, mealID := range mealIDs {
+		costs[mealID] = MealCost{MealID: mealID, Currency: models.PriceCurrency, MissingIngredients: []string{}}
+	}
+
+	for _, mealIngredient := range mealIngredients {
+		cost := costs[mealIngredient.MealID]
+
+		price, known := prices[mealIngredient.IngredientID]
+		amount, ok := repository.PriceFor(mealIngredient.Quantity, mealIngredient.Unit, price)
+		if !known || !ok {
+			cost.MissingIngredients = append(cost.MissingIngredients, mealIngredient.Ingredient.Name)
+		} else {
+			cost.Total += amount
+		}
+
+		costs[mealIngredient.MealID] = cost
+	}
+
+	for mealID, cost := range costs {
+		if servings[mealID] > 0 {
+			cost.PerServing = cost.Total / float64(servings[mealID])
+		}
+		costs[mealID] = cost
+	}
+
+	return costs, nil
+}
+
+// mealCostsPerServing returns the per-serving cost of each meal, for the planner's budget
+func (s *Server) mealCostsPerServing(meals []models.Meal) (map[uint]float64, error) {
+	costs, err := s.estimateMealCosts(meals, "")
+	if err != nil {
+		return nil, err
+	}
+	perServing := map[uint]float64{}
+	for mealID, cost := range costs {
+		perServing[mealID] = cost.PerServing
+	}
+	return perServing, nil
+}
+
+// setPlannerBudgetHeaders reports the planner's cost estimate when a budget was requested
+func setPlannerBudgetHeaders(c *gin.Context, options services.PlannerOptions, result services.PlanResult) {
+	if options.WeeklyBudget <= 0 {
+		return
+	}
+	c.Header("X-Plan-Estimated-Cost", strconv.FormatFloat(result.EstimatedCost, 'f', 2, 64))
+	c.Header("X-Plan-Over-Budget", strconv.FormatBool(result.OverBudget))
+}
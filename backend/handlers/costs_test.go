@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"food-app/models"
+)
+
+// ingredientIDs maps the names of a meal's ingredients to their IDs
+func ingredientIDs(t *testing.T, api *testAPI, meal models.Meal) map[string]uint {
+	t.Helper()
+
+	lines, err := api.store.MealIngredients([]uint{meal.ID})
+	if err != nil {
+		t.Fatalf("meal ingredients: %v", err)
+	}
+	ids := map[string]uint{}
+	for _, line := range lines {
+		ids[line.Ingredient.Name] = line.IngredientID
+	}
+	return ids
+}
+
+func TestOnlyCatalogEditorsRecordPrices(t *testing.T) {
+	api := newTestAPI(t)
+	meals := api.seedMeals()
+	user, _ := api.register("ann")
+	editor, _ := api.register("editor")
+	rice := ingredientIDs(t, api, meals[0])["Rice"]
+
+	path := fmt.Sprintf("/ingredients/%d/prices", rice)
+	price := AddIngredientPriceRequest{Price: 3, Quantity: 1, Unit: "cup"}
+	api.expect(http.StatusUnauthorized, "POST", path, "", price, nil)
+	api.expect(http.StatusForbidden, "POST", path, user, price, nil)
+
+	var created models.IngredientPrice
+	api.expect(http.StatusCreated, "POST", path, editor, price, &created)
+	if created.Currency != models.PriceCurrency || created.IngredientID != rice {
+		t.Errorf("created %+v", created)
+	}
+
+	// Estimates add prices up, so they must share a currency
+	api.expect(http.StatusBadRequest, "POST", path, editor, AddIngredientPriceRequest{Price: 2, Quantity: 1, Unit: "cup", Currency: "eur"}, nil)
+	api.expect(http.StatusCreated, "POST", path, editor, AddIngredientPriceRequest{Price: 4, Quantity: 1, Unit: "cup", Currency: "usd"}, nil)
+	api.expect(http.StatusNotFound, "POST", "/ingredients/999/prices", editor, price, nil)
+
+	var listed struct {
+		Prices []models.IngredientPrice `json:"prices"`
+	}
+	api.expect(http.StatusOK, "GET", path, "", nil, &listed)
+	if len(listed.Prices) != 2 {
+		t.Errorf("listed %d prices, want the 2 recorded", len(listed.Prices))
+	}
+}
+
+func TestCostsIgnorePricesInOtherCurrencies(t *testing.T) {
+	api := newTestAPI(t)
+	meals := api.seedMeals()
+	token, _ := api.register("ann")
+	editor, _ := api.register("editor")
+	api.likeAll(token, meals)
+
+	meal := meals[0]
+	protein, rice := ingredientIDs(t, api, meal)["Protein 0"], ingredientIDs(t, api, meal)["Rice"]
+
+	api.expect(http.StatusCreated, "POST", fmt.Sprintf("/ingredients/%d/prices", protein), editor,
+		AddIngredientPriceRequest{Price: 8, Quantity: 1, Unit: "lb", ObservedAt: "2026-10-01"}, nil)
+	api.expect(http.StatusCreated, "POST", fmt.Sprintf("/ingredients/%d/prices", rice), editor,
+		AddIngredientPriceRequest{Price: 2, Quantity: 1, Unit: "cup", ObservedAt: "2026-10-01"}, nil)
+
+	// A newer price recorded in another currency, e.g. before prices were restricted
+	euros := models.IngredientPrice{
+		IngredientID: protein, Price: 900, Quantity: 1, Unit: "lb", Currency: "EUR", ObservedAt: time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC),
+	}
+	if err := api.store.DB().Create(&euros).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var cost MealCost
+	api.expect(http.StatusOK, "GET", fmt.Sprintf("/meals/%d/cost", meal.ID), token, nil, &cost)
+	if cost.Currency != models.PriceCurrency || cost.Total != 10 || cost.PerServing != 5 || len(cost.MissingIngredients) != 0 {
+		t.Errorf("meal cost = %+v, want 10 USD, 5 per serving", cost)
+	}
+
+	// The plan cooks the recipe's 2 servings for a household of 4, twice over
+	api.expect(http.StatusOK, "GET", "/current-meal-plan", token, nil, nil)
+	api.expect(http.StatusOK, "PUT", "/current-meal-plan/settings", token, UpdatePlanSettingsRequest{HouseholdSize: 4}, nil)
+	api.expect(http.StatusOK, "PUT", "/current-meal-plan/meals", token, payload{
+		"day": "monday", "meal_type": "dinner", "meal_id": meal.ID,
+	}, nil)
+	api.expect(http.StatusOK, "PUT", "/current-meal-plan/meals", token, payload{
+		"day": "tuesday", "meal_type": "dinner", "meal_id": meals[3].ID,
+	}, nil)
+
+	var plan PlanCost
+	api.expect(http.StatusOK, "GET", "/current-meal-plan/cost", token, nil, &plan)
+	if plan.Currency != models.PriceCurrency || plan.ByDay["monday"] != 20 {
+		t.Errorf("plan cost = %+v, want 20 USD on monday", plan)
+	}
+	// Tuesday's protein has no price, so only its 4 cups of rice count
+	if plan.ByDay["tuesday"] != 4 || plan.Total != 24 || len(plan.MissingIngredients) != 1 || plan.MissingIngredients[0] != "Protein 1" {
+		t.Errorf("plan cost %+v missing %q, want 24 with only tuesday's protein unpriced", plan.ByDay, plan.MissingIngredients)
+	}
+	if plan.ShoppingListTotal != 24 {
+		t.Errorf("shopping list total = %v, want 24", plan.ShoppingListTotal)
+	}
+}
@@ -1,146 +1,176 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
-	"math/rand"
 
-	"food-app/database"
 	"food-app/models"
+	"food-app/repository"
+	"food-app/services"
 
 	"github.com/gin-gonic/gin"
 )
 
 // GetCurrentMealPlan gets the user's single active meal plan
-func GetCurrentMealPlan(c *gin.Context) {
+func (s *Server) GetCurrentMealPlan(c *gin.Context) {
 	userID := c.GetUint("userID")
 
-	var mealPlan models.CurrentMealPlan
-	if database.DB.Preload("Meals").Preload("Meals.Meal").Preload("Meals.Meal.Ingredients").
-		Preload("ShoppingList").Preload("ShoppingList.Items").Preload("ShoppingList.Items.Ingredient").
-		Where("user_id = ?", userID).First(&mealPlan).RecordNotFound() {
-		
-		// If no current meal plan exists, create one for this week
-		weekStart := getCurrentWeekStart()
-		mealPlan = models.CurrentMealPlan{
-			UserID:    userID,
-			WeekStart: weekStart,
-		}
-		
-		if err := database.DB.Create(&mealPlan).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create meal plan"})
-			return
-		}
+	mealPlan, err := s.getOrCreateActivePlan(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create meal plan"})
+		return
 	}
 
+	mealPlan, err = s.repo.PlanWithShoppingList(mealPlan.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load meal plan"})
+		return
+	}
+
+	// Clients pass this to the event stream to receive changes made after this snapshot
+	c.Header("X-Event-ID", s.events.LastEventID(planTopic(mealPlan.ID)))
 	c.JSON(http.StatusOK, mealPlan)
 }
 
-// PopulateFromLikedMeals auto-populates the current meal plan with liked meals
-func PopulateFromLikedMeals(c *gin.Context) {
+type SetActivePlanRequest struct {
+	MealPlanID uint `json:"meal_plan_id" binding:"required"`
+}
+
+// SetActiveMealPlan makes another of the plan owner's plans the current one.
+// In a household only the owner and admins can switch the shared plan.
+func (s *Server) SetActiveMealPlan(c *gin.Context) {
 	userID := c.GetUint("userID")
 
-	// Get or create current meal plan
-	var mealPlan models.CurrentMealPlan
-	if database.DB.Where("user_id = ?", userID).First(&mealPlan).RecordNotFound() {
-		weekStart := getCurrentWeekStart()
-		mealPlan = models.CurrentMealPlan{
-			UserID:    userID,
-			WeekStart: weekStart,
-		}
-		
-		if err := database.DB.Create(&mealPlan).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create meal plan"})
-			return
-		}
+	var req SetActivePlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	// Get user's liked meals
-	var interactions []models.UserMealInteraction
-	if err := database.DB.Preload("Meal").Preload("Meal.Ingredients").
-		Where("user_id = ? AND liked = true", userID).Find(&interactions).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch liked meals"})
+	if membership, ok := s.householdMembership(userID); ok && !membership.CanManage() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the household owner or an admin can switch the shared plan"})
 		return
 	}
 
-	if len(interactions) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No liked meals found. Please like some meals first."})
+	mealPlan, err := s.repo.Plan(req.MealPlanID)
+	if err != nil || mealPlan.UserID != s.currentPlanOwnerID(userID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Meal plan not found"})
 		return
 	}
 
-	// Clear existing meals
-	database.DB.Where("meal_plan_id = ?", mealPlan.ID).Delete(&models.MealPlanEntry{})
+	previousID := s.activeMealPlanID(userID)
+	householdID := s.currentHouseholdID(userID)
 
-	// Separate meals by type
-	mealsByType := map[string][]models.Meal{
-		"breakfast": {},
-		"lunch":     {},
-		"dinner":    {},
+	err = s.repo.Transaction(func(tx repository.Repository) error {
+		if err := tx.ActivatePlan(mealPlan); err != nil {
+			return err
+		}
+		if householdID == nil {
+			return nil
+		}
+		// Switching a household's plan shares the new one and unshares the old one
+		if previousID != 0 && previousID != mealPlan.ID {
+			if err := tx.SharePlan(previousID, nil); err != nil {
+				return err
+			}
+		}
+		return tx.SharePlan(mealPlan.ID, householdID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to switch meal plan"})
+		return
+	}
+
+	mealPlan, _ = s.repo.PlanWithShoppingList(mealPlan.ID)
+
+	if previousID != 0 && previousID != mealPlan.ID {
+		s.publishPlanEvent(previousID, EventPlanDeactivated, gin.H{"active_meal_plan_id": mealPlan.ID})
 	}
 
-	for _, interaction := range interactions {
-		meal := interaction.Meal
-		if meal.MealType != "" {
-			mealsByType[meal.MealType] = append(mealsByType[meal.MealType], meal)
+	c.Header("X-Event-ID", s.events.LastEventID(planTopic(mealPlan.ID)))
+	c.JSON(http.StatusOK, mealPlan)
+}
+
+type PopulatePlanRequest struct {
+	services.PlannerOptions
+}
+
+// PopulateFromLikedMeals auto-populates the current meal plan with liked meals
+func (s *Server) PopulateFromLikedMeals(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var req PopulatePlanRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
 		}
 	}
 
-	// If no specific meal types, use all meals for all types
-	allMeals := []models.Meal{}
-	for _, interaction := range interactions {
-		allMeals = append(allMeals, interaction.Meal)
+	// Get or create current meal plan
+	mealPlan, err := s.getOrCreateActivePlan(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create meal plan"})
+		return
 	}
 
-	// Generate meal entries for the week
-	days := []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
-	mealTypes := []string{"breakfast", "lunch", "dinner"}
-
-	for _, day := range days {
-		for _, mealType := range mealTypes {
-			// Choose meals for this meal type
-			availableMeals := mealsByType[mealType]
-			if len(availableMeals) == 0 {
-				// Fallback to all meals if no specific type available
-				availableMeals = allMeals
-			}
+	// Get user's liked meals
+	likedMeals, err := s.repo.LikedMeals(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch liked meals"})
+		return
+	}
 
-			if len(availableMeals) > 0 {
-				// Randomly select a meal
-				selectedMeal := availableMeals[rand.Intn(len(availableMeals))]
+	if len(likedMeals) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No liked meals found. Please like some meals first."})
+		return
+	}
 
-				entry := models.MealPlanEntry{
-					MealPlanID: mealPlan.ID,
-					MealID:     selectedMeal.ID,
-					Day:        day,
-					MealType:   mealType,
-					Servings:   1,
-				}
+	// Generate a varied week; the seed reproduces it
+	options, err := s.plannerOptionsFor(userID, mealPlan.HouseholdSize, req.PlannerOptions, likedMeals)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to estimate meal costs"})
+		return
+	}
+	result := services.NewMealPlanner(options).Plan(likedMeals)
 
-				database.DB.Create(&entry)
-			}
+	// Replace the week and its shopping list together
+	err = s.repo.Transaction(func(tx repository.Repository) error {
+		if err := tx.ClearEntries(mealPlan.ID, nil); err != nil {
+			return err
+		}
+		if err := tx.SavePlannedMeals(mealPlan.ID, result.Meals, nil); err != nil {
+			return err
 		}
+		return tx.SyncShoppingList(mealPlan.ID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update meal plan"})
+		return
 	}
-
-	// Update shopping list automatically
-	updateShoppingListForCurrentPlan(userID, mealPlan.ID)
+	c.Header("X-Planner-Seed", strconv.FormatInt(result.Seed, 10))
+	setPlannerBudgetHeaders(c, options, result)
 
 	// Load updated meal plan
-	database.DB.Preload("Meals").Preload("Meals.Meal").Preload("Meals.Meal.Ingredients").
-		Preload("ShoppingList").Preload("ShoppingList.Items").Preload("ShoppingList.Items.Ingredient").
-		First(&mealPlan, mealPlan.ID)
+	mealPlan, _ = s.repo.PlanWithShoppingList(mealPlan.ID)
+
+	s.publishPlanEvent(mealPlan.ID, EventPlanReplaced, mealPlan)
 
 	c.JSON(http.StatusOK, mealPlan)
 }
 
 // UpdateMealInPlan updates a specific meal in the current plan
-func UpdateMealInPlan(c *gin.Context) {
+func (s *Server) UpdateMealInPlan(c *gin.Context) {
 	userID := c.GetUint("userID")
-	
+
 	type UpdateMealRequest struct {
-		Day      string `json:"day" binding:"required"`
-		MealType string `json:"meal_type" binding:"required"`
-		MealID   *uint  `json:"meal_id"` // nil to remove meal
-		Servings int    `json:"servings"`
+		Day       string `json:"day" binding:"required"`
+		MealType  string `json:"meal_type" binding:"required"`
+		MealID    *uint  `json:"meal_id"`   // nil to remove meal
+		Servings  int    `json:"servings"`  // deprecated alias for headcount
+		Headcount int    `json:"headcount"` // 0 uses the plan's household size
 	}
 
 	var req UpdateMealRequest
@@ -150,52 +180,76 @@ func UpdateMealInPlan(c *gin.Context) {
 	}
 
 	// Get current meal plan
-	var mealPlan models.CurrentMealPlan
-	if database.DB.Where("user_id = ?", userID).First(&mealPlan).RecordNotFound() {
+	mealPlan, err := s.repo.Plan(s.activeMealPlanID(userID))
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "No active meal plan found"})
 		return
 	}
 
-	// Remove existing meal for this day/type
-	database.DB.Where("meal_plan_id = ? AND day = ? AND meal_type = ?", 
-		mealPlan.ID, req.Day, req.MealType).Delete(&models.MealPlanEntry{})
+	change := PlanEntryChange{Day: req.Day, MealType: req.MealType}
 
-	// Add new meal if provided
+	var entry *models.MealPlanEntry
 	if req.MealID != nil {
 		servings := req.Servings
 		if servings == 0 {
 			servings = 1
 		}
 
-		entry := models.MealPlanEntry{
+		headcount := req.Headcount
+		if headcount == 0 {
+			headcount = req.Servings
+		}
+
+		entry = &models.MealPlanEntry{
 			MealPlanID: mealPlan.ID,
 			MealID:     *req.MealID,
 			Day:        req.Day,
 			MealType:   req.MealType,
 			Servings:   servings,
+			Headcount:  headcount,
 		}
+	}
 
-		if err := database.DB.Create(&entry).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add meal to plan"})
-			return
+	// Replace the slot and update the shopping list in one go
+	err = s.repo.Transaction(func(tx repository.Repository) error {
+		if err := tx.ClearSlot(mealPlan.ID, req.Day, req.MealType); err != nil {
+			return err
+		}
+		if entry != nil {
+			if err := tx.CreateEntry(entry); err != nil {
+				return err
+			}
 		}
+		return tx.SyncShoppingList(mealPlan.ID)
+	})
+	if errors.Is(err, repository.ErrLockedLeftover) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A locked leftover depends on this slot; unlock it first"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update meal plan"})
+		return
+	}
+
+	if entry != nil {
+		if stored, err := s.repo.Entry(entry.ID); err == nil {
+			entry = &stored
+		}
+		change.Entry = entry
 	}
 
-	// Update shopping list automatically
-	updateShoppingListForCurrentPlan(userID, mealPlan.ID)
+	s.publishPlanEvent(mealPlan.ID, EventPlanEntryUpdated, change)
+	s.publishShoppingListReplaced(mealPlan.ID)
 
 	// Return updated meal plan
-	database.DB.Preload("Meals").Preload("Meals.Meal").Preload("Meals.Meal.Ingredients").
-		Preload("ShoppingList").Preload("ShoppingList.Items").Preload("ShoppingList.Items.Ingredient").
-		First(&mealPlan, mealPlan.ID)
+	mealPlan, _ = s.repo.PlanWithShoppingList(mealPlan.ID)
 
 	c.JSON(http.StatusOK, mealPlan)
 }
 
 // ToggleShoppingItem toggles the purchased status of a shopping list item
-func ToggleShoppingItem(c *gin.Context) {
+func (s *Server) ToggleShoppingItem(c *gin.Context) {
 	userID := c.GetUint("userID")
-	itemID := c.Param("item_id")
 
 	type ToggleRequest struct {
 		IsPurchased bool   `json:"is_purchased"`
@@ -208,24 +262,24 @@ func ToggleShoppingItem(c *gin.Context) {
 		return
 	}
 
-	// Verify the item belongs to the user's shopping list
-	var item models.ShoppingListItem
-	if database.DB.Joins("JOIN shopping_lists ON shopping_list_items.shopping_list_id = shopping_lists.id").
-		Where("shopping_list_items.id = ? AND shopping_lists.user_id = ?", itemID, userID).
-		First(&item).RecordNotFound() {
+	// Verify the item belongs to the user's or their household's shopping list
+	item, err := s.findAccessibleShoppingItem(userID, c.Param("item_id"))
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Shopping list item not found"})
 		return
 	}
 
 	// Update the item
-	item.IsPurchased = req.IsPurchased
-	item.Notes = req.Notes
-	
-	if err := database.DB.Save(&item).Error; err != nil {
+	if err := s.repo.UpdateShoppingItem(&item, map[string]interface{}{
+		"is_purchased": req.IsPurchased,
+		"notes":        req.Notes,
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update shopping list item"})
 		return
 	}
 
+	s.publishShoppingItemUpdated(item)
+
 	c.JSON(http.StatusOK, item)
 }
 
@@ -241,63 +295,67 @@ func getCurrentWeekStart() time.Time {
 	return time.Date(monday.Year(), monday.Month(), monday.Day(), 0, 0, 0, 0, monday.Location())
 }
 
-func updateShoppingListForCurrentPlan(userID, mealPlanID uint) {
-	// Delete existing shopping list for this meal plan
-	var existingList models.ShoppingList
-	if !database.DB.Where("meal_plan_id = ?", mealPlanID).First(&existingList).RecordNotFound() {
-		database.DB.Where("shopping_list_id = ?", existingList.ID).Delete(&models.ShoppingListItem{})
-		database.DB.Delete(&existingList)
+// activeMealPlanID returns the ID of the plan the user works on: their household
+// owner's active plan, or their own. It is 0 when there is none.
+func (s *Server) activeMealPlanID(userID uint) uint {
+	owner, err := s.repo.User(s.currentPlanOwnerID(userID))
+	if err != nil || owner.ActiveMealPlanID == nil {
+		return 0
 	}
+	return *owner.ActiveMealPlanID
+}
 
-	// Get meal plan with all meals
-	var mealPlan models.CurrentMealPlan
-	if database.DB.Preload("Meals").Preload("Meals.Meal").Where("id = ?", mealPlanID).First(&mealPlan).RecordNotFound() {
-		return
-	}
+// activePlan loads the plan the user works on without its entries
+func (s *Server) activePlan(userID uint) (models.MealPlan, error) {
+	return s.repo.Plan(s.activeMealPlanID(userID))
+}
 
-	// Create new shopping list
-	shoppingList := models.ShoppingList{
-		UserID:     userID,
-		MealPlanID: mealPlanID,
-		Name:       "Week of " + mealPlan.WeekStart.Format("Jan 2, 2006"),
+// getOrCreateActivePlan loads the user's active plan, creating one for this week if there is none
+func (s *Server) getOrCreateActivePlan(userID uint) (models.MealPlan, error) {
+	mealPlan, err := s.activePlan(userID)
+	if !isNotFound(err) {
+		return mealPlan, err
 	}
 
-	if err := database.DB.Create(&shoppingList).Error; err != nil {
-		return
+	weekStart := getCurrentWeekStart()
+	mealPlan = models.MealPlan{
+		UserID:        s.currentPlanOwnerID(userID),
+		HouseholdID:   s.currentHouseholdID(userID),
+		Name:          "Week of " + weekStart.Format("Jan 2, 2006"),
+		WeekStart:     weekStart,
+		HouseholdSize: 1,
 	}
 
-	// Aggregate ingredients from all meals
-	ingredientQuantities := make(map[uint]map[string]float64) // ingredient_id -> unit -> total_quantity
-
-	for _, entry := range mealPlan.Meals {
-		// Get meal ingredients with quantities
-		var mealIngredients []models.MealIngredient
-		database.DB.Preload("Ingredient").Where("meal_id = ?", entry.MealID).Find(&mealIngredients)
-
-		for _, mealIngredient := range mealIngredients {
-			ingredientID := mealIngredient.IngredientID
-			unit := mealIngredient.Unit
-			quantity := mealIngredient.Quantity * float64(entry.Servings)
-
-			if ingredientQuantities[ingredientID] == nil {
-				ingredientQuantities[ingredientID] = make(map[string]float64)
-			}
-			ingredientQuantities[ingredientID][unit] += quantity
+	err = s.repo.Transaction(func(tx repository.Repository) error {
+		if err := tx.CreatePlan(&mealPlan); err != nil {
+			return err
 		}
+		return tx.ActivatePlan(mealPlan)
+	})
+	if err != nil {
+		return mealPlan, err
 	}
 
-	// Create shopping list items
-	for ingredientID, units := range ingredientQuantities {
-		for unit, quantity := range units {
-			item := models.ShoppingListItem{
-				ShoppingListID: shoppingList.ID,
-				IngredientID:   ingredientID,
-				Quantity:       quantity,
-				Unit:           unit,
-				IsPurchased:    false,
-			}
+	mealPlan.IsActive = true
+	return mealPlan, nil
+}
 
-			database.DB.Create(&item)
+// plannerOptionsFor completes request options with the plan's household size, the
+// user's cooking preferences and, when a budget is set, the candidates' costs
+func (s *Server) plannerOptionsFor(userID uint, householdSize int, options services.PlannerOptions, candidates []models.Meal) (services.PlannerOptions, error) {
+	options.HouseholdSize = householdSize
+	s.applyCookingPreferences(userID, &options)
+	if options.WeeklyBudget > 0 {
+		costs, err := s.mealCostsPerServing(candidates)
+		if err != nil {
+			return options, err
 		}
+		options.MealCosts = costs
 	}
+	return options, nil
+}
+
+// findAccessibleShoppingItem loads an item from a list owned by the user or shared with their household
+func (s *Server) findAccessibleShoppingItem(userID uint, itemID string) (models.ShoppingListItem, error) {
+	return s.repo.AccessibleShoppingItem(parseUint(itemID), userID, s.currentHouseholdID(userID))
 }
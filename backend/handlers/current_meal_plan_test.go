@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"food-app/models"
+	"food-app/services"
+)
+
+// entryIn finds the plan's entry in a slot
+func entryIn(plan models.MealPlan, day, mealType string) (models.MealPlanEntry, bool) {
+	for _, entry := range plan.Meals {
+		if entry.Day == day && entry.MealType == mealType {
+			return entry, true
+		}
+	}
+	return models.MealPlanEntry{}, false
+}
+
+// shoppingItemFor finds the shopping list line of an ingredient
+func shoppingItemFor(items []models.ShoppingListItem, ingredientName string) (models.ShoppingListItem, bool) {
+	for _, item := range items {
+		if item.Ingredient.Name == ingredientName {
+			return item, true
+		}
+	}
+	return models.ShoppingListItem{}, false
+}
+
+func TestCurrentPlanIsCreatedAndPopulated(t *testing.T) {
+	api := newTestAPI(t)
+	meals := api.seedMeals()
+	token, user := api.register("ann")
+
+	var plan models.MealPlan
+	api.expect(http.StatusOK, "GET", "/current-meal-plan", token, nil, &plan)
+	if plan.ID == 0 || plan.UserID != user.ID || len(plan.Meals) != 0 {
+		t.Fatalf("new current plan = %+v", plan)
+	}
+
+	api.expect(http.StatusBadRequest, "POST", "/current-meal-plan/populate-from-liked", token, nil, nil)
+
+	api.likeAll(token, meals)
+	populated := api.populate(token)
+	if populated.ID != plan.ID {
+		t.Errorf("populated plan %d, want the current plan %d", populated.ID, plan.ID)
+	}
+	if len(populated.Meals) != len(services.WeekSlots()) {
+		t.Errorf("populated %d slots, want %d", len(populated.Meals), len(services.WeekSlots()))
+	}
+	if populated.ShoppingList == nil || len(populated.ShoppingList.Items) == 0 {
+		t.Fatal("populating the plan did not build its shopping list")
+	}
+	if _, ok := shoppingItemFor(populated.ShoppingList.Items, "Rice"); !ok {
+		t.Error("the shopping list is missing rice, which every meal uses")
+	}
+
+	// The same seed plans the same week
+	again := api.populate(token)
+	for _, entry := range populated.Meals {
+		if other, _ := entryIn(again, entry.Day, entry.MealType); other.MealID != entry.MealID {
+			t.Errorf("%s %s: seeded plans differ", entry.Day, entry.MealType)
+		}
+	}
+}
+
+func TestUpdateMealInPlan(t *testing.T) {
+	api := newTestAPI(t)
+	meals := api.seedMeals()
+	token, _ := api.register("ann")
+	api.expect(http.StatusOK, "GET", "/current-meal-plan", token, nil, nil)
+
+	var plan models.MealPlan
+	api.expect(http.StatusOK, "PUT", "/current-meal-plan/meals", token, payload{
+		"day": "monday", "meal_type": "dinner", "meal_id": meals[2].ID, "headcount": 3,
+	}, &plan)
+	entry, ok := entryIn(plan, "monday", "dinner")
+	if !ok || entry.MealID != meals[2].ID || entry.Headcount != 3 {
+		t.Fatalf("monday dinner = %+v, want %q for 3", entry, meals[2].Name)
+	}
+	if len(api.shoppingItems(plan.ID)) != 2 {
+		t.Errorf("shopping list has %d items, want the meal's 2", len(api.shoppingItems(plan.ID)))
+	}
+
+	api.expect(http.StatusOK, "PUT", "/current-meal-plan/meals", token, payload{
+		"day": "monday", "meal_type": "dinner", "meal_id": nil,
+	}, &plan)
+	if _, ok := entryIn(plan, "monday", "dinner"); ok {
+		t.Error("clearing the slot left its entry")
+	}
+	if items := api.shoppingItems(plan.ID); len(items) != 0 {
+		t.Errorf("shopping list has %d items after the only meal was removed", len(items))
+	}
+}
+
+func TestLockedSlotsSurviveRegenerationAndRefuseSwaps(t *testing.T) {
+	api := newTestAPI(t)
+	meals := api.seedMeals()
+	token, _ := api.register("ann")
+	api.likeAll(token, meals)
+	plan := api.populate(token)
+
+	locked, _ := entryIn(plan, "tuesday", "dinner")
+	var stored models.MealPlanEntry
+	api.expect(http.StatusOK, "PUT", "/current-meal-plan/locks", token, LockSlotRequest{
+		Day: "tuesday", MealType: "dinner", Locked: true,
+	}, &stored)
+	if !stored.Locked {
+		t.Fatal("slot was not locked")
+	}
+	api.expect(http.StatusNotFound, "PUT", "/current-meal-plan/locks", token, LockSlotRequest{
+		Day: "someday", MealType: "dinner", Locked: true,
+	}, nil)
+
+	var regenerated PlanWithAlternatives
+	for seed := int64(1); seed <= 3; seed++ {
+		seed := seed
+		api.expect(http.StatusOK, "POST", "/current-meal-plan/regenerate", token,
+			RegeneratePlanRequest{services.PlannerOptions{Seed: &seed}}, &regenerated)
+		kept, _ := entryIn(regenerated.MealPlan, "tuesday", "dinner")
+		if kept.ID != locked.ID || kept.MealID != locked.MealID || !kept.Locked {
+			t.Fatalf("seed %d: locked slot became %+v", seed, kept)
+		}
+	}
+	if len(regenerated.MealPlan.Meals) != len(services.WeekSlots()) || len(regenerated.Slots) == 0 {
+		t.Errorf("regenerated %d slots with %d alternatives", len(regenerated.MealPlan.Meals), len(regenerated.Slots))
+	}
+
+	api.expect(http.StatusConflict, "POST", "/current-meal-plan/swap", token, SwapSlotRequest{
+		Day: "tuesday", MealType: "dinner",
+	}, nil)
+
+	before, _ := entryIn(regenerated.MealPlan, "friday", "lunch")
+	var swapped PlanWithAlternatives
+	api.expect(http.StatusOK, "POST", "/current-meal-plan/swap", token, SwapSlotRequest{
+		Day: "friday", MealType: "lunch",
+	}, &swapped)
+	after, _ := entryIn(swapped.MealPlan, "friday", "lunch")
+	if after.MealID == 0 || after.MealID == before.MealID {
+		t.Errorf("swap kept %d in friday lunch", before.MealID)
+	}
+}
+
+func TestShoppingListToggleAndSync(t *testing.T) {
+	api := newTestAPI(t)
+	meals := api.seedMeals()
+	token, _ := api.register("ann")
+	api.expect(http.StatusOK, "GET", "/current-meal-plan", token, nil, nil)
+
+	var plan models.MealPlan
+	for _, day := range []string{"monday", "tuesday"} {
+		api.expect(http.StatusOK, "PUT", "/current-meal-plan/meals", token, payload{
+			"day": day, "meal_type": "dinner", "meal_id": meals[2].ID,
+		}, &plan)
+	}
+
+	rice, ok := shoppingItemFor(api.shoppingItems(plan.ID), "Rice")
+	if !ok {
+		t.Fatal("no rice on the list")
+	}
+
+	var toggled models.ShoppingListItem
+	api.expect(http.StatusOK, "PUT", fmt.Sprintf("/shopping-items/%d", rice.ID), token, payload{
+		"is_purchased": true, "notes": "brown",
+	}, &toggled)
+	if !toggled.IsPurchased || toggled.Notes != "brown" {
+		t.Fatalf("toggled item = %+v", toggled)
+	}
+
+	var grouped GroupedShoppingList
+	api.expect(http.StatusOK, "GET", "/current-meal-plan/shopping-list", token, nil, &grouped)
+	if grouped.MealPlanID != plan.ID || len(grouped.Groups) == 0 {
+		t.Errorf("grouped list = %+v", grouped)
+	}
+
+	// Planning less keeps the bought item, its note and its place on the list
+	api.expect(http.StatusOK, "PUT", "/current-meal-plan/meals", token, payload{
+		"day": "tuesday", "meal_type": "dinner", "meal_id": nil,
+	}, nil)
+	synced, ok := shoppingItemFor(api.shoppingItems(plan.ID), "Rice")
+	if !ok || synced.ID != rice.ID || !synced.IsPurchased || synced.Notes != "brown" || synced.Quantity >= rice.Quantity {
+		t.Errorf("after removing a meal rice is %+v, was %+v", synced, rice)
+	}
+
+	// Another user cannot touch the list
+	other, _ := api.register("bob")
+	api.expect(http.StatusNotFound, "PUT", fmt.Sprintf("/shopping-items/%d", rice.ID), other, payload{"is_purchased": false}, nil)
+}
@@ -0,0 +1,389 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"food-app/models"
+	"food-app/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CreateHouseholdRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type InviteMemberRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role"`
+}
+
+type UpdateMemberRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// CreateHousehold creates a household owned by the current user and shares their plan with it
+func (s *Server) CreateHousehold(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var req CreateHouseholdRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, ok := s.householdMembership(userID); ok {
+		c.JSON(http.StatusConflict, gin.H{"error": "You already belong to a household"})
+		return
+	}
+
+	household := models.Household{
+		Name:    req.Name,
+		OwnerID: userID,
+	}
+
+	planID := s.activeMealPlanID(userID)
+	err := s.repo.Transaction(func(tx repository.Repository) error {
+		if err := tx.CreateHousehold(&household); err != nil {
+			return err
+		}
+		// The owner's active plan and its shopping list become the shared ones
+		if planID == 0 {
+			return nil
+		}
+		return tx.SharePlan(planID, &household.ID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create household"})
+		return
+	}
+
+	household, _ = s.repo.HouseholdWithMembers(household.ID)
+
+	c.JSON(http.StatusCreated, household)
+}
+
+// GetHousehold returns the current user's household with its members
+func (s *Server) GetHousehold(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	membership, ok := s.householdMembership(userID)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "You are not a member of a household"})
+		return
+	}
+
+	household, err := s.repo.HouseholdWithMembers(membership.HouseholdID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Household not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, household)
+}
+
+// DeleteHousehold dissolves the household; the shared plan goes back to being the owner's own
+func (s *Server) DeleteHousehold(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	membership, ok := s.householdMembership(userID)
+	if !ok || membership.Role != models.HouseholdRoleOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the household owner can delete it"})
+		return
+	}
+
+	err := s.repo.Transaction(func(tx repository.Repository) error {
+		return tx.DeleteHousehold(membership.HouseholdID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete household"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Household deleted successfully"})
+}
+
+// LeaveHousehold removes the current user from their household; they return to their own plan
+func (s *Server) LeaveHousehold(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	membership, ok := s.householdMembership(userID)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "You are not a member of a household"})
+		return
+	}
+
+	if membership.Role == models.HouseholdRoleOwner {
+		c.JSON(http.StatusConflict, gin.H{"error": "The owner cannot leave; delete the household instead"})
+		return
+	}
+
+	if err := s.repo.DeleteMember(&membership); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to leave household"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Left household successfully"})
+}
+
+// UpdateHouseholdMember changes a member's role (owner only)
+func (s *Server) UpdateHouseholdMember(c *gin.Context) {
+	userID := c.GetUint("userID")
+	memberUserID := parseUint(c.Param("user_id"))
+
+	var req UpdateMemberRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Role != models.HouseholdRoleAdmin && req.Role != models.HouseholdRoleMember {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Role must be admin or member"})
+		return
+	}
+
+	membership, ok := s.householdMembership(userID)
+	if !ok || membership.Role != models.HouseholdRoleOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the household owner can change roles"})
+		return
+	}
+
+	member, err := s.repo.Member(membership.HouseholdID, memberUserID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return
+	}
+
+	if member.Role == models.HouseholdRoleOwner {
+		c.JSON(http.StatusConflict, gin.H{"error": "The owner's role cannot be changed"})
+		return
+	}
+
+	if err := s.repo.SetMemberRole(&member, req.Role); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update member"})
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+// RemoveHouseholdMember removes a member (owner or admin only)
+func (s *Server) RemoveHouseholdMember(c *gin.Context) {
+	userID := c.GetUint("userID")
+	memberUserID := parseUint(c.Param("user_id"))
+
+	membership, ok := s.householdMembership(userID)
+	if !ok || !membership.CanManage() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only owners and admins can remove members"})
+		return
+	}
+
+	member, err := s.repo.Member(membership.HouseholdID, memberUserID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return
+	}
+
+	if member.Role == models.HouseholdRoleOwner {
+		c.JSON(http.StatusConflict, gin.H{"error": "The owner cannot be removed"})
+		return
+	}
+
+	if err := s.repo.DeleteMember(&member); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
+
+// InviteHouseholdMember invites someone by email (owner or admin only)
+func (s *Server) InviteHouseholdMember(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var req InviteMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Role == "" {
+		req.Role = models.HouseholdRoleMember
+	}
+	if req.Role != models.HouseholdRoleAdmin && req.Role != models.HouseholdRoleMember {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Role must be admin or member"})
+		return
+	}
+
+	membership, ok := s.householdMembership(userID)
+	if !ok || !membership.CanManage() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only owners and admins can invite members"})
+		return
+	}
+
+	token, err := invitationToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invitation"})
+		return
+	}
+
+	invitation := models.HouseholdInvitation{
+		HouseholdID: membership.HouseholdID,
+		Email:       strings.ToLower(req.Email),
+		Role:        req.Role,
+		Token:       token,
+		Status:      models.InvitationPending,
+		InvitedByID: userID,
+		ExpiresAt:   time.Now().Add(7 * 24 * time.Hour),
+	}
+
+	if err := s.repo.CreateInvitation(&invitation); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invitation"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, invitation)
+}
+
+// GetHouseholdInvitations lists the household's pending invitations (owner or admin only)
+func (s *Server) GetHouseholdInvitations(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	membership, ok := s.householdMembership(userID)
+	if !ok || !membership.CanManage() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only owners and admins can view invitations"})
+		return
+	}
+
+	invitations, err := s.repo.PendingInvitations(membership.HouseholdID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch invitations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invitations": invitations})
+}
+
+// GetMyInvitations lists pending invitations addressed to the current user's email
+func (s *Server) GetMyInvitations(c *gin.Context) {
+	user, err := s.repo.User(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	invitations, err := s.repo.InvitationsFor(user.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch invitations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invitations": invitations})
+}
+
+// AcceptInvitation joins the inviting household
+func (s *Server) AcceptInvitation(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	invitation, ok := s.findPendingInvitation(c)
+	if !ok {
+		return
+	}
+
+	if _, member := s.householdMembership(userID); member {
+		c.JSON(http.StatusConflict, gin.H{"error": "Leave your current household before joining another"})
+		return
+	}
+
+	err := s.repo.Transaction(func(tx repository.Repository) error {
+		return tx.AcceptInvitation(&invitation, userID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join household"})
+		return
+	}
+
+	household, _ := s.repo.HouseholdWithMembers(invitation.HouseholdID)
+
+	c.JSON(http.StatusOK, household)
+}
+
+// DeclineInvitation rejects an invitation
+func (s *Server) DeclineInvitation(c *gin.Context) {
+	invitation, ok := s.findPendingInvitation(c)
+	if !ok {
+		return
+	}
+
+	if err := s.repo.SetInvitationStatus(&invitation, models.InvitationDeclined); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decline invitation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation declined"})
+}
+
+// findPendingInvitation loads the invitation in the :token param if it is addressed to the current user.
+// It matches the account's stored email, not the token's claim, which outlives email changes.
+func (s *Server) findPendingInvitation(c *gin.Context) (models.HouseholdInvitation, bool) {
+	var invitation models.HouseholdInvitation
+	user, err := s.repo.User(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return invitation, false
+	}
+
+	invitation, err = s.repo.PendingInvitation(c.Param("token"), user.Email)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invitation not found"})
+		return invitation, false
+	}
+
+	if time.Now().After(invitation.ExpiresAt) {
+		c.JSON(http.StatusGone, gin.H{"error": "Invitation has expired"})
+		return invitation, false
+	}
+
+	return invitation, true
+}
+
+// householdMembership returns the user's household membership, if any
+func (s *Server) householdMembership(userID uint) (models.HouseholdMember, bool) {
+	membership, err := s.repo.Membership(userID)
+	return membership, err == nil
+}
+
+// currentPlanOwnerID returns the user whose active plan the given user works on:
+// the household owner for household members, otherwise the user themself
+func (s *Server) currentPlanOwnerID(userID uint) uint {
+	membership, ok := s.householdMembership(userID)
+	if !ok {
+		return userID
+	}
+
+	household, err := s.repo.Household(membership.HouseholdID)
+	if err != nil {
+		return userID
+	}
+	return household.OwnerID
+}
+
+// currentHouseholdID returns the household ID a new plan or list should be shared with
+func (s *Server) currentHouseholdID(userID uint) *uint {
+	if membership, ok := s.householdMembership(userID); ok {
+		return &membership.HouseholdID
+	}
+	return nil
+}
+
+func invitationToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
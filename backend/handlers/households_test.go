@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"food-app/middleware"
+	"food-app/models"
+)
+
+func TestHouseholdsSharePlanAndShoppingList(t *testing.T) {
+	api := newTestAPI(t)
+	meals := api.seedMeals()
+	owner, _ := api.register("ann")
+	member, memberUser := api.register("bob")
+
+	var ownPlan models.MealPlan
+	api.expect(http.StatusOK, "GET", "/current-meal-plan", owner, nil, &ownPlan)
+	api.expect(http.StatusOK, "PUT", "/current-meal-plan/meals", owner, payload{
+		"day": "monday", "meal_type": "dinner", "meal_id": meals[0].ID,
+	}, nil)
+
+	var household models.Household
+	api.expect(http.StatusCreated, "POST", "/household", owner, CreateHouseholdRequest{Name: "Home"}, &household)
+	if len(household.Members) != 1 || household.Members[0].Role != models.HouseholdRoleOwner {
+		t.Fatalf("new household members = %+v", household.Members)
+	}
+	api.expect(http.StatusConflict, "POST", "/household", owner, CreateHouseholdRequest{Name: "Again"}, nil)
+
+	var invitation models.HouseholdInvitation
+	api.expect(http.StatusCreated, "POST", "/household/invitations", owner, InviteMemberRequest{Email: "bob@example.com"}, &invitation)
+
+	var mine struct {
+		Invitations []models.HouseholdInvitation `json:"invitations"`
+	}
+	api.expect(http.StatusOK, "GET", "/invitations", member, nil, &mine)
+	if len(mine.Invitations) != 1 || mine.Invitations[0].Token != invitation.Token {
+		t.Fatalf("bob's invitations = %+v", mine.Invitations)
+	}
+	stranger, _ := api.register("cat")
+	api.expect(http.StatusNotFound, "POST", "/invitations/"+invitation.Token+"/accept", stranger, nil, nil)
+
+	api.expect(http.StatusOK, "POST", "/invitations/"+invitation.Token+"/accept", member, nil, &household)
+	if len(household.Members) != 2 {
+		t.Fatalf("household has %d members after accepting, want 2", len(household.Members))
+	}
+
+	// The member works on the owner's plan and list
+	var shared models.MealPlan
+	api.expect(http.StatusOK, "GET", "/current-meal-plan", member, nil, &shared)
+	if shared.ID != ownPlan.ID {
+		t.Fatalf("member's current plan is %d, want the owner's %d", shared.ID, ownPlan.ID)
+	}
+	items := api.shoppingItems(shared.ID)
+	if len(items) == 0 {
+		t.Fatal("shared plan has no shopping list")
+	}
+	api.expect(http.StatusOK, "PUT", fmt.Sprintf("/shopping-items/%d", items[0].ID), member, payload{"is_purchased": true}, nil)
+	api.expect(http.StatusNotFound, "PUT", fmt.Sprintf("/shopping-items/%d", items[0].ID), stranger, payload{"is_purchased": true}, nil)
+
+	// Only owners and admins manage the household
+	api.expect(http.StatusForbidden, "POST", "/household/invitations", member, InviteMemberRequest{Email: "cat@example.com"}, nil)
+	api.expect(http.StatusForbidden, "DELETE", "/household", member, nil, nil)
+	api.expect(http.StatusConflict, "POST", "/household/leave", owner, nil, nil)
+
+	api.expect(http.StatusOK, "POST", "/household/leave", member, nil, nil)
+	var ownAgain models.MealPlan
+	api.expect(http.StatusOK, "GET", "/current-meal-plan", member, nil, &ownAgain)
+	if ownAgain.ID == shared.ID || ownAgain.UserID != memberUser.ID {
+		t.Errorf("after leaving, the member's plan is %d of user %d", ownAgain.ID, ownAgain.UserID)
+	}
+
+	api.expect(http.StatusOK, "DELETE", "/household", owner, nil, nil)
+	api.expect(http.StatusNotFound, "GET", "/household", owner, nil, nil)
+}
+
+func TestInvitationsMatchTheStoredEmail(t *testing.T) {
+	api := newTestAPI(t)
+	owner, _ := api.register("ann")
+	_, bob := api.register("bob")
+	api.expect(http.StatusCreated, "POST", "/household", owner, CreateHouseholdRequest{Name: "Home"}, nil)
+
+	var invitation models.HouseholdInvitation
+	api.expect(http.StatusCreated, "POST", "/household/invitations", owner, InviteMemberRequest{Email: "cat@example.com"}, &invitation)
+
+	// Bob's token claims the invited address, but his account has another
+	claimed := bob
+	claimed.Email = "cat@example.com"
+	forged, err := middleware.GenerateToken(claimed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mine struct {
+		Invitations []models.HouseholdInvitation `json:"invitations"`
+	}
+	api.expect(http.StatusOK, "GET", "/invitations", forged, nil, &mine)
+	if len(mine.Invitations) != 0 {
+		t.Errorf("listed %d invitations for another address", len(mine.Invitations))
+	}
+	api.expect(http.StatusNotFound, "POST", "/invitations/"+invitation.Token+"/accept", forged, nil, nil)
+	api.expect(http.StatusNotFound, "POST", "/invitations/"+invitation.Token+"/decline", forged, nil, nil)
+
+	// Once the account holds the address, the same token may accept
+	if err := api.store.DB().Model(&models.User{}).Where("id = ?", bob.ID).Update("email", "Cat@Example.com").Error; err != nil {
+		t.Fatal(err)
+	}
+	var household models.Household
+	api.expect(http.StatusOK, "POST", "/invitations/"+invitation.Token+"/accept", forged, nil, &household)
+	if len(household.Members) != 2 {
+		t.Errorf("household has %d members, want 2", len(household.Members))
+	}
+}
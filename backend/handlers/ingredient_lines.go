@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"food-app/ingredients"
+	"food-app/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ParseIngredientsRequest holds free-text ingredient lines, as a list or as one
+// block of text with a line per ingredient
+type ParseIngredientsRequest struct {
+	Lines []string `json:"lines"`
+	Text  string   `json:"text"`
+}
+
+// ParsedIngredient is a parsed ingredient line with the catalog ingredient its
+// name matched, if any
+type ParsedIngredient struct {
+	ingredients.Line
+	Ingredient *models.Ingredient `json:"ingredient"`
+}
+
+// ParseIngredients splits free-text ingredient lines into quantity, unit, name,
+// note and optional flag, and matches each name to the ingredient catalog
+func (s *Server) ParseIngredients(c *gin.Context) {
+	var req ParseIngredientsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	lines := append(req.Lines, strings.Split(req.Text, "\n")...)
+	parsed, err := s.matchIngredientLines(ingredients.ParseLines(lines))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load ingredients"})
+		return
+	}
+	if len(parsed) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide ingredient lines or text"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"ingredients": parsed, "unresolved": unresolvedNames(parsed)})
+}
+
+// matchIngredientLines pairs parsed lines with their catalog ingredients
+func (s *Server) matchIngredientLines(lines []ingredients.Line) ([]ParsedIngredient, error) {
+	matcher, err := s.repo.IngredientMatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := make([]ParsedIngredient, 0, len(lines))
+	for _, line := range lines {
+		item := ParsedIngredient{Line: line}
+		if ingredient, ok := matcher.Match(line.Name); ok {
+			item.Ingredient = &ingredient
+		}
+		parsed = append(parsed, item)
+	}
+	return parsed, nil
+}
+
+// unresolvedNames lists the names of parsed lines that matched no ingredient or alias
+func unresolvedNames(parsed []ParsedIngredient) []string {
+	names := []string{}
+	for _, item := range parsed {
+		if item.Ingredient == nil {
+			names = append(names, item.Name)
+		}
+	}
+	return names
+}
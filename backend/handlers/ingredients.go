@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"food-app/models"
+	"food-app/repository"
+	"food-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateIngredientRequest adds an ingredient to the catalog, optionally with aliases
+type CreateIngredientRequest struct {
+	Name            string   `json:"name" binding:"required"`
+	Category        string   `json:"category"`
+	Unit            string   `json:"unit"`
+	CaloriesPer100g float64  `json:"calories_per_100g" binding:"min=0"`
+	Aliases         []string `json:"aliases"`
+}
+
+// UpdateIngredientRequest changes an ingredient; omitted fields are left as they are
+type UpdateIngredientRequest struct {
+	Name            *string  `json:"name"`
+	Category        *string  `json:"category"`
+	Unit            *string  `json:"unit"`
+	CaloriesPer100g *float64 `json:"calories_per_100g" binding:"omitempty,min=0"`
+}
+
+// AddIngredientAliasRequest gives an ingredient another name
+type AddIngredientAliasRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// GetIngredients searches the ingredient catalog by name or alias (q) and category
+func (s *Server) GetIngredients(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+
+	catalog, err := s.repo.Ingredients(repository.IngredientFilter{
+		Query:    strings.TrimSpace(c.Query("q")),
+		Category: c.Query("category"),
+		Offset:   (page - 1) * limit,
+		Limit:    limit,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ingredients"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ingredients": catalog,
+		"page":        page,
+		"limit":       limit,
+	})
+}
+
+// GetIngredient returns an ingredient with its aliases
+func (s *Server) GetIngredient(c *gin.Context) {
+	ingredient, err := s.repo.Ingredient(parseUint(c.Param("id")))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ingredient not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ingredient)
+}
+
+// CreateIngredient adds an ingredient and its aliases. Names must be unused by
+// other ingredients and aliases, ignoring case.
+func (s *Server) CreateIngredient(c *gin.Context) {
+	var req CreateIngredientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ingredient := models.Ingredient{
+		Name:            strings.TrimSpace(req.Name),
+		Category:        strings.TrimSpace(req.Category),
+		Unit:            services.NormalizeUnit(req.Unit),
+		CaloriesPer100g: req.CaloriesPer100g,
+	}
+	if ingredient.Unit != "" && services.UnitDimension(ingredient.Unit) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unknown unit %q", req.Unit)})
+		return
+	}
+
+	names := append([]string{ingredient.Name}, req.Aliases...)
+	seen := map[string]bool{}
+	for _, name := range names {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if key == "" || seen[key] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Names and aliases must be distinct and not empty"})
+			return
+		}
+		seen[key] = true
+		if !s.nameAvailable(c, name, 0) {
+			return
+		}
+	}
+
+	err := s.repo.Transaction(func(tx repository.Repository) error {
+		if err := tx.CreateIngredient(&ingredient); err != nil {
+			return err
+		}
+		for _, name := range req.Aliases {
+			if err := tx.CreateIngredientAlias(&models.IngredientAlias{IngredientID: ingredient.ID, Name: strings.TrimSpace(name)}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create ingredient"})
+		return
+	}
+
+	ingredient, _ = s.repo.Ingredient(ingredient.ID)
+	c.JSON(http.StatusCreated, ingredient)
+}
+
+// UpdateIngredient renames or changes the details of an ingredient
+func (s *Server) UpdateIngredient(c *gin.Context) {
+	ingredient, err := s.repo.Ingredient(parseUint(c.Param("id")))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ingredient not found"})
+		return
+	}
+
+	var req UpdateIngredientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fields := map[string]interface{}{}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Name must not be empty"})
+			return
+		}
+		if !s.nameAvailable(c, name, ingredient.ID) {
+			return
+		}
+		fields["name"] = name
+	}
+	if req.Category != nil {
+		fields["category"] = strings.TrimSpace(*req.Category)
+	}
+	if req.Unit != nil {
+		unit := services.NormalizeUnit(*req.Unit)
+		if unit != "" && services.UnitDimension(unit) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unknown unit %q", *req.Unit)})
+			return
+		}
+		fields["unit"] = unit
+	}
+	if req.CaloriesPer100g != nil {
+		fields["calories_per100g"] = *req.CaloriesPer100g
+	}
+
+	if len(fields) > 0 {
+		if err := s.repo.UpdateIngredient(&ingredient, fields); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update ingredient"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, ingredient)
+}
+
+// DeleteIngredient removes an ingredient no meal, shopping list or price refers to
+func (s *Server) DeleteIngredient(c *gin.Context) {
+	ingredient, err := s.repo.Ingredient(parseUint(c.Param("id")))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ingredient not found"})
+		return
+	}
+
+	inUse, err := s.repo.IngredientInUse(ingredient.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete ingredient"})
+		return
+	}
+	if inUse {
+		c.JSON(http.StatusConflict, gin.H{"error": "Ingredient is used by meals, shopping lists or prices; add its name as an alias of another ingredient instead"})
+		return
+	}
+
+	if err := s.repo.DeleteIngredient(&ingredient); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete ingredient"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Ingredient deleted successfully"})
+}
+
+// AddIngredientAlias adds a name that resolves to the ingredient
+func (s *Server) AddIngredientAlias(c *gin.Context) {
+	ingredient, err := s.repo.Ingredient(parseUint(c.Param("id")))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ingredient not found"})
+		return
+	}
+
+	var req AddIngredientAliasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name must not be empty"})
+		return
+	}
+	if !s.nameAvailable(c, name, 0) {
+		return
+	}
+
+	alias := models.IngredientAlias{IngredientID: ingredient.ID, Name: name}
+	if err := s.repo.CreateIngredientAlias(&alias); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add alias"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, alias)
+}
+
+// DeleteIngredientAlias removes one of an ingredient's aliases
+func (s *Server) DeleteIngredientAlias(c *gin.Context) {
+	alias, err := s.repo.IngredientAlias(parseUint(c.Param("alias_id")), parseUint(c.Param("id")))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Alias not found"})
+		return
+	}
+
+	if err := s.repo.DeleteIngredientAlias(&alias); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete alias"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Alias deleted successfully"})
+}
+
+// nameAvailable checks that no ingredient other than ingredientID has name as its
+// name or alias, responding with a conflict when one does
+func (s *Server) nameAvailable(c *gin.Context, name string, ingredientID uint) bool {
+	existing, err := s.repo.IngredientNamed(name)
+	switch {
+	case isNotFound(err):
+		return true
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check ingredient names"})
+		return false
+	case existing.ID != ingredientID:
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("%q already names ingredient %q", name, existing.Name), "ingredient": existing})
+		return false
+	}
+	return true
+}
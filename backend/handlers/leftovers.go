@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"food-app/models"
+	"food-app/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ScheduleLeftoverRequest struct {
+	SourceDay      string `json:"source_day" binding:"required"`
+	SourceMealType string `json:"source_meal_type" binding:"required"`
+	Day            string `json:"day" binding:"required"`
+	MealType       string `json:"meal_type" binding:"required"`
+	Headcount      int    `json:"headcount"` // 0 uses the plan's household size
+}
+
+// ScheduleLeftover fills a later slot with leftovers of a cooked entry,
+// e.g. Monday dinner covering Tuesday lunch. The cook's shopping quantities grow to match.
+func (s *Server) ScheduleLeftover(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var req ScheduleLeftoverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sourceSlot, sourceOK := models.SlotIndex(req.SourceDay, req.SourceMealType)
+	targetSlot, targetOK := models.SlotIndex(req.Day, req.MealType)
+	if !sourceOK || !targetOK {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown day or meal type"})
+		return
+	}
+
+	mealPlan, err := s.activePlan(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No active meal plan found"})
+		return
+	}
+
+	source, err := s.repo.EntryInSlot(mealPlan.ID, req.SourceDay, req.SourceMealType)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No meal planned in the source slot"})
+		return
+	}
+
+	if source.IsLeftover() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The source slot is itself leftovers; use the original cook"})
+		return
+	}
+
+	if targetSlot <= sourceSlot {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Leftovers must be scheduled after the source meal"})
+		return
+	}
+
+	entry := models.MealPlanEntry{
+		MealPlanID:   mealPlan.ID,
+		MealID:       source.MealID,
+		Day:          req.Day,
+		MealType:     req.MealType,
+		Servings:     1,
+		Headcount:    req.Headcount,
+		LeftoverOfID: &source.ID,
+	}
+
+	err = s.repo.Transaction(func(tx repository.Repository) error {
+		if err := tx.ClearSlot(mealPlan.ID, req.Day, req.MealType); err != nil {
+			return err
+		}
+		if err := tx.CreateEntry(&entry); err != nil {
+			return err
+		}
+		return tx.SyncShoppingList(mealPlan.ID)
+	})
+	if errors.Is(err, repository.ErrLockedLeftover) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A locked leftover depends on this slot; unlock it first"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule leftovers"})
+		return
+	}
+
+	if stored, err := s.repo.Entry(entry.ID); err == nil {
+		entry = stored
+	}
+	s.publishPlanEvent(mealPlan.ID, EventPlanEntryUpdated, PlanEntryChange{Day: entry.Day, MealType: entry.MealType, Entry: &entry})
+	s.publishShoppingListReplaced(mealPlan.ID)
+
+	mealPlan, _ = s.repo.PlanWithShoppingList(mealPlan.ID)
+
+	c.JSON(http.StatusOK, mealPlan)
+}
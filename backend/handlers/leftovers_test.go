@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"food-app/models"
+)
+
+func TestScheduleLeftover(t *testing.T) {
+	api := newTestAPI(t)
+	meals := api.seedMeals()
+	token, _ := api.register("ann")
+	api.expect(http.StatusOK, "GET", "/current-meal-plan", token, nil, nil)
+	var plan models.MealPlan
+	api.expect(http.StatusOK, "PUT", "/current-meal-plan/meals", token, payload{
+		"day": "monday", "meal_type": "dinner", "meal_id": meals[2].ID,
+	}, &plan)
+
+	for _, req := range []ScheduleLeftoverRequest{
+		{SourceDay: "mon", SourceMealType: "dinner", Day: "tuesday", MealType: "lunch"},
+		{SourceDay: "monday", SourceMealType: "supper", Day: "tuesday", MealType: "lunch"},
+		{SourceDay: "monday", SourceMealType: "dinner", Day: "someday", MealType: "lunch"},
+		{SourceDay: "monday", SourceMealType: "dinner", Day: "tuesday", MealType: "brunch"},
+		{SourceDay: "monday", SourceMealType: "dinner", Day: "monday", MealType: "lunch"},
+	} {
+		api.expect(http.StatusBadRequest, "POST", "/current-meal-plan/leftovers", token, req, nil)
+	}
+
+	api.expect(http.StatusOK, "POST", "/current-meal-plan/leftovers", token, ScheduleLeftoverRequest{
+		SourceDay: "monday", SourceMealType: "dinner", Day: "tuesday", MealType: "lunch",
+	}, &plan)
+	source, _ := entryIn(plan, "monday", "dinner")
+	entry, _ := entryIn(plan, "tuesday", "lunch")
+	if entry.MealID != meals[2].ID || entry.LeftoverOfID == nil || *entry.LeftoverOfID != source.ID {
+		t.Errorf("leftover entry = %+v, want meal %d from entry %d", entry, meals[2].ID, source.ID)
+	}
+}
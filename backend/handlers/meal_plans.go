@@ -2,34 +2,54 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
 	"time"
 
-	"food-app/database"
 	"food-app/models"
+	"food-app/repository"
+	"food-app/services"
 
 	"github.com/gin-gonic/gin"
-	"math/rand"
 )
 
 type CreateMealPlanRequest struct {
-	Name      string             `json:"name" binding:"required"`
-	WeekStart string             `json:"week_start" binding:"required"`
-	Meals     []MealPlanEntryReq `json:"meals"`
+	Name          string             `json:"name" binding:"required"`
+	WeekStart     string             `json:"week_start" binding:"required"`
+	HouseholdSize int                `json:"household_size"`
+	Meals         []MealPlanEntryReq `json:"meals"`
 }
 
 type MealPlanEntryReq struct {
-	MealID   uint   `json:"meal_id" binding:"required"`
-	Day      string `json:"day" binding:"required"`
-	MealType string `json:"meal_type" binding:"required"`
-	Servings int    `json:"servings"`
+	MealID    uint   `json:"meal_id" binding:"required"`
+	Day       string `json:"day" binding:"required"`
+	MealType  string `json:"meal_type" binding:"required"`
+	Servings  int    `json:"servings"`  // deprecated alias for headcount
+	Headcount int    `json:"headcount"` // 0 uses the plan's household size
 }
 
 type AutoGenerateMealPlanRequest struct {
-	Name      string `json:"name" binding:"required"`
-	WeekStart string `json:"week_start" binding:"required"`
+	Name          string `json:"name" binding:"required"`
+	WeekStart     string `json:"week_start" binding:"required"`
+	HouseholdSize int    `json:"household_size"`
+	services.PlannerOptions
 }
 
-func CreateMealPlan(c *gin.Context) {
+// headcount returns the requested headcount, falling back to the deprecated servings field
+func (r MealPlanEntryReq) headcount() int {
+	if r.Headcount > 0 {
+		return r.Headcount
+	}
+	return r.Servings
+}
+
+func householdSizeOrDefault(size int) int {
+	if size > 0 {
+		return size
+	}
+	return 1
+}
+
+func (s *Server) CreateMealPlan(c *gin.Context) {
 	userID := c.GetUint("userID")
 
 	var req CreateMealPlanRequest
@@ -47,42 +67,31 @@ func CreateMealPlan(c *gin.Context) {
 
 	// Create meal plan
 	mealPlan := models.MealPlan{
-		UserID:    userID,
-		Name:      req.Name,
-		WeekStart: weekStart,
-		IsActive:  true,
+		UserID:        userID,
+		Name:          req.Name,
+		WeekStart:     weekStart,
+		HouseholdSize: householdSizeOrDefault(req.HouseholdSize),
 	}
 
-	if err := database.DB.Create(&mealPlan).Error; err != nil {
+	// Create the plan with its entries
+	err = s.repo.Transaction(func(tx repository.Repository) error {
+		if err := tx.CreatePlan(&mealPlan); err != nil {
+			return err
+		}
+		return createRequestedEntries(tx, mealPlan.ID, req.Meals)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create meal plan"})
 		return
 	}
 
-	// Add meal plan entries
-	for _, mealReq := range req.Meals {
-		servings := mealReq.Servings
-		if servings == 0 {
-			servings = 1
-		}
-
-		entry := models.MealPlanEntry{
-			MealPlanID: mealPlan.ID,
-			MealID:     mealReq.MealID,
-			Day:        mealReq.Day,
-			MealType:   mealReq.MealType,
-			Servings:   servings,
-		}
-
-		database.DB.Create(&entry)
-	}
-
 	// Load the complete meal plan with relationships
-	database.DB.Preload("Meals").Preload("Meals.Meal").Preload("Meals.Meal.Ingredients").First(&mealPlan, mealPlan.ID)
+	mealPlan, _ = s.repo.PlanWithMeals(mealPlan.ID)
 
 	c.JSON(http.StatusCreated, mealPlan)
 }
 
-func AutoGenerateMealPlan(c *gin.Context) {
+func (s *Server) AutoGenerateMealPlan(c *gin.Context) {
 	userID := c.GetUint("userID")
 
 	var req AutoGenerateMealPlanRequest
@@ -99,93 +108,57 @@ func AutoGenerateMealPlan(c *gin.Context) {
 	}
 
 	// Get user's liked meals
-	var interactions []models.UserMealInteraction
-	if err := database.DB.Preload("Meal").Preload("Meal.Ingredients").
-		Where("user_id = ? AND liked = true", userID).Find(&interactions).Error; err != nil {
+	likedMeals, err := s.repo.LikedMeals(userID)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch liked meals"})
 		return
 	}
 
-	if len(interactions) == 0 {
+	if len(likedMeals) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "No liked meals found. Please like some meals first."})
 		return
 	}
 
-	// Separate meals by type
-	mealsByType := map[string][]models.Meal{
-		"breakfast": {},
-		"lunch":     {},
-		"dinner":    {},
-	}
-
-	for _, interaction := range interactions {
-		meal := interaction.Meal
-		if meal.MealType != "" {
-			mealsByType[meal.MealType] = append(mealsByType[meal.MealType], meal)
-		}
-	}
-
-	// If no specific meal types, use all meals for all types
-	allMeals := []models.Meal{}
-	for _, interaction := range interactions {
-		allMeals = append(allMeals, interaction.Meal)
-	}
-
 	// Create meal plan
 	mealPlan := models.MealPlan{
-		UserID:    userID,
-		Name:      req.Name,
-		WeekStart: weekStart,
-		IsActive:  true,
+		UserID:        userID,
+		Name:          req.Name,
+		WeekStart:     weekStart,
+		HouseholdSize: householdSizeOrDefault(req.HouseholdSize),
 	}
 
-	if err := database.DB.Create(&mealPlan).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create meal plan"})
+	// Generate a varied week; the seed reproduces it
+	options, err := s.plannerOptionsFor(userID, mealPlan.HouseholdSize, req.PlannerOptions, likedMeals)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to estimate meal costs"})
 		return
 	}
+	result := services.NewMealPlanner(options).Plan(likedMeals)
 
-	// Generate meal entries for the week
-	days := []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
-	mealTypes := []string{"breakfast", "lunch", "dinner"}
-
-	for _, day := range days {
-		for _, mealType := range mealTypes {
-			// Choose meals for this meal type
-			availableMeals := mealsByType[mealType]
-			if len(availableMeals) == 0 {
-				// Fallback to all meals if no specific type available
-				availableMeals = allMeals
-			}
-
-			if len(availableMeals) > 0 {
-				// Randomly select a meal
-				selectedMeal := availableMeals[rand.Intn(len(availableMeals))]
-
-				entry := models.MealPlanEntry{
-					MealPlanID: mealPlan.ID,
-					MealID:     selectedMeal.ID,
-					Day:        day,
-					MealType:   mealType,
-					Servings:   1,
-				}
-
-				database.DB.Create(&entry)
-			}
+	err = s.repo.Transaction(func(tx repository.Repository) error {
+		if err := tx.CreatePlan(&mealPlan); err != nil {
+			return err
 		}
+		return tx.SavePlannedMeals(mealPlan.ID, result.Meals, nil)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create meal plan"})
+		return
 	}
+	c.Header("X-Planner-Seed", strconv.FormatInt(result.Seed, 10))
+	setPlannerBudgetHeaders(c, options, result)
 
 	// Load the complete meal plan with relationships
-	database.DB.Preload("Meals").Preload("Meals.Meal").Preload("Meals.Meal.Ingredients").First(&mealPlan, mealPlan.ID)
+	mealPlan, _ = s.repo.PlanWithMeals(mealPlan.ID)
 
 	c.JSON(http.StatusCreated, mealPlan)
 }
 
-func GetMealPlans(c *gin.Context) {
+func (s *Server) GetMealPlans(c *gin.Context) {
 	userID := c.GetUint("userID")
 
-	var mealPlans []models.MealPlan
-	if err := database.DB.Preload("Meals").Preload("Meals.Meal").
-		Where("user_id = ?", userID).Order("created_at DESC").Find(&mealPlans).Error; err != nil {
+	mealPlans, err := s.repo.UserPlans(userID)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch meal plans"})
 		return
 	}
@@ -193,13 +166,11 @@ func GetMealPlans(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"meal_plans": mealPlans})
 }
 
-func GetMealPlan(c *gin.Context) {
+func (s *Server) GetMealPlan(c *gin.Context) {
 	userID := c.GetUint("userID")
-	planID := c.Param("id")
 
-	var mealPlan models.MealPlan
-	if database.DB.Preload("Meals").Preload("Meals.Meal").Preload("Meals.Meal.Ingredients").
-		Where("id = ? AND user_id = ?", planID, userID).First(&mealPlan).RecordNotFound() {
+	mealPlan, err := s.repo.PlanWithMeals(parseUint(c.Param("id")))
+	if err != nil || mealPlan.UserID != userID {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Meal plan not found"})
 		return
 	}
@@ -207,12 +178,11 @@ func GetMealPlan(c *gin.Context) {
 	c.JSON(http.StatusOK, mealPlan)
 }
 
-func UpdateMealPlan(c *gin.Context) {
+func (s *Server) UpdateMealPlan(c *gin.Context) {
 	userID := c.GetUint("userID")
-	planID := c.Param("id")
 
-	var mealPlan models.MealPlan
-	if database.DB.Where("id = ? AND user_id = ?", planID, userID).First(&mealPlan).RecordNotFound() {
+	mealPlan, err := s.repo.Plan(parseUint(c.Param("id")))
+	if err != nil || mealPlan.UserID != userID {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Meal plan not found"})
 		return
 	}
@@ -224,135 +194,109 @@ func UpdateMealPlan(c *gin.Context) {
 	}
 
 	// Update meal plan
+	fields := map[string]interface{}{}
 	if req.Name != "" {
-		mealPlan.Name = req.Name
+		fields["name"] = req.Name
 	}
 
 	if req.WeekStart != "" {
 		if weekStart, err := time.Parse("2006-01-02", req.WeekStart); err == nil {
-			mealPlan.WeekStart = weekStart
+			fields["week_start"] = weekStart
 		}
 	}
 
-	database.DB.Save(&mealPlan)
-
-	// Update meal entries if provided
-	if len(req.Meals) > 0 {
-		// Delete existing entries
-		database.DB.Where("meal_plan_id = ?", mealPlan.ID).Delete(&models.MealPlanEntry{})
+	if req.HouseholdSize > 0 {
+		fields["household_size"] = req.HouseholdSize
+	}
 
-		// Add new entries
-		for _, mealReq := range req.Meals {
-			servings := mealReq.Servings
-			if servings == 0 {
-				servings = 1
+	// The plan may be someone's active or shared plan, so the shopping list and
+	// everyone watching it follow the change as they do for /current-meal-plan
+	err = s.repo.Transaction(func(tx repository.Repository) error {
+		if len(fields) > 0 {
+			if err := tx.UpdatePlan(&mealPlan, fields); err != nil {
+				return err
 			}
+		}
 
-			entry := models.MealPlanEntry{
-				MealPlanID: mealPlan.ID,
-				MealID:     mealReq.MealID,
-				Day:        mealReq.Day,
-				MealType:   mealReq.MealType,
-				Servings:   servings,
+		// Replace meal entries if provided
+		if len(req.Meals) > 0 {
+			if err := tx.ClearEntries(mealPlan.ID, nil); err != nil {
+				return err
+			}
+			if err := createRequestedEntries(tx, mealPlan.ID, req.Meals); err != nil {
+				return err
 			}
-
-			database.DB.Create(&entry)
 		}
+		return tx.SyncShoppingList(mealPlan.ID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update meal plan"})
+		return
 	}
 
 	// Load updated meal plan
-	database.DB.Preload("Meals").Preload("Meals.Meal").Preload("Meals.Meal.Ingredients").First(&mealPlan, mealPlan.ID)
+	mealPlan, _ = s.repo.PlanWithShoppingList(mealPlan.ID)
+
+	s.publishPlanEvent(mealPlan.ID, EventPlanReplaced, mealPlan)
 
 	c.JSON(http.StatusOK, mealPlan)
 }
 
-func DeleteMealPlan(c *gin.Context) {
+func (s *Server) DeleteMealPlan(c *gin.Context) {
 	userID := c.GetUint("userID")
-	planID := c.Param("id")
 
-	var mealPlan models.MealPlan
-	if database.DB.Where("id = ? AND user_id = ?", planID, userID).First(&mealPlan).RecordNotFound() {
+	mealPlan, err := s.repo.Plan(parseUint(c.Param("id")))
+	if err != nil || mealPlan.UserID != userID {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Meal plan not found"})
 		return
 	}
 
-	// Delete meal plan entries first
-	database.DB.Where("meal_plan_id = ?", mealPlan.ID).Delete(&models.MealPlanEntry{})
+	// The active plan can be deleted; /current-meal-plan then starts a new one
+	err = s.repo.Transaction(func(tx repository.Repository) error {
+		return tx.DeletePlan(&mealPlan)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete meal plan"})
+		return
+	}
 
-	// Delete meal plan
-	database.DB.Delete(&mealPlan)
+	s.publishPlanEvent(mealPlan.ID, EventPlanDeactivated, gin.H{"active_meal_plan_id": nil})
 
 	c.JSON(http.StatusOK, gin.H{"message": "Meal plan deleted successfully"})
 }
 
-func GenerateShoppingList(c *gin.Context) {
+// GenerateShoppingList brings the plan's shopping list up to date; a plan has a single list
+func (s *Server) GenerateShoppingList(c *gin.Context) {
 	userID := c.GetUint("userID")
-	planID := c.Param("id")
 
-	var mealPlan models.MealPlan
-	if database.DB.Preload("Meals").Preload("Meals.Meal").Preload("Meals.Meal.Ingredients").
-		Where("id = ? AND user_id = ?", planID, userID).First(&mealPlan).RecordNotFound() {
+	mealPlan, err := s.repo.Plan(parseUint(c.Param("id")))
+	if err != nil || mealPlan.UserID != userID {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Meal plan not found"})
 		return
 	}
 
-	// Create shopping list
-	shoppingList := models.ShoppingList{
-		UserID:     userID,
-		MealPlanID: mealPlan.ID,
-		Name:       "Shopping List for " + mealPlan.Name,
-	}
-
-	if err := database.DB.Create(&shoppingList).Error; err != nil {
+	if err := s.repo.Transaction(func(tx repository.Repository) error {
+		return tx.SyncShoppingList(mealPlan.ID)
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create shopping list"})
 		return
 	}
+	s.publishShoppingListReplaced(mealPlan.ID)
 
-	// Aggregate ingredients from all meals
-	ingredientQuantities := make(map[uint]map[string]float64) // ingredient_id -> unit -> total_quantity
-
-	for _, entry := range mealPlan.Meals {
-		// Get meal ingredients with quantities (this would need to be implemented in meal seeding)
-		var mealIngredients []models.MealIngredient
-		database.DB.Preload("Ingredient").Where("meal_id = ?", entry.MealID).Find(&mealIngredients)
-
-		for _, mealIngredient := range mealIngredients {
-			ingredientID := mealIngredient.IngredientID
-			unit := mealIngredient.Unit
-			quantity := mealIngredient.Quantity * float64(entry.Servings)
-
-			if ingredientQuantities[ingredientID] == nil {
-				ingredientQuantities[ingredientID] = make(map[string]float64)
-			}
-			ingredientQuantities[ingredientID][unit] += quantity
-		}
-	}
-
-	// Create shopping list items
-	for ingredientID, units := range ingredientQuantities {
-		for unit, quantity := range units {
-			item := models.ShoppingListItem{
-				ShoppingListID: shoppingList.ID,
-				IngredientID:   ingredientID,
-				Quantity:       quantity,
-				Unit:           unit,
-			}
-			database.DB.Create(&item)
-		}
+	shoppingList, err := s.repo.ShoppingListForPlan(mealPlan.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create shopping list"})
+		return
 	}
 
-	// Load complete shopping list
-	database.DB.Preload("Items").Preload("Items.Ingredient").First(&shoppingList, shoppingList.ID)
-
 	c.JSON(http.StatusCreated, shoppingList)
 }
 
-func GetShoppingLists(c *gin.Context) {
+func (s *Server) GetShoppingLists(c *gin.Context) {
 	userID := c.GetUint("userID")
 
-	var shoppingLists []models.ShoppingList
-	if err := database.DB.Preload("Items").Preload("Items.Ingredient").
-		Where("user_id = ?", userID).Order("created_at DESC").Find(&shoppingLists).Error; err != nil {
+	shoppingLists, err := s.repo.ShoppingLists(userID, s.currentHouseholdID(userID))
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch shopping lists"})
 		return
 	}
@@ -360,14 +304,11 @@ func GetShoppingLists(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"shopping_lists": shoppingLists})
 }
 
-func UpdateShoppingListItem(c *gin.Context) {
+func (s *Server) UpdateShoppingListItem(c *gin.Context) {
 	userID := c.GetUint("userID")
-	itemID := c.Param("item_id")
 
-	var item models.ShoppingListItem
-	if database.DB.Joins("JOIN shopping_lists ON shopping_list_items.shopping_list_id = shopping_lists.id").
-		Where("shopping_list_items.id = ? AND shopping_lists.user_id = ?", itemID, userID).
-		First(&item).RecordNotFound() {
+	item, err := s.findAccessibleShoppingItem(userID, c.Param("item_id"))
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Shopping list item not found"})
 		return
 	}
@@ -378,10 +319,36 @@ func UpdateShoppingListItem(c *gin.Context) {
 		return
 	}
 
-	if err := database.DB.Model(&item).Updates(updateData).Error; err != nil {
+	if err := s.repo.UpdateShoppingItem(&item, updateData); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update item"})
 		return
 	}
 
+	s.publishShoppingItemUpdated(item)
+
 	c.JSON(http.StatusOK, item)
 }
+
+// createRequestedEntries adds the entries of a create or update request to a plan
+func createRequestedEntries(tx repository.PlanRepository, mealPlanID uint, meals []MealPlanEntryReq) error {
+	for _, mealReq := range meals {
+		servings := mealReq.Servings
+		if servings == 0 {
+			servings = 1
+		}
+
+		entry := models.MealPlanEntry{
+			MealPlanID: mealPlanID,
+			MealID:     mealReq.MealID,
+			Day:        mealReq.Day,
+			MealType:   mealReq.MealType,
+			Servings:   servings,
+			Headcount:  mealReq.headcount(),
+		}
+
+		if err := tx.CreateEntry(&entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
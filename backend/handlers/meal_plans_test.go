@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"food-app/models"
+	"food-app/services"
+)
+
+func TestLegacyPlanUpdateSyncsAndPublishes(t *testing.T) {
+	api := newTestAPI(t)
+	meals := api.seedMeals()
+	token, _ := api.register("ann")
+	api.likeAll(token, meals)
+	api.expect(http.StatusOK, "GET", "/current-meal-plan", token, nil, nil)
+	plan := api.populate(token)
+
+	events, _, _, cancel := api.server.events.Subscribe(planTopic(plan.ID), "", services.FromLatest)
+	defer cancel()
+
+	var updated models.MealPlan
+	api.expect(http.StatusOK, "PUT", fmt.Sprintf("/meal-plans/%d", plan.ID), token, CreateMealPlanRequest{
+		Name:      "Quiet week",
+		WeekStart: "2026-10-19",
+		Meals:     []MealPlanEntryReq{{MealID: meals[2].ID, Day: "monday", MealType: "dinner", Headcount: 2}},
+	}, &updated)
+	if len(updated.Meals) != 1 || updated.Name != "Quiet week" {
+		t.Fatalf("updated plan %q has %d entries, want 1", updated.Name, len(updated.Meals))
+	}
+
+	// The list now covers only the one dinner: a pound of its protein and a cup of rice for two
+	items := api.shoppingItems(plan.ID)
+	if len(items) != 2 {
+		t.Errorf("shopping list has %d items, want the 2 of the remaining meal: %+v", len(items), items)
+	}
+	if rice, ok := shoppingItemFor(items, "Rice"); !ok || rice.Quantity != 1 {
+		t.Errorf("rice = %+v, want 1 cup", rice)
+	}
+
+	select {
+	case event := <-events:
+		if event.Type != EventPlanReplaced {
+			t.Errorf("published %s, want %s", event.Type, EventPlanReplaced)
+		}
+	default:
+		t.Error("no event was published")
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"food-app/models"
+)
+
+func TestMealBrowsing(t *testing.T) {
+	api := newTestAPI(t)
+	meals := api.seedMeals()
+
+	var list struct {
+		Meals []models.Meal `json:"meals"`
+	}
+	api.expect(http.StatusOK, "GET", "/meals?cuisine=thai", "", nil, &list)
+	if len(list.Meals) != 3 {
+		t.Fatalf("got %d thai meals, want 3", len(list.Meals))
+	}
+	for _, meal := range list.Meals {
+		if meal.Cuisine != "thai" {
+			t.Errorf("cuisine filter returned %q", meal.Cuisine)
+		}
+	}
+
+	var meal models.Meal
+	api.expect(http.StatusOK, "GET", fmt.Sprintf("/meals/%d", meals[0].ID), "", nil, &meal)
+	if meal.Name != meals[0].Name || len(meal.IngredientLines) != 2 {
+		t.Errorf("meal %q has %d ingredient lines, want %q with 2", meal.Name, len(meal.IngredientLines), meals[0].Name)
+	}
+	api.expect(http.StatusNotFound, "GET", "/meals/9999", "", nil, nil)
+}
+
+func TestLikesAndReviews(t *testing.T) {
+	api := newTestAPI(t)
+	meals := api.seedMeals()
+	token, _ := api.register("ann")
+
+	api.likeAll(token, meals[:2])
+	api.expect(http.StatusOK, "POST", fmt.Sprintf("/meals/%d/dislike", meals[1].ID), token, nil, nil)
+
+	var liked struct {
+		Meals []models.Meal `json:"meals"`
+	}
+	api.expect(http.StatusOK, "GET", "/meals/liked", token, nil, &liked)
+	if len(liked.Meals) != 1 || liked.Meals[0].ID != meals[0].ID {
+		t.Errorf("liked meals = %d, want only %q", len(liked.Meals), meals[0].Name)
+	}
+
+	path := fmt.Sprintf("/meals/%d/reviews", meals[0].ID)
+	api.expect(http.StatusCreated, "POST", path, token, payload{"rating": 4, "comment": "good"}, nil)
+
+	var reviews struct {
+		Reviews []models.MealReview `json:"reviews"`
+	}
+	api.expect(http.StatusOK, "GET", path, "", nil, &reviews)
+	if len(reviews.Reviews) != 1 || reviews.Reviews[0].Rating != 4 {
+		t.Errorf("reviews = %+v, want one 4-star review", reviews.Reviews)
+	}
+}
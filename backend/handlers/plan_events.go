@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"food-app/models"
+	"food-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Plan stream event types
+const (
+	EventPlanReplaced         = "plan.replaced"
+	EventPlanEntryUpdated     = "plan_entry.updated"
+	EventShoppingListReplaced = "shopping_list.replaced"
+	EventShoppingItemAdded    = "shopping_item.added" // a manual item joined the list
+	EventShoppingItemUpdated  = "shopping_item.updated"
+	EventShoppingItemRemoved  = "shopping_item.removed"
+	EventPlanDeactivated      = "plan.deactivated" // the plan is no longer active; reload and reconnect
+)
+
+const streamHeartbeat = 25 * time.Second
+
+// PlanEntryChange is the payload of a plan_entry.updated event; Entry is nil when the slot was cleared
+type PlanEntryChange struct {
+	Day      string                `json:"day"`
+	MealType string                `json:"meal_type"`
+	Entry    *models.MealPlanEntry `json:"entry"`
+}
+
+func planTopic(mealPlanID uint) string {
+	return fmt.Sprintf("plan:%d", mealPlanID)
+}
+
+// publishPlanEvent notifies everyone watching a plan
+func (s *Server) publishPlanEvent(mealPlanID uint, eventType string, data interface{}) {
+	s.events.Publish(planTopic(mealPlanID), eventType, data)
+}
+
+// publishShoppingListReplaced reloads the plan's shopping list and publishes it
+func (s *Server) publishShoppingListReplaced(mealPlanID uint) {
+	shoppingList, err := s.repo.ShoppingListForPlan(mealPlanID)
+	if err != nil {
+		return
+	}
+	s.publishPlanEvent(mealPlanID, EventShoppingListReplaced, shoppingList)
+}
+
+// publishShoppingItemUpdated notifies watchers of the plan the item's list belongs to
+func (s *Server) publishShoppingItemUpdated(item models.ShoppingListItem) {
+	shoppingList, err := s.repo.ShoppingList(item.ShoppingListID)
+	if err != nil {
+		return
+	}
+
+	s.publishPlanEvent(shoppingList.MealPlanID, EventShoppingItemUpdated, item)
+}
+
+// publishShoppingItemAdded notifies watchers of the plan that an item joined its list
+func (s *Server) publishShoppingItemAdded(mealPlanID uint, item models.ShoppingListItem) {
+	s.publishPlanEvent(mealPlanID, EventShoppingItemAdded, item)
+}
+
+// publishShoppingItemRemoved notifies watchers of the plan that an item left its list
+func (s *Server) publishShoppingItemRemoved(mealPlanID uint, item models.ShoppingListItem) {
+	s.publishPlanEvent(mealPlanID, EventShoppingItemRemoved, gin.H{"id": item.ID, "shopping_list_id": item.ShoppingListID})
+}
+
+// StreamCurrentMealPlan streams plan and shopping list changes as Server-Sent Events.
+// Clients resume by sending the last seen event id in Last-Event-ID or ?since=;
+// if it is from before a restart or no longer retained a "reset" event tells them to reload.
+func (s *Server) StreamCurrentMealPlan(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	mealPlan, err := s.activePlan(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No active meal plan found"})
+		return
+	}
+
+	var epoch string
+	since := services.FromLatest
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("since")
+	}
+	if lastEventID != "" {
+		epoch, since, err = services.ParseEventID(lastEventID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event id"})
+			return
+		}
+	}
+
+	topic := planTopic(mealPlan.ID)
+	events, backlog, resumed, cancel := s.events.Subscribe(topic, epoch, since)
+	defer cancel()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	if !resumed {
+		writeStreamEvent(c.Writer, services.Event{
+			Epoch:     s.events.Epoch(),
+			Version:   s.events.Version(topic),
+			Type:      "reset",
+			Data:      gin.H{"meal_plan_id": mealPlan.ID},
+			CreatedAt: time.Now(),
+		})
+	}
+	for _, event := range backlog {
+		writeStreamEvent(c.Writer, event)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, open := <-events:
+			if !open {
+				return false
+			}
+			writeStreamEvent(w, event)
+			return true
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			return true
+		}
+	})
+}
+
+// writeStreamEvent writes one SSE frame; the id carries the epoch and version used for resume
+func writeStreamEvent(w io.Writer, event services.Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID(), event.Type, data)
+}
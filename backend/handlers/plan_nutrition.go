@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"food-app/models"
+	"food-app/repository"
+	"food-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UpdatePlanSettingsRequest struct {
+	HouseholdSize int `json:"household_size" binding:"required,min=1"`
+}
+
+type SlotAttendanceRequest struct {
+	Day      string   `json:"day" binding:"required"`
+	MealType string   `json:"meal_type" binding:"required"`
+	Absent   []string `json:"absent"` // usernames of members who will not eat this slot
+}
+
+// DayNutrition is the nutrition of one day's planned meals
+type DayNutrition struct {
+	Day       string               `json:"day"`
+	Total     models.NutritionInfo `json:"total"`
+	PerPerson models.NutritionInfo `json:"per_person"`
+	Guests    models.NutritionInfo `json:"guests"`
+}
+
+// MemberNutrition is what one household member eats over the week, counting only
+// the entries they are not absent from
+type MemberNutrition struct {
+	UserID               uint                 `json:"user_id"`
+	Username             string               `json:"username"`
+	CalorieGoal          int                  `json:"calorie_goal"`
+	Nutrition            models.NutritionInfo `json:"nutrition"`
+	DailyAverageCalories float64              `json:"daily_average_calories"`
+}
+
+// PlanNutrition splits a plan's nutrition between household members and guests.
+// Meal nutrition is per serving; each entry cooks one serving per headcount.
+// PerPerson is the share of an average household member; Members attributes
+// each entry to the members who eat it.
+type PlanNutrition struct {
+	HouseholdSize int                  `json:"household_size"`
+	Total         models.NutritionInfo `json:"total"`
+	PerPerson     models.NutritionInfo `json:"per_person"`
+	Guests        models.NutritionInfo `json:"guests"`
+	Days          []DayNutrition       `json:"days"`
+	Members       []MemberNutrition    `json:"members"`
+}
+
+// UpdateCurrentPlanSettings sets plan-level options such as the household size
+func (s *Server) UpdateCurrentPlanSettings(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var req UpdatePlanSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	mealPlan, err := s.activePlan(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No active meal plan found"})
+		return
+	}
+
+	// Quantities depend on headcount, so update the shopping list with the size
+	err = s.repo.Transaction(func(tx repository.Repository) error {
+		if err := tx.UpdatePlan(&mealPlan, map[string]interface{}{"household_size": req.HouseholdSize}); err != nil {
+			return err
+		}
+		return tx.SyncShoppingList(mealPlan.ID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update meal plan"})
+		return
+	}
+
+	mealPlan, _ = s.repo.PlanWithShoppingList(mealPlan.ID)
+
+	s.publishPlanEvent(mealPlan.ID, EventPlanReplaced, mealPlan)
+
+	c.JSON(http.StatusOK, mealPlan)
+}
+
+// SetCurrentPlanAttendance records which members will not eat a slot of the current
+// plan. Their servings leave the entry's default headcount and their nutrition.
+func (s *Server) SetCurrentPlanAttendance(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var req SlotAttendanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	mealPlan, err := s.activePlan(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No active meal plan found"})
+		return
+	}
+
+	entry, err := s.repo.EntryInSlot(mealPlan.ID, req.Day, req.MealType)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No meal planned in this slot"})
+		return
+	}
+
+	members := s.planMembers(mealPlan)
+	absent := []string{}
+	for _, name := range req.Absent {
+		member, ok := memberNamed(members, name)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Not a member of this plan's household: " + name})
+			return
+		}
+		if !containsFold(absent, member.Username) {
+			absent = append(absent, member.Username)
+		}
+	}
+
+	// The default headcount drops with each absent member, so the list changes too
+	err = s.repo.Transaction(func(tx repository.Repository) error {
+		if err := tx.SetEntryAbsentMembers(&entry, absent); err != nil {
+			return err
+		}
+		return tx.SyncShoppingList(mealPlan.ID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update slot"})
+		return
+	}
+
+	if stored, err := s.repo.Entry(entry.ID); err == nil {
+		entry = stored
+	}
+	s.publishPlanEvent(mealPlan.ID, EventPlanEntryUpdated, PlanEntryChange{Day: entry.Day, MealType: entry.MealType, Entry: &entry})
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// GetCurrentPlanNutrition returns plan totals, per-person, guest and per-member nutrition
+func (s *Server) GetCurrentPlanNutrition(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	mealPlan, err := s.repo.PlanWithMeals(s.activeMealPlanID(userID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No active meal plan found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, calculatePlanNutrition(mealPlan.Meals, mealPlan.HouseholdSize, s.planMembers(mealPlan)))
+}
+
+// planMembers returns the users who eat from a plan: its household's members,
+// or just its owner for a personal plan
+func (s *Server) planMembers(mealPlan models.MealPlan) []models.User {
+	if mealPlan.HouseholdID != nil {
+		if household, err := s.repo.HouseholdWithMembers(*mealPlan.HouseholdID); err == nil {
+			users := make([]models.User, 0, len(household.Members))
+			for _, member := range household.Members {
+				users = append(users, member.User)
+			}
+			return users
+		}
+	}
+	if owner, err := s.repo.User(mealPlan.UserID); err == nil {
+		return []models.User{owner}
+	}
+	return nil
+}
+
+func memberNamed(members []models.User, username string) (models.User, bool) {
+	for _, member := range members {
+		if strings.EqualFold(member.Username, strings.TrimSpace(username)) {
+			return member, true
+		}
+	}
+	return models.User{}, false
+}
+
+func containsFold(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// calculatePlanNutrition attributes each entry's servings: up to the household
+// members at the table go to them (shared equally), any beyond that are guests.
+// Each member is attributed the household share of the entries they eat.
+func calculatePlanNutrition(entries []models.MealPlanEntry, householdSize int, members []models.User) PlanNutrition {
+	if householdSize <= 0 {
+		householdSize = 1
+	}
+
+	summary := PlanNutrition{HouseholdSize: householdSize, Members: []MemberNutrition{}}
+	for _, member := range members {
+		summary.Members = append(summary.Members, MemberNutrition{
+			UserID:      member.ID,
+			Username:    member.Username,
+			CalorieGoal: member.CalorieGoal,
+		})
+	}
+
+	days := make(map[string]*DayNutrition, len(services.PlanDays))
+	for _, day := range services.PlanDays {
+		summary.Days = append(summary.Days, DayNutrition{Day: day})
+	}
+	for i := range summary.Days {
+		days[summary.Days[i].Day] = &summary.Days[i]
+	}
+
+	for _, entry := range entries {
+		headcount := entry.EffectiveHeadcount(householdSize)
+		perServing := entry.Meal.NutritionInfo
+
+		present := householdSize - len(entry.AbsentMembers)
+		if present < 0 {
+			present = 0
+		}
+		householdServings := headcount
+		if householdServings > present {
+			householdServings = present
+		}
+		guestServings := headcount - householdServings
+
+		total := perServing.Scaled(float64(headcount))
+		perPerson := perServing.Scaled(float64(householdServings) / float64(householdSize))
+		guests := perServing.Scaled(float64(guestServings))
+
+		summary.Total.Add(total)
+		summary.PerPerson.Add(perPerson)
+		summary.Guests.Add(guests)
+
+		if present > 0 {
+			share := perServing.Scaled(float64(householdServings) / float64(present))
+			for i := range summary.Members {
+				if !containsFold(entry.AbsentMembers, summary.Members[i].Username) {
+					summary.Members[i].Nutrition.Add(share)
+				}
+			}
+		}
+
+		if day, ok := days[entry.Day]; ok {
+			day.Total.Add(total)
+			day.PerPerson.Add(perPerson)
+			day.Guests.Add(guests)
+		}
+	}
+
+	for i := range summary.Members {
+		summary.Members[i].DailyAverageCalories = summary.Members[i].Nutrition.Calories / float64(len(services.PlanDays))
+	}
+
+	return summary
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"food-app/models"
+)
+
+func TestCalculatePlanNutritionSplitsGuests(t *testing.T) {
+	meal := models.Meal{NutritionInfo: models.NutritionInfo{Calories: 500}}
+	summary := calculatePlanNutrition([]models.MealPlanEntry{
+		{Day: "monday", MealType: "dinner", Meal: meal},                 // household of 2
+		{Day: "saturday", MealType: "dinner", Headcount: 5, Meal: meal}, // 3 guests
+		{Day: "sunday", MealType: "lunch", Headcount: 1, Meal: meal},    // one member
+	}, 2, nil)
+
+	if summary.Total.Calories != 4000 {
+		t.Errorf("total = %v calories, want 4000", summary.Total.Calories)
+	}
+	if summary.Guests.Calories != 1500 {
+		t.Errorf("guests = %v calories, want 1500", summary.Guests.Calories)
+	}
+	// 2 + 2 + 1 household servings shared by 2 people
+	if summary.PerPerson.Calories != 1250 {
+		t.Errorf("per person = %v calories, want 1250", summary.PerPerson.Calories)
+	}
+	if len(summary.Days) != 7 || summary.Days[5].Guests.Calories != 1500 || summary.Days[6].PerPerson.Calories != 250 {
+		t.Errorf("days = %+v", summary.Days)
+	}
+}
+
+func TestCalculatePlanNutritionAttributesEntriesToMembers(t *testing.T) {
+	meal := models.Meal{NutritionInfo: models.NutritionInfo{Calories: 500}}
+	members := []models.User{{ID: 1, Username: "ann"}, {ID: 2, Username: "bob"}, {ID: 3, Username: "cat"}}
+	summary := calculatePlanNutrition([]models.MealPlanEntry{
+		{Day: "monday", MealType: "dinner", Meal: meal},                                            // everyone
+		{Day: "tuesday", MealType: "dinner", AbsentMembers: models.StringArray{"Bob"}, Meal: meal}, // ann and cat
+		{Day: "saturday", MealType: "dinner", Headcount: 4, AbsentMembers: models.StringArray{"cat"}, Meal: meal},
+	}, 3, members)
+
+	// Saturday feeds ann, bob and two guests
+	if summary.Total.Calories != 4500 || summary.Guests.Calories != 1000 {
+		t.Errorf("total = %v, guests = %v calories, want 4500 and 1000", summary.Total.Calories, summary.Guests.Calories)
+	}
+	want := map[string]float64{"ann": 1500, "bob": 1000, "cat": 1000}
+	if len(summary.Members) != 3 {
+		t.Fatalf("members = %+v", summary.Members)
+	}
+	for _, member := range summary.Members {
+		if member.Nutrition.Calories != want[member.Username] {
+			t.Errorf("%s ate %v calories, want %v", member.Username, member.Nutrition.Calories, want[member.Username])
+		}
+	}
+}
+
+func TestAbsentMembersLeaveHeadcountAndNutrition(t *testing.T) {
+	api := newTestAPI(t)
+	meals := api.seedMeals()
+	owner, _ := api.register("ann")
+	member, _ := api.register("bob")
+	api.store.DB().Model(&meals[0]).Update("calories", 600)
+
+	api.expect(http.StatusOK, "GET", "/current-meal-plan", owner, nil, nil)
+	api.expect(http.StatusCreated, "POST", "/household", owner, CreateHouseholdRequest{Name: "Home"}, nil)
+	var invitation models.HouseholdInvitation
+	api.expect(http.StatusCreated, "POST", "/household/invitations", owner, InviteMemberRequest{Email: "bob@example.com"}, &invitation)
+	api.expect(http.StatusOK, "POST", "/invitations/"+invitation.Token+"/accept", member, nil, nil)
+
+	var plan models.MealPlan
+	api.expect(http.StatusOK, "PUT", "/current-meal-plan/settings", owner, UpdatePlanSettingsRequest{HouseholdSize: 2}, &plan)
+	api.expect(http.StatusOK, "PUT", "/current-meal-plan/meals", owner, payload{
+		"day": "monday", "meal_type": "dinner", "meal_id": meals[0].ID,
+	}, nil)
+	rice, _ := shoppingItemFor(api.shoppingItems(plan.ID), "Rice")
+	if rice.Quantity != 1 {
+		t.Fatalf("rice for two = %v cups, want 1", rice.Quantity)
+	}
+
+	api.expect(http.StatusNotFound, "PUT", "/current-meal-plan/attendance", member, SlotAttendanceRequest{
+		Day: "tuesday", MealType: "dinner", Absent: []string{"bob"},
+	}, nil)
+	api.expect(http.StatusBadRequest, "PUT", "/current-meal-plan/attendance", member, SlotAttendanceRequest{
+		Day: "monday", MealType: "dinner", Absent: []string{"cat"},
+	}, nil)
+	var entry models.MealPlanEntry
+	api.expect(http.StatusOK, "PUT", "/current-meal-plan/attendance", member, SlotAttendanceRequest{
+		Day: "monday", MealType: "dinner", Absent: []string{"Bob"},
+	}, &entry)
+	if len(entry.AbsentMembers) != 1 || entry.AbsentMembers[0] != "bob" {
+		t.Errorf("absent = %q, want bob", entry.AbsentMembers)
+	}
+
+	// Only ann eats, so the default headcount and the list drop to one
+	rice, _ = shoppingItemFor(api.shoppingItems(plan.ID), "Rice")
+	if rice.Quantity != 0.5 {
+		t.Errorf("rice for one = %v cups, want 0.5", rice.Quantity)
+	}
+
+	var summary PlanNutrition
+	api.expect(http.StatusOK, "GET", "/current-meal-plan/nutrition", member, nil, &summary)
+	eaten := map[string]float64{}
+	for _, m := range summary.Members {
+		eaten[m.Username] = m.Nutrition.Calories
+	}
+	if len(eaten) != 2 || eaten["ann"] != 600 || eaten["bob"] != 0 {
+		t.Errorf("members ate %v, want ann 600 and bob 0", eaten)
+	}
+	if summary.Total.Calories != 600 || summary.Guests.Calories != 0 {
+		t.Errorf("total = %v, guests = %v", summary.Total.Calories, summary.Guests.Calories)
+	}
+}
@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"food-app/models"
+	"food-app/repository"
+	"food-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxSlotAlternatives is how many ranked alternatives are returned per slot
+const maxSlotAlternatives = 5
+
+type LockSlotRequest struct {
+	Day      string `json:"day" binding:"required"`
+	MealType string `json:"meal_type" binding:"required"`
+	Locked   bool   `json:"locked"`
+}
+
+type RegeneratePlanRequest struct {
+	services.PlannerOptions
+}
+
+type SwapSlotRequest struct {
+	Day      string `json:"day" binding:"required"`
+	MealType string `json:"meal_type" binding:"required"`
+	services.PlannerOptions
+}
+
+// SlotAlternatives ranks other liked meals for one slot, best first
+type SlotAlternatives struct {
+	Day          string                 `json:"day"`
+	MealType     string                 `json:"meal_type"`
+	Alternatives []services.Alternative `json:"alternatives"`
+}
+
+// PlanWithAlternatives is returned by regeneration and swaps
+type PlanWithAlternatives struct {
+	MealPlan models.MealPlan    `json:"meal_plan"`
+	Slots    []SlotAlternatives `json:"slots"`
+}
+
+// LockPlanSlot locks or unlocks a slot of the current plan so regeneration keeps it
+func (s *Server) LockPlanSlot(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var req LockSlotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	mealPlan, err := s.activePlan(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No active meal plan found"})
+		return
+	}
+
+	entry, err := s.repo.EntryInSlot(mealPlan.ID, req.Day, req.MealType)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No meal planned in this slot"})
+		return
+	}
+
+	if err := s.repo.SetEntryLocked(&entry, req.Locked); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update slot"})
+		return
+	}
+
+	if stored, err := s.repo.Entry(entry.ID); err == nil {
+		entry = stored
+	}
+	s.publishPlanEvent(mealPlan.ID, EventPlanEntryUpdated, PlanEntryChange{Day: entry.Day, MealType: entry.MealType, Entry: &entry})
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// RegenerateCurrentPlan replans every unlocked slot around the locked ones.
+// Leftovers of a locked cook, and the cook of a locked leftover, are kept too.
+func (s *Server) RegenerateCurrentPlan(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var req RegeneratePlanRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	mealPlan, err := s.repo.PlanWithMeals(s.activeMealPlanID(userID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No active meal plan found"})
+		return
+	}
+
+	likedMeals, err := s.repo.LikedMeals(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch liked meals"})
+		return
+	}
+	if len(likedMeals) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No liked meals found. Please like some meals first."})
+		return
+	}
+
+	kept := keptEntries(mealPlan.Meals)
+	var keptIDs []uint
+	var fixed []models.MealPlanEntry
+	existing := map[services.PlanSlot]uint{}
+	for _, entry := range mealPlan.Meals {
+		if kept[entry.ID] {
+			keptIDs = append(keptIDs, entry.ID)
+			fixed = append(fixed, entry)
+			existing[services.PlanSlot{Day: entry.Day, MealType: entry.MealType}] = entry.ID
+		}
+	}
+
+	options, err := s.plannerOptionsFor(userID, mealPlan.HouseholdSize, req.PlannerOptions, likedMeals)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to estimate meal costs"})
+		return
+	}
+	planner := services.NewMealPlanner(options)
+	result := planner.PlanAround(likedMeals, plannedFromEntries(fixed))
+
+	// Replace everything that is not kept, and the shopping list, together
+	err = s.repo.Transaction(func(tx repository.Repository) error {
+		if err := tx.ClearEntries(mealPlan.ID, keptIDs); err != nil {
+			return err
+		}
+		if err := tx.SavePlannedMeals(mealPlan.ID, result.Meals, existing); err != nil {
+			return err
+		}
+		return tx.SyncShoppingList(mealPlan.ID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to regenerate meal plan"})
+		return
+	}
+	c.Header("X-Planner-Seed", strconv.FormatInt(result.Seed, 10))
+	setPlannerBudgetHeaders(c, options, result)
+
+	mealPlan, _ = s.repo.PlanWithShoppingList(mealPlan.ID)
+
+	s.publishPlanEvent(mealPlan.ID, EventPlanReplaced, mealPlan)
+
+	slots := make([]SlotAlternatives, 0, len(result.Meals))
+	for _, planned := range result.Meals {
+		slots = append(slots, SlotAlternatives{
+			Day:          planned.Day,
+			MealType:     planned.MealType,
+			Alternatives: planner.Alternatives(likedMeals, result.Meals, planned.PlanSlot, maxSlotAlternatives),
+		})
+	}
+
+	c.JSON(http.StatusOK, PlanWithAlternatives{MealPlan: mealPlan, Slots: slots})
+}
+
+// SwapPlanSlot replaces one slot with the best-ranked liked meal that breaks none
+// of the planner's constraints given the rest of the plan
+func (s *Server) SwapPlanSlot(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var req SwapSlotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	mealPlan, err := s.repo.PlanWithMeals(s.activeMealPlanID(userID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No active meal plan found"})
+		return
+	}
+
+	var current *models.MealPlanEntry
+	for i, entry := range mealPlan.Meals {
+		if entry.Day == req.Day && entry.MealType == req.MealType {
+			current = &mealPlan.Meals[i]
+		}
+	}
+	if current != nil && current.Locked {
+		c.JSON(http.StatusConflict, gin.H{"error": "This slot is locked; unlock it before swapping"})
+		return
+	}
+
+	likedMeals, err := s.repo.LikedMeals(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch liked meals"})
+		return
+	}
+
+	options, err := s.plannerOptionsFor(userID, mealPlan.HouseholdSize, req.PlannerOptions, likedMeals)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to estimate meal costs"})
+		return
+	}
+	slot := services.PlanSlot{Day: req.Day, MealType: req.MealType}
+	alternatives := services.NewMealPlanner(options).
+		Alternatives(likedMeals, plannedFromEntries(mealPlan.Meals), slot, maxSlotAlternatives)
+	slots := []SlotAlternatives{{Day: req.Day, MealType: req.MealType, Alternatives: alternatives}}
+
+	if len(alternatives) == 0 || len(alternatives[0].Violations) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "No alternative satisfies the plan's constraints",
+			"slots": slots,
+		})
+		return
+	}
+
+	headcount := 0
+	var absent models.StringArray
+	if current != nil {
+		headcount = current.Headcount
+		absent = current.AbsentMembers
+	}
+
+	entry := models.MealPlanEntry{
+		MealPlanID:    mealPlan.ID,
+		MealID:        alternatives[0].Meal.ID,
+		Day:           req.Day,
+		MealType:      req.MealType,
+		Servings:      1,
+		Headcount:     headcount,
+		AbsentMembers: absent,
+	}
+	err = s.repo.Transaction(func(tx repository.Repository) error {
+		if err := tx.ClearSlot(mealPlan.ID, req.Day, req.MealType); err != nil {
+			return err
+		}
+		if err := tx.CreateEntry(&entry); err != nil {
+			return err
+		}
+		return tx.SyncShoppingList(mealPlan.ID)
+	})
+	if errors.Is(err, repository.ErrLockedLeftover) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A locked leftover depends on this slot; unlock it first"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add meal to plan"})
+		return
+	}
+
+	if stored, err := s.repo.Entry(entry.ID); err == nil {
+		entry = stored
+	}
+	s.publishPlanEvent(mealPlan.ID, EventPlanEntryUpdated, PlanEntryChange{Day: req.Day, MealType: req.MealType, Entry: &entry})
+	s.publishShoppingListReplaced(mealPlan.ID)
+
+	mealPlan, _ = s.repo.PlanWithShoppingList(mealPlan.ID)
+
+	c.JSON(http.StatusOK, PlanWithAlternatives{MealPlan: mealPlan, Slots: slots})
+}
+
+// keptEntries returns the IDs of locked entries plus the cooks and leftovers linked to them
+func keptEntries(entries []models.MealPlanEntry) map[uint]bool {
+	kept := map[uint]bool{}
+	for _, entry := range entries {
+		if !entry.Locked {
+			continue
+		}
+		kept[entry.ID] = true
+		if entry.LeftoverOfID != nil {
+			kept[*entry.LeftoverOfID] = true
+		}
+	}
+	for _, entry := range entries {
+		if entry.LeftoverOfID != nil && kept[*entry.LeftoverOfID] {
+			kept[entry.ID] = true
+		}
+	}
+	return kept
+}
+
+// plannedFromEntries converts stored entries to the planner's representation
+func plannedFromEntries(entries []models.MealPlanEntry) []services.PlannedMeal {
+	slots := make(map[uint]services.PlanSlot, len(entries))
+	for _, entry := range entries {
+		slots[entry.ID] = services.PlanSlot{Day: entry.Day, MealType: entry.MealType}
+	}
+
+	planned := make([]services.PlannedMeal, 0, len(entries))
+	for _, entry := range entries {
+		meal := services.PlannedMeal{PlanSlot: slots[entry.ID], Meal: entry.Meal}
+		if entry.LeftoverOfID != nil {
+			if source, ok := slots[*entry.LeftoverOfID]; ok {
+				meal.LeftoverOf = &source
+			}
+		}
+		planned = append(planned, meal)
+	}
+	return planned
+}
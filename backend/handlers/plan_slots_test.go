@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"food-app/models"
+)
+
+func TestLockedLeftoverSurvivesChangesToItsCook(t *testing.T) {
+	api := newTestAPI(t)
+	meals := api.seedMeals()
+	token, _ := api.register("ann")
+	api.likeAll(token, meals)
+	api.expect(http.StatusOK, "GET", "/current-meal-plan", token, nil, nil)
+
+	var plan models.MealPlan
+	api.expect(http.StatusOK, "PUT", "/current-meal-plan/meals", token, payload{
+		"day": "monday", "meal_type": "dinner", "meal_id": meals[2].ID,
+	}, &plan)
+	api.expect(http.StatusOK, "POST", "/current-meal-plan/leftovers", token, ScheduleLeftoverRequest{
+		SourceDay: "monday", SourceMealType: "dinner", Day: "tuesday", MealType: "lunch",
+	}, &plan)
+	api.expect(http.StatusOK, "PUT", "/current-meal-plan/locks", token, LockSlotRequest{
+		Day: "tuesday", MealType: "lunch", Locked: true,
+	}, nil)
+	cook, _ := entryIn(plan, "monday", "dinner")
+	leftover, _ := entryIn(plan, "tuesday", "lunch")
+
+	// Neither a swap, a manual edit nor a new leftover may replace the cook
+	api.expect(http.StatusConflict, "POST", "/current-meal-plan/swap", token, SwapSlotRequest{Day: "monday", MealType: "dinner"}, nil)
+	api.expect(http.StatusConflict, "PUT", "/current-meal-plan/meals", token, payload{
+		"day": "monday", "meal_type": "dinner", "meal_id": meals[0].ID,
+	}, nil)
+	api.expect(http.StatusOK, "GET", "/current-meal-plan", token, nil, &plan)
+	if entry, ok := entryIn(plan, "monday", "dinner"); !ok || entry.ID != cook.ID {
+		t.Errorf("cook = %+v, want entry %d", entry, cook.ID)
+	}
+	if entry, ok := entryIn(plan, "tuesday", "lunch"); !ok || entry.ID != leftover.ID || !entry.Locked {
+		t.Errorf("leftover = %+v, want locked entry %d", entry, leftover.ID)
+	}
+
+	// Once unlocked, the leftover goes with its cook
+	api.expect(http.StatusOK, "PUT", "/current-meal-plan/locks", token, LockSlotRequest{
+		Day: "tuesday", MealType: "lunch", Locked: false,
+	}, nil)
+	var swapped PlanWithAlternatives
+	api.expect(http.StatusOK, "POST", "/current-meal-plan/swap", token, SwapSlotRequest{Day: "monday", MealType: "dinner"}, &swapped)
+	if entry, _ := entryIn(swapped.MealPlan, "monday", "dinner"); entry.ID == cook.ID {
+		t.Error("swap kept the old cook")
+	}
+	if entry, ok := entryIn(swapped.MealPlan, "tuesday", "lunch"); ok {
+		t.Errorf("unlocked leftover %+v outlived its cook", entry)
+	}
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"food-app/ingredients"
+	"food-app/models"
+	"food-app/repository"
+	"food-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImportRecipeRequest imports a schema.org recipe from a page URL or its HTML
+type ImportRecipeRequest struct {
+	URL    string `json:"url"`
+	HTML   string `json:"html"`    // page source; parsed instead of fetching URL, which is then only recorded
+	DryRun bool   `json:"dry_run"` // return the parsed recipe without saving it
+}
+
+// ImportedRecipeResponse is an imported recipe with its ingredient lines parsed
+type ImportedRecipeResponse struct {
+	services.ImportedRecipe
+	Ingredients []ParsedIngredient `json:"ingredients"`
+	Unresolved  []string           `json:"unresolved"` // ingredient names that matched no ingredient or alias
+}
+
+// ImportRecipe reads a recipe page's schema.org Recipe (JSON-LD or microdata) and
+// saves it as a meal with its ingredients and steps
+func (s *Server) ImportRecipe(c *gin.Context) {
+	var req ImportRecipeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.URL == "" && req.HTML == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide a recipe url or its html"})
+		return
+	}
+
+	if req.URL != "" && !req.DryRun {
+		if existing, found := s.importedMeal(req.URL); found {
+			c.JSON(http.StatusConflict, gin.H{"error": "This recipe has already been imported", "meal": existing})
+			return
+		}
+	}
+
+	var imported services.ImportedRecipe
+	var err error
+	if req.HTML != "" {
+		imported, err = services.ParseRecipeHTML([]byte(req.HTML), req.URL)
+	} else {
+		imported, err = s.recipeImporter.ImportURL(c.Request.Context(), req.URL)
+	}
+	if err != nil && (req.HTML != "" || errors.Is(err, services.ErrNoRecipe)) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.respondImportedRecipe(c, imported, req.DryRun)
+}
+
+// respondImportedRecipe parses the recipe's ingredient lines and either previews
+// it (dry run) or saves it as a meal, adding unknown ingredients to the catalog
+func (s *Server) respondImportedRecipe(c *gin.Context, imported services.ImportedRecipe, dryRun bool) {
+	lines := ingredients.ParseLines(imported.IngredientLines)
+	if dryRun {
+		parsed, err := s.matchIngredientLines(lines)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load ingredients"})
+			return
+		}
+		c.JSON(http.StatusOK, ImportedRecipeResponse{ImportedRecipe: imported, Ingredients: parsed, Unresolved: unresolvedNames(parsed)})
+		return
+	}
+
+	// Resolve before saving, which adds the unmatched names to the catalog
+	before, err := s.matchIngredientLines(lines)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load ingredients"})
+		return
+	}
+
+	meal := imported.Meal
+	err = s.repo.Transaction(func(tx repository.Repository) error {
+		return tx.CreateImportedMeal(&meal, lines)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save imported recipe"})
+		return
+	}
+
+	if stored, err := s.repo.Meal(meal.ID); err == nil {
+		meal = stored
+	}
+	imported.Meal = meal
+
+	parsed, _ := s.matchIngredientLines(lines)
+	c.JSON(http.StatusCreated, ImportedRecipeResponse{ImportedRecipe: imported, Ingredients: parsed, Unresolved: unresolvedNames(before)})
+}
+
+// importedMeal finds the meal already imported from sourceURL
+func (s *Server) importedMeal(sourceURL string) (models.Meal, bool) {
+	existing, err := s.repo.MealBySourceURL(sourceURL)
+	return existing, err == nil
+}
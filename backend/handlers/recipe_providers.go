@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"food-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetRecipeProviders lists the enabled recipe providers with today's request
+// counts against their quotas
+func (s *Server) GetRecipeProviders(c *gin.Context) {
+	usage, err := s.recipeProviders.Usage()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read provider usage"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"providers": s.recipeProviders.ProviderNames(), "usage": usage})
+}
+
+// SearchProviderRecipes searches one provider: ?provider=&q=&limit= (default 10, at most 50)
+func (s *Server) SearchProviderRecipes(c *gin.Context) {
+	provider, err := s.recipeProviders.Provider(c.Query("provider"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown recipe provider"})
+		return
+	}
+	query := c.Query("q")
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 50 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 50"})
+		return
+	}
+
+	recipes, err := provider.Search(c.Request.Context(), query, limit)
+	if err != nil {
+		respondProviderError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recipes": recipes})
+}
+
+// GetProviderRecipe previews a provider's recipe as it would be imported
+func (s *Server) GetProviderRecipe(c *gin.Context) {
+	imported, ok := s.fetchProviderRecipe(c)
+	if !ok {
+		return
+	}
+	s.respondImportedRecipe(c, imported, true)
+}
+
+// ImportProviderRecipe saves a provider's recipe as a meal
+func (s *Server) ImportProviderRecipe(c *gin.Context) {
+	imported, ok := s.fetchProviderRecipe(c)
+	if !ok {
+		return
+	}
+	if existing, found := s.importedMeal(imported.Meal.SourceURL); found {
+		c.JSON(http.StatusConflict, gin.H{"error": "This recipe has already been imported", "meal": existing})
+		return
+	}
+	s.respondImportedRecipe(c, imported, false)
+}
+
+// fetchProviderRecipe loads and normalizes the :provider/:id recipe; on failure it
+// writes the error response
+func (s *Server) fetchProviderRecipe(c *gin.Context) (services.ImportedRecipe, bool) {
+	provider, err := s.recipeProviders.Provider(c.Param("provider"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown recipe provider"})
+		return services.ImportedRecipe{}, false
+	}
+
+	recipe, err := provider.Recipe(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondProviderError(c, err)
+		return services.ImportedRecipe{}, false
+	}
+
+	imported, err := provider.Normalize(recipe)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to read the provider's recipe"})
+		return services.ImportedRecipe{}, false
+	}
+	return imported, true
+}
+
+// respondProviderError maps a provider failure onto a response. Quota and
+// circuit breaker refusals are ours, so they are not reported as bad gateways.
+func respondProviderError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrRecipeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+	case errors.Is(err, services.ErrQuotaExhausted):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "The provider's daily request quota is used up, try again tomorrow"})
+	case errors.Is(err, services.ErrCircuitOpen):
+		c.Header("Retry-After", "30")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "The provider is temporarily unavailable"})
+	default:
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	}
+}
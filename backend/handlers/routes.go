@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"time"
+
+	"food-app/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// legacyPlanSunset is when the deprecated /meal-plans and /shopping-lists routes go away
+var legacyPlanSunset = time.Date(2027, time.January, 31, 0, 0, 0, 0, time.UTC)
+
+// handler is a route's handler method, written as a method expression such as
+// (*Server).GetMeals so that it can only be registered through bind
+type handler func(s *Server, c *gin.Context)
+
+// bind serves h with a copy of the server whose queries run under the request's
+// context, so they stop when the client goes away
+func (s *Server) bind(h handler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		h(s.withRequest(c), c)
+	}
+}
+
+// Routes registers the API on api. Catalog and ingredient editing are limited
+// to catalogEditors.
+func (s *Server) Routes(api *gin.RouterGroup, catalogEditors []string) {
+	h := s.bind
+
+	// Public routes
+	public := api.Group("/")
+	{
+		// Authentication
+		public.POST("/register", h((*Server).Register))
+		public.POST("/login", h((*Server).Login))
+		public.GET("/auth/providers", h((*Server).GetLoginProviders))
+		public.GET("/auth/:provider/login", h((*Server).SocialLoginRedirect))
+		public.GET("/auth/:provider/callback", h((*Server).SocialLoginCallback))
+
+		// Public meal browsing
+		public.GET("/meals", h((*Server).GetMeals))
+		public.GET("/meals/:id", h((*Server).GetMeal))
+		public.GET("/meals/trending", h((*Server).GetTrendingMeals))
+		public.GET("/meals/:id/reviews", h((*Server).GetMealReviews))
+		public.GET("/meals/:id/cost", h((*Server).GetMealCost))
+		public.GET("/ingredients", h((*Server).GetIngredients))
+		public.GET("/ingredients/:id", h((*Server).GetIngredient))
+		public.GET("/ingredients/:id/prices", h((*Server).GetIngredientPrices))
+		public.GET("/recipes/providers", h((*Server).GetRecipeProviders))
+
+		// Calendar subscriptions authenticate with the secret token in the URL
+		public.GET("/calendar/:token", h((*Server).ServeCalendarFeed))
+	}
+
+	// Streaming routes accept the token as a query parameter for EventSource clients
+	stream := api.Group("/")
+	stream.Use(middleware.StreamAuthMiddleware())
+	{
+		stream.GET("/current-meal-plan/events", h((*Server).StreamCurrentMealPlan))
+	}
+
+	// Protected routes
+	protected := api.Group("/")
+	protected.Use(middleware.AuthMiddleware())
+	{
+		// User profile
+		protected.GET("/profile", h((*Server).GetProfile))
+		protected.PUT("/profile", h((*Server).UpdateProfile))
+		protected.PUT("/profile/preferences", h((*Server).UpdatePreferences))
+		protected.GET("/profile/identities", h((*Server).GetIdentities))
+		protected.POST("/profile/identities/:provider", h((*Server).LinkIdentity))
+		protected.DELETE("/profile/identities/:id", h((*Server).UnlinkIdentity))
+		protected.GET("/profile/calendar", h((*Server).GetCalendarFeed))
+		protected.PUT("/profile/calendar", h((*Server).UpdateCalendarFeed))
+		protected.POST("/profile/calendar/token", h((*Server).RotateCalendarFeedToken))
+
+		// Personalized meals
+		protected.GET("/meals/personalized", h((*Server).GetPersonalizedMeals))
+		protected.GET("/meals/liked", h((*Server).GetLikedMeals))
+		protected.POST("/meals/:id/like", h((*Server).LikeMeal))
+		protected.POST("/meals/:id/dislike", h((*Server).DislikeMeal))
+		protected.POST("/meals/:id/reviews", h((*Server).AddMealReview))
+		protected.POST("/meals/import", h((*Server).ImportRecipe))
+		protected.POST("/ingredients/parse", h((*Server).ParseIngredients))
+		protected.GET("/recipes/search", h((*Server).SearchProviderRecipes))
+		protected.GET("/recipes/:provider/:id", h((*Server).GetProviderRecipe))
+		protected.POST("/recipes/:provider/:id/import", h((*Server).ImportProviderRecipe))
+
+		// Current Meal Plan (Single Plan Approach)
+		protected.GET("/current-meal-plan", h((*Server).GetCurrentMealPlan))
+		protected.PUT("/current-meal-plan/active", h((*Server).SetActiveMealPlan))
+		protected.POST("/current-meal-plan/populate-from-liked", h((*Server).PopulateFromLikedMeals))
+		protected.PUT("/current-meal-plan/meals", h((*Server).UpdateMealInPlan))
+		protected.PUT("/current-meal-plan/settings", h((*Server).UpdateCurrentPlanSettings))
+		protected.PUT("/current-meal-plan/attendance", h((*Server).SetCurrentPlanAttendance))
+		protected.POST("/current-meal-plan/leftovers", h((*Server).ScheduleLeftover))
+		protected.PUT("/current-meal-plan/locks", h((*Server).LockPlanSlot))
+		protected.POST("/current-meal-plan/regenerate", h((*Server).RegenerateCurrentPlan))
+		protected.POST("/current-meal-plan/swap", h((*Server).SwapPlanSlot))
+		protected.GET("/current-meal-plan/nutrition", h((*Server).GetCurrentPlanNutrition))
+		protected.GET("/current-meal-plan/cost", h((*Server).GetCurrentPlanCost))
+		protected.GET("/current-meal-plan/cooking-time", h((*Server).GetCurrentPlanCookingTime))
+		protected.GET("/current-meal-plan/shopping-list", h((*Server).GetCurrentShoppingList))
+		protected.GET("/current-meal-plan/shopping-list/export", h((*Server).ExportCurrentShoppingList))
+		protected.POST("/current-meal-plan/shopping-items", h((*Server).AddManualShoppingItem))
+		protected.PUT("/shopping-items/:item_id", h((*Server).ToggleShoppingItem))
+		protected.DELETE("/shopping-items/:item_id", h((*Server).DeleteShoppingItem))
+
+		// Store layouts (aisle order for shopping lists)
+		protected.GET("/store-layouts", h((*Server).GetStoreLayouts))
+		protected.POST("/store-layouts", h((*Server).CreateStoreLayout))
+		protected.PUT("/store-layouts/:id", h((*Server).UpdateStoreLayout))
+		protected.DELETE("/store-layouts/:id", h((*Server).DeleteStoreLayout))
+
+		// Households (shared plan and shopping list)
+		protected.POST("/household", h((*Server).CreateHousehold))
+		protected.GET("/household", h((*Server).GetHousehold))
+		protected.DELETE("/household", h((*Server).DeleteHousehold))
+		protected.POST("/household/leave", h((*Server).LeaveHousehold))
+		protected.PUT("/household/members/:user_id", h((*Server).UpdateHouseholdMember))
+		protected.DELETE("/household/members/:user_id", h((*Server).RemoveHouseholdMember))
+		protected.POST("/household/invitations", h((*Server).InviteHouseholdMember))
+		protected.GET("/household/invitations", h((*Server).GetHouseholdInvitations))
+		protected.GET("/invitations", h((*Server).GetMyInvitations))
+		protected.POST("/invitations/:token/accept", h((*Server).AcceptInvitation))
+		protected.POST("/invitations/:token/decline", h((*Server).DeclineInvitation))
+	}
+
+	// Catalog import and export, for the editors listed in CATALOG_EDITORS
+	catalog := api.Group("/catalog")
+	catalog.Use(middleware.AuthMiddleware(), middleware.RequireEmail(catalogEditors))
+	{
+		catalog.GET("/export", h((*Server).ExportCatalog))
+		catalog.POST("/import", h((*Server).ImportCatalog))
+	}
+
+	// Ingredient catalog editing, for the same editors
+	ingredientEditors := api.Group("/")
+	ingredientEditors.Use(middleware.AuthMiddleware(), middleware.RequireEmail(catalogEditors))
+	{
+		ingredientEditors.POST("/ingredients", h((*Server).CreateIngredient))
+		ingredientEditors.PUT("/ingredients/:id", h((*Server).UpdateIngredient))
+		ingredientEditors.DELETE("/ingredients/:id", h((*Server).DeleteIngredient))
+		ingredientEditors.POST("/ingredients/:id/aliases", h((*Server).AddIngredientAlias))
+		ingredientEditors.DELETE("/ingredients/:id/aliases/:alias_id", h((*Server).DeleteIngredientAlias))
+		ingredientEditors.POST("/ingredients/:id/prices", h((*Server).AddIngredientPrice))
+	}
+
+	// Legacy meal planning routes, served from the same plans as /current-meal-plan
+	// until the sunset date
+	legacy := api.Group("/")
+	legacy.Use(middleware.AuthMiddleware(), middleware.Deprecated(legacyPlanSunset, "/api/v1/current-meal-plan"))
+	{
+		legacy.POST("/meal-plans", h((*Server).CreateMealPlan))
+		legacy.POST("/meal-plans/auto-generate", h((*Server).AutoGenerateMealPlan))
+		legacy.GET("/meal-plans", h((*Server).GetMealPlans))
+		legacy.GET("/meal-plans/:id", h((*Server).GetMealPlan))
+		legacy.GET("/meal-plans/:id/cooking-time", h((*Server).GetMealPlanCookingTime))
+		legacy.PUT("/meal-plans/:id", h((*Server).UpdateMealPlan))
+		legacy.DELETE("/meal-plans/:id", h((*Server).DeleteMealPlan))
+
+		// Shopping lists
+		legacy.POST("/meal-plans/:id/shopping-list", h((*Server).GenerateShoppingList))
+		legacy.GET("/shopping-lists", h((*Server).GetShoppingLists))
+		legacy.PUT("/shopping-list-items/:item_id", h((*Server).UpdateShoppingListItem))
+	}
+}
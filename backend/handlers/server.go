@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"errors"
+
+	"food-app/repository"
+	"food-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Server holds what the handlers depend on; every handler is a method on it,
+// so a Server built on another repository (such as one over an in-memory
+// SQLite database) serves the same API
+type Server struct {
+	repo            repository.Repository
+	events          *services.EventHub
+	socialLogin     *services.SocialLoginService
+	recipeProviders *services.RecipeProviders
+	recipeImporter  *services.RecipeImporter
+}
+
+// NewServer builds the handlers around a repository and the configured
+// external login and recipe providers
+func NewServer(repo repository.Repository, socialLogin *services.SocialLoginService, recipeProviders *services.RecipeProviders) *Server {
+	return &Server{
+		repo:            repo,
+		events:          services.NewEventHub(500),
+		socialLogin:     socialLogin,
+		recipeProviders: recipeProviders,
+		recipeImporter:  services.NewRecipeImporter(),
+	}
+}
+
+// withRequest returns a copy of the server whose queries run under the
+// request's context, so they stop when the client goes away. bind applies it
+// to every route.
+func (s *Server) withRequest(c *gin.Context) *Server {
+	scoped := *s
+	scoped.repo = s.repo.WithContext(c.Request.Context())
+	return &scoped
+}
+
+// isNotFound reports whether a repository lookup matched nothing
+func isNotFound(err error) bool {
+	return errors.Is(err, repository.ErrNotFound)
+}
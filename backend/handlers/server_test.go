@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"food-app/database"
+	"food-app/models"
+	"food-app/repository"
+	"food-app/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+)
+
+// catalogEditor may use the catalog routes in tests
+const catalogEditor = "editor@example.com"
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// payload is an ad hoc JSON request body
+type payload = map[string]interface{}
+
+// testAPI serves the full API from a Server over an in-memory SQLite store
+type testAPI struct {
+	t      *testing.T
+	store  *repository.Store
+	server *Server
+	router *gin.Engine
+}
+
+// newTestStore opens a store on a fresh in-memory database with the full schema
+func newTestStore(t *testing.T) *repository.Store {
+	t.Helper()
+
+	db, err := database.Open(sqlite.Open(":memory:"))
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("database handle: %v", err)
+	}
+	// Every connection to ":memory:" is its own database, so keep to one
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := database.AutoMigrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return repository.New(db)
+}
+
+// newTestAPI serves the API from a fresh store
+func newTestAPI(t *testing.T) *testAPI {
+	store := newTestStore(t)
+	return newTestAPIOn(t, store, store)
+}
+
+// newTestAPIOn serves the API through repo, which may wrap store
+func newTestAPIOn(t *testing.T, store *repository.Store, repo repository.Repository) *testAPI {
+	t.Helper()
+
+	server := NewServer(repo,
+		services.NewSocialLoginService(context.Background(), nil),
+		services.NewRecipeProviders(nil, services.ProviderClientOptions{}))
+
+	router := gin.New()
+	server.Routes(router.Group("/api/v1"), []string{catalogEditor})
+
+	return &testAPI{t: t, store: store, server: server, router: router}
+}
+
+// do sends a request with an optional bearer token and JSON body
+func (api *testAPI) do(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	api.t.Helper()
+
+	var reader *bytes.Reader
+	if body == nil {
+		reader = bytes.NewReader(nil)
+	} else {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			api.t.Fatalf("encode body: %v", err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req := httptest.NewRequest(method, "/api/v1"+path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	rec := httptest.NewRecorder()
+	api.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// expect sends a request and fails unless it answers with status, decoding the body into out
+func (api *testAPI) expect(status int, method, path, token string, body, out interface{}) *httptest.ResponseRecorder {
+	api.t.Helper()
+
+	rec := api.do(method, path, token, body)
+	if rec.Code != status {
+		api.t.Fatalf("%s %s: status %d, want %d: %s", method, path, rec.Code, status, rec.Body.String())
+	}
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			api.t.Fatalf("%s %s: decode %s: %v", method, path, rec.Body.String(), err)
+		}
+	}
+	return rec
+}
+
+// register creates an account and returns its token and user
+func (api *testAPI) register(name string) (string, models.User) {
+	api.t.Helper()
+
+	var resp AuthResponse
+	api.expect(http.StatusCreated, "POST", "/register", "", RegisterRequest{
+		Email:    name + "@example.com",
+		Username: name,
+		Password: "secret1",
+	}, &resp)
+	return resp.Token, resp.User
+}
+
+// seedMeals adds one meal per cuisine and meal type, each with a line of its own
+// protein and a shared line of rice, and returns them
+func (api *testAPI) seedMeals() []models.Meal {
+	api.t.Helper()
+
+	db := api.store.DB()
+	rice := models.Ingredient{Name: "Rice", Category: "grain", Unit: "cup"}
+	if err := db.Create(&rice).Error; err != nil {
+		api.t.Fatalf("create ingredient: %v", err)
+	}
+
+	var meals []models.Meal
+	for i, cuisine := range []string{"italian", "mexican", "thai", "indian", "greek"} {
+		protein := models.Ingredient{Name: fmt.Sprintf("Protein %d", i), Category: "protein", Unit: "lb"}
+		if err := db.Create(&protein).Error; err != nil {
+			api.t.Fatalf("create ingredient: %v", err)
+		}
+		for _, mealType := range services.PlanMealTypes {
+			meal := models.Meal{
+				Name:     fmt.Sprintf("%s %s", cuisine, mealType),
+				Cuisine:  cuisine,
+				MealType: mealType,
+				Servings: 2,
+				PrepTime: 10,
+				CookTime: 10,
+			}
+			if err := db.Create(&meal).Error; err != nil {
+				api.t.Fatalf("create meal: %v", err)
+			}
+			for _, line := range []models.MealIngredient{
+				{MealID: meal.ID, IngredientID: protein.ID, Quantity: 1, Unit: "lb"},
+				{MealID: meal.ID, IngredientID: rice.ID, Quantity: 1, Unit: "cup"},
+			} {
+				if err := db.Create(&line).Error; err != nil {
+					api.t.Fatalf("create meal ingredient: %v", err)
+				}
+			}
+			meals = append(meals, meal)
+		}
+	}
+	return meals
+}
+
+// likeAll likes every meal as the token's user
+func (api *testAPI) likeAll(token string, meals []models.Meal) {
+	api.t.Helper()
+	for _, meal := range meals {
+		api.expect(http.StatusOK, "POST", fmt.Sprintf("/meals/%d/like", meal.ID), token, nil, nil)
+	}
+}
+
+// populate fills the user's current plan from their liked meals with a fixed seed
+func (api *testAPI) populate(token string) models.MealPlan {
+	api.t.Helper()
+
+	seed := int64(7)
+	var plan models.MealPlan
+	api.expect(http.StatusOK, "POST", "/current-meal-plan/populate-from-liked", token,
+		PopulatePlanRequest{services.PlannerOptions{Seed: &seed}}, &plan)
+	return plan
+}
+
+// shoppingItems lists the items of a plan's shopping list straight from the database
+func (api *testAPI) shoppingItems(mealPlanID uint) []models.ShoppingListItem {
+	api.t.Helper()
+
+	list, err := api.store.ShoppingListForPlan(mealPlanID)
+	if err != nil {
+		api.t.Fatalf("shopping list: %v", err)
+	}
+	return list.Items
+}
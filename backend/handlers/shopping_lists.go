@@ -0,0 +1,391 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"food-app/models"
+	"food-app/repository"
+	"food-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Shopping list groupings
+const (
+	GroupByCategory = "category"
+	GroupByAisle    = "aisle"
+)
+
+type AddManualItemRequest struct {
+	Name     string  `json:"name" binding:"required"`
+	Quantity float64 `json:"quantity" binding:"min=0"`
+	Unit     string  `json:"unit"`
+	Category string  `json:"category"` // matched against store layout aisles
+	Notes    string  `json:"notes"`
+}
+
+type StoreAisleRequest struct {
+	Name       string   `json:"name" binding:"required"`
+	Categories []string `json:"categories"`
+}
+
+// StoreLayoutRequest lists a store's aisles in the order they are walked
+type StoreLayoutRequest struct {
+	Name   string              `json:"name" binding:"required"`
+	Aisles []StoreAisleRequest `json:"aisles" binding:"dive"`
+}
+
+// ShoppingListGroup is one category or aisle of a grouped shopping list
+type ShoppingListGroup struct {
+	Name  string                    `json:"name"`
+	Items []models.ShoppingListItem `json:"items"`
+}
+
+// GroupedShoppingList is a shopping list with its items grouped and in walking order
+type GroupedShoppingList struct {
+	ID             uint                `json:"id"`
+	MealPlanID     uint                `json:"meal_plan_id"`
+	Name           string              `json:"name"`
+	EstimatedTotal float64             `json:"estimated_total"`
+	GroupBy        string              `json:"group_by"`
+	StoreLayoutID  *uint               `json:"store_layout_id,omitempty"`
+	Groups         []ShoppingListGroup `json:"groups"`
+}
+
+// GetCurrentShoppingList returns the current plan's shopping list grouped by
+// ingredient category, or by aisle following one of the user's store layouts
+func (s *Server) GetCurrentShoppingList(c *gin.Context) {
+	if grouped, ok := s.groupedCurrentShoppingList(c); ok {
+		c.JSON(http.StatusOK, grouped)
+	}
+}
+
+// ExportCurrentShoppingList downloads the grouped shopping list as plain text,
+// a Markdown checklist, CSV, a printable PDF or iCalendar VTODO reminders
+func (s *Server) ExportCurrentShoppingList(c *gin.Context) {
+	format := c.DefaultQuery("format", services.ExportText)
+	contentType, ok := services.ExportFormats[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be txt, md, csv, pdf or ics"})
+		return
+	}
+
+	grouped, ok := s.groupedCurrentShoppingList(c)
+	if !ok {
+		return
+	}
+
+	list := services.ExportList{ID: grouped.ID, Title: "Shopping list - " + grouped.Name}
+	for _, group := range grouped.Groups {
+		exported := services.ExportGroup{Name: group.Name}
+		for _, item := range group.Items {
+			exported.Items = append(exported.Items, services.ExportItem{
+				ID:        item.ID,
+				Name:      item.DisplayName(),
+				Quantity:  item.Quantity,
+				Unit:      item.Unit,
+				Notes:     item.Notes,
+				Purchased: item.IsPurchased,
+			})
+		}
+		list.Groups = append(list.Groups, exported)
+	}
+
+	var body bytes.Buffer
+	if err := services.WriteShoppingList(&body, format, list); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export shopping list"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="shopping-list-%d.%s"`, grouped.ID, format))
+	c.Data(http.StatusOK, contentType, body.Bytes())
+}
+
+// groupedCurrentShoppingList loads and groups the current plan's shopping list per the
+// group_by and layout_id query parameters; on failure it writes the error response
+func (s *Server) groupedCurrentShoppingList(c *gin.Context) (GroupedShoppingList, bool) {
+	userID := c.GetUint("userID")
+
+	groupBy := c.Query("group_by")
+	if groupBy == "" {
+		groupBy = GroupByCategory
+		if c.Query("layout_id") != "" {
+			groupBy = GroupByAisle
+		}
+	}
+	if groupBy != GroupByCategory && groupBy != GroupByAisle {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "group_by must be category or aisle"})
+		return GroupedShoppingList{}, false
+	}
+
+	shoppingList, err := s.repo.ShoppingListForPlan(s.activeMealPlanID(userID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No shopping list found"})
+		return GroupedShoppingList{}, false
+	}
+
+	grouped := GroupedShoppingList{
+		ID:             shoppingList.ID,
+		MealPlanID:     shoppingList.MealPlanID,
+		Name:           shoppingList.Name,
+		EstimatedTotal: shoppingList.EstimatedTotal,
+		GroupBy:        groupBy,
+	}
+
+	if groupBy == GroupByCategory {
+		grouped.Groups = groupByCategory(shoppingList.Items)
+		return grouped, true
+	}
+
+	// Without a layout_id the user's first layout is walked
+	var layout models.StoreLayout
+	if layoutID := c.Query("layout_id"); layoutID != "" {
+		layout, err = s.ownStoreLayout(layoutID, userID)
+	} else {
+		layout, err = s.repo.StoreLayout(0, userID)
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Store layout not found"})
+		return GroupedShoppingList{}, false
+	}
+
+	grouped.StoreLayoutID = &layout.ID
+	grouped.Groups = groupByAisle(shoppingList.Items, layout)
+	return grouped, true
+}
+
+// AddManualShoppingItem adds a free-text item, such as "paper towels", to the current plan's shopping list
+func (s *Server) AddManualShoppingItem(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var req AddManualItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	mealPlan, err := s.getOrCreateActivePlan(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create meal plan"})
+		return
+	}
+
+	item := models.ShoppingListItem{
+		Name:     strings.TrimSpace(req.Name),
+		Quantity: req.Quantity,
+		Unit:     req.Unit,
+		Category: req.Category,
+		Notes:    req.Notes,
+	}
+
+	err = s.repo.Transaction(func(tx repository.Repository) error {
+		return tx.AddManualItem(mealPlan.ID, &item)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add shopping list item"})
+		return
+	}
+
+	s.publishShoppingItemAdded(mealPlan.ID, item)
+
+	c.JSON(http.StatusCreated, item)
+}
+
+// DeleteShoppingItem removes a manual item; ingredient items follow the plan and cannot be removed
+func (s *Server) DeleteShoppingItem(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	item, err := s.findAccessibleShoppingItem(userID, c.Param("item_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Shopping list item not found"})
+		return
+	}
+
+	if !item.Manual {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only manual items can be removed; change the meal plan instead"})
+		return
+	}
+
+	shoppingList, _ := s.repo.ShoppingList(item.ShoppingListID)
+
+	if err := s.repo.DeleteShoppingItem(&item); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove shopping list item"})
+		return
+	}
+
+	s.publishShoppingItemRemoved(shoppingList.MealPlanID, item)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Shopping list item removed"})
+}
+
+// GetStoreLayouts lists the user's store layouts with their aisles in walking order
+func (s *Server) GetStoreLayouts(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	layouts, err := s.repo.StoreLayouts(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch store layouts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"store_layouts": layouts})
+}
+
+// CreateStoreLayout saves a store layout; aisles are walked in the order given
+func (s *Server) CreateStoreLayout(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var req StoreLayoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	layout := models.StoreLayout{UserID: userID, Name: req.Name}
+	err := s.repo.Transaction(func(tx repository.Repository) error {
+		return tx.SaveStoreLayout(&layout, storeAisles(req.Aisles))
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create store layout"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, layout)
+}
+
+// UpdateStoreLayout renames a layout and replaces its aisles
+func (s *Server) UpdateStoreLayout(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	layout, err := s.ownStoreLayout(c.Param("id"), userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Store layout not found"})
+		return
+	}
+
+	var req StoreLayoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	layout.Name = req.Name
+	err = s.repo.Transaction(func(tx repository.Repository) error {
+		return tx.SaveStoreLayout(&layout, storeAisles(req.Aisles))
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update store layout"})
+		return
+	}
+
+	c.JSON(http.StatusOK, layout)
+}
+
+// DeleteStoreLayout removes one of the user's store layouts
+func (s *Server) DeleteStoreLayout(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	layout, err := s.ownStoreLayout(c.Param("id"), userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Store layout not found"})
+		return
+	}
+
+	err = s.repo.Transaction(func(tx repository.Repository) error {
+		return tx.DeleteStoreLayout(&layout)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete store layout"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Store layout deleted successfully"})
+}
+
+// ownStoreLayout loads the :id layout if the user owns it; it never falls back
+// to their first layout
+func (s *Server) ownStoreLayout(id string, userID uint) (models.StoreLayout, error) {
+	layoutID := parseUint(id)
+	if layoutID == 0 {
+		return models.StoreLayout{}, repository.ErrNotFound
+	}
+	return s.repo.StoreLayout(layoutID, userID)
+}
+
+func storeAisles(requests []StoreAisleRequest) []models.StoreAisle {
+	aisles := make([]models.StoreAisle, 0, len(requests))
+	for _, req := range requests {
+		aisles = append(aisles, models.StoreAisle{Name: req.Name, Categories: models.StringArray(req.Categories)})
+	}
+	return aisles
+}
+
+// groupByCategory groups items by category, alphabetically with uncategorized items last
+func groupByCategory(items []models.ShoppingListItem) []ShoppingListGroup {
+	byName := map[string]*ShoppingListGroup{}
+	var names []string
+	for _, item := range items {
+		category := item.ItemCategory()
+		key := strings.ToLower(category)
+		if byName[key] == nil {
+			byName[key] = &ShoppingListGroup{Name: category}
+			names = append(names, key)
+		}
+		byName[key].Items = append(byName[key].Items, item)
+	}
+
+	uncategorized := strings.ToLower(models.UncategorizedGroup)
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == uncategorized) != (names[j] == uncategorized) {
+			return names[j] == uncategorized
+		}
+		return names[i] < names[j]
+	})
+
+	groups := make([]ShoppingListGroup, 0, len(names))
+	for _, name := range names {
+		groups = append(groups, sortedGroup(*byName[name]))
+	}
+	return groups
+}
+
+// groupByAisle groups items by the layout's aisles in walking order; items in no
+// aisle come last. Empty aisles are left out.
+func groupByAisle(items []models.ShoppingListItem, layout models.StoreLayout) []ShoppingListGroup {
+	byAisle := map[uint]*ShoppingListGroup{}
+	other := ShoppingListGroup{Name: models.UncategorizedGroup}
+	for _, item := range items {
+		aisle, ok := layout.AisleFor(item.ItemCategory())
+		if !ok {
+			other.Items = append(other.Items, item)
+			continue
+		}
+		if byAisle[aisle.ID] == nil {
+			byAisle[aisle.ID] = &ShoppingListGroup{Name: aisle.Name}
+		}
+		byAisle[aisle.ID].Items = append(byAisle[aisle.ID].Items, item)
+	}
+
+	groups := make([]ShoppingListGroup, 0, len(byAisle)+1)
+	for _, aisle := range layout.Aisles {
+		if group := byAisle[aisle.ID]; group != nil {
+			groups = append(groups, sortedGroup(*group))
+		}
+	}
+	if len(other.Items) > 0 {
+		groups = append(groups, sortedGroup(other))
+	}
+	return groups
+}
+
+// sortedGroup orders a group's items by name
+func sortedGroup(group ShoppingListGroup) ShoppingListGroup {
+	sort.SliceStable(group.Items, func(i, j int) bool {
+		return strings.ToLower(group.Items[i].DisplayName()) < strings.ToLower(group.Items[j].DisplayName())
+	})
+	return group
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"food-app/models"
+	"food-app/services"
+)
+
+// groupNames lists the names of a grouped list's groups, in order
+func groupNames(list GroupedShoppingList) []string {
+	names := make([]string, 0, len(list.Groups))
+	for _, group := range list.Groups {
+		names = append(names, group.Name)
+	}
+	return names
+}
+
+// itemNames lists the display names of a group's items, in order
+func itemNames(group ShoppingListGroup) []string {
+	names := make([]string, 0, len(group.Items))
+	for _, item := range group.Items {
+		names = append(names, item.DisplayName())
+	}
+	return names
+}
+
+func TestShoppingListGroupsByCategoryAndAisle(t *testing.T) {
+	api := newTestAPI(t)
+	meals := api.seedMeals()
+	token, _ := api.register("ann")
+	api.likeAll(token, meals)
+	api.expect(http.StatusOK, "GET", "/current-meal-plan", token, nil, nil)
+
+	api.expect(http.StatusOK, "PUT", "/current-meal-plan/meals", token, payload{
+		"day": "monday", "meal_type": "dinner", "meal_id": meals[0].ID,
+	}, nil)
+	api.expect(http.StatusCreated, "POST", "/current-meal-plan/shopping-items", token, AddManualItemRequest{Name: "Milk", Category: "Dairy"}, nil)
+	api.expect(http.StatusCreated, "POST", "/current-meal-plan/shopping-items", token, AddManualItemRequest{Name: "Paper towels"}, nil)
+	api.expect(http.StatusCreated, "POST", "/current-meal-plan/shopping-items", token, AddManualItemRequest{Name: "bread", Category: "grain"}, nil)
+
+	// Categories sort by name, with uncategorized items last
+	var list GroupedShoppingList
+	api.expect(http.StatusOK, "GET", "/current-meal-plan/shopping-list", token, nil, &list)
+	want := []string{"Dairy", "grain", "protein", models.UncategorizedGroup}
+	if got := groupNames(list); fmt.Sprint(got) != fmt.Sprint(want) || list.GroupBy != GroupByCategory {
+		t.Fatalf("groups = %q by %s, want %q by category", got, list.GroupBy, want)
+	}
+	if got := itemNames(list.Groups[1]); fmt.Sprint(got) != "[bread Rice]" {
+		t.Errorf("grain items = %q, want bread and Rice by name", got)
+	}
+
+	var layout models.StoreLayout
+	api.expect(http.StatusCreated, "POST", "/store-layouts", token, StoreLayoutRequest{
+		Name: "Corner shop",
+		Aisles: []StoreAisleRequest{
+			{Name: "Bakery & grains", Categories: []string{"Grain"}},
+			{Name: "Empty aisle", Categories: []string{"frozen"}},
+			{Name: "Butcher", Categories: []string{"protein"}},
+		},
+	}, &layout)
+
+	// Aisles follow the walking order; the rest, dairy included, comes last
+	api.expect(http.StatusOK, "GET", "/current-meal-plan/shopping-list?group_by=aisle", token, nil, &list)
+	want = []string{"Bakery & grains", "Butcher", models.UncategorizedGroup}
+	if got := groupNames(list); fmt.Sprint(got) != fmt.Sprint(want) || list.StoreLayoutID == nil || *list.StoreLayoutID != layout.ID {
+		t.Errorf("aisles = %q of layout %v, want %q of %d", got, list.StoreLayoutID, want, layout.ID)
+	}
+	if got := itemNames(list.Groups[2]); fmt.Sprint(got) != "[Milk Paper towels]" {
+		t.Errorf("other items = %q", got)
+	}
+	api.expect(http.StatusOK, "GET", fmt.Sprintf("/current-meal-plan/shopping-list?layout_id=%d", layout.ID), token, nil, &list)
+	if list.GroupBy != GroupByAisle {
+		t.Errorf("a layout_id grouped by %s, want aisle", list.GroupBy)
+	}
+
+	other, _ := api.register("bob")
+	api.expect(http.StatusOK, "GET", "/current-meal-plan", other, nil, nil)
+	api.expect(http.StatusNotFound, "GET", fmt.Sprintf("/current-meal-plan/shopping-list?layout_id=%d", layout.ID), other, nil, nil)
+	api.expect(http.StatusNotFound, "GET", "/current-meal-plan/shopping-list?group_by=aisle", other, nil, nil)
+	api.expect(http.StatusBadRequest, "GET", "/current-meal-plan/shopping-list?group_by=price", token, nil, nil)
+}
+
+func TestManualShoppingItems(t *testing.T) {
+	api := newTestAPI(t)
+	meals := api.seedMeals()
+	token, _ := api.register("ann")
+	api.likeAll(token, meals)
+
+	var plan models.MealPlan
+	api.expect(http.StatusOK, "GET", "/current-meal-plan", token, nil, &plan)
+	api.expect(http.StatusOK, "PUT", "/current-meal-plan/meals", token, payload{
+		"day": "monday", "meal_type": "dinner", "meal_id": meals[0].ID,
+	}, nil)
+	events, _, _, cancel := api.server.events.Subscribe(planTopic(plan.ID), "", services.FromLatest)
+	defer cancel()
+
+	api.expect(http.StatusBadRequest, "POST", "/current-meal-plan/shopping-items", token, payload{"quantity": 2}, nil)
+	var item models.ShoppingListItem
+	api.expect(http.StatusCreated, "POST", "/current-meal-plan/shopping-items", token, AddManualItemRequest{
+		Name: "  Paper towels ", Quantity: 2, Unit: "piece", Notes: "the strong kind",
+	}, &item)
+	if !item.Manual || item.Name != "Paper towels" || item.IngredientID != 0 || item.Quantity != 2 {
+		t.Errorf("added %+v", item)
+	}
+
+	select {
+	case event := <-events:
+		added, ok := event.Data.(models.ShoppingListItem)
+		if event.Type != EventShoppingItemAdded || !ok || added.ID != item.ID {
+			t.Errorf("published %s with %+v, want %s for item %d", event.Type, event.Data, EventShoppingItemAdded, item.ID)
+		}
+	default:
+		t.Error("adding an item published nothing")
+	}
+
+	// Manual items survive changes to the plan
+	api.expect(http.StatusOK, "PUT", "/current-meal-plan/meals", token, payload{
+		"day": "monday", "meal_type": "dinner", "meal_id": meals[3].ID,
+	}, nil)
+	for len(events) > 0 {
+		<-events
+	}
+	if _, ok := shoppingItemNamed(api.shoppingItems(plan.ID), "Paper towels"); !ok {
+		t.Fatal("the manual item left the list when the plan changed")
+	}
+
+	// Only manual items can be removed, and only by those who share the list
+	rice, _ := shoppingItemFor(api.shoppingItems(plan.ID), "Rice")
+	api.expect(http.StatusConflict, "DELETE", fmt.Sprintf("/shopping-items/%d", rice.ID), token, nil, nil)
+	other, _ := api.register("bob")
+	api.expect(http.StatusNotFound, "DELETE", fmt.Sprintf("/shopping-items/%d", item.ID), other, nil, nil)
+	api.expect(http.StatusOK, "DELETE", fmt.Sprintf("/shopping-items/%d", item.ID), token, nil, nil)
+
+	select {
+	case event := <-events:
+		if event.Type != EventShoppingItemRemoved {
+			t.Errorf("published %s, want %s", event.Type, EventShoppingItemRemoved)
+		}
+	default:
+		t.Error("removing an item published nothing")
+	}
+	if _, ok := shoppingItemNamed(api.shoppingItems(plan.ID), "Paper towels"); ok {
+		t.Error("the removed item is still listed")
+	}
+}
+
+// shoppingItemNamed finds a manual item by its text
+func shoppingItemNamed(items []models.ShoppingListItem, name string) (models.ShoppingListItem, bool) {
+	for _, item := range items {
+		if item.Manual && item.Name == name {
+			return item, true
+		}
+	}
+	return models.ShoppingListItem{}, false
+}
@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"food-app/middleware"
+	"food-app/models"
+	"food-app/repository"
+	"food-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const oauthStateCookie = "oauth_state"
+
+var (
+	errEmailNotVerified = errors.New("an account with this email already exists; log in and link this provider from your profile")
+	errIdentityTaken    = errors.New("this external account is already linked to another user")
+)
+
+// GetLoginProviders lists the external login providers that are enabled
+func (s *Server) GetLoginProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": s.socialLogin.ProviderNames()})
+}
+
+// SocialLoginRedirect starts the authorization code flow for a provider
+func (s *Server) SocialLoginRedirect(c *gin.Context) {
+	provider, err := s.socialLogin.Provider(c.Param("provider"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	authURL, err := startSocialLogin(c, provider, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// SocialLoginCallback completes the flow, links or creates the user and issues our JWT
+func (s *Server) SocialLoginCallback(c *gin.Context) {
+	provider, err := s.socialLogin.Provider(c.Param("provider"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	if errParam := c.Query("error"); errParam != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login was cancelled or denied: " + errParam})
+		return
+	}
+
+	stateParam := c.Query("state")
+	cookie, _ := c.Cookie(oauthStateCookie)
+	if stateParam == "" || cookie != stateParam {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid login state"})
+		return
+	}
+	c.SetCookie(oauthStateCookie, "", -1, "/", "", false, true)
+
+	state, err := middleware.ParseOAuthState(stateParam, provider.Name())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	identity, err := provider.Exchange(c.Request.Context(), c.Query("code"), state.Nonce)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Failed to verify login with provider"})
+		return
+	}
+
+	var user models.User
+	if state.LinkUserID != 0 {
+		user, err = s.linkIdentity(state.LinkUserID, identity)
+	} else {
+		user, err = s.findOrCreateSocialUser(identity)
+	}
+	if err != nil {
+		switch err {
+		case errEmailNotVerified, errIdentityTaken:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign in"})
+		}
+		return
+	}
+
+	token, err := middleware.GenerateToken(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, AuthResponse{
+		Token: token,
+		User:  user,
+	})
+}
+
+// GetIdentities lists the external identities linked to the current user
+func (s *Server) GetIdentities(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	identities, err := s.repo.Identities(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch linked accounts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"identities": identities})
+}
+
+// LinkIdentity returns the provider URL an authenticated user visits to link a new identity
+func (s *Server) LinkIdentity(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	provider, err := s.socialLogin.Provider(c.Param("provider"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	authURL, err := startSocialLogin(c, provider, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start account linking"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"auth_url": authURL})
+}
+
+// UnlinkIdentity removes a linked identity, keeping at least one way to sign in
+func (s *Server) UnlinkIdentity(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	identity, err := s.repo.UserIdentity(parseUint(c.Param("id")), userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Linked account not found"})
+		return
+	}
+
+	user, err := s.repo.User(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	identityCount, err := s.repo.CountIdentities(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink account"})
+		return
+	}
+	if user.Password == "" && identityCount <= 1 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cannot unlink the only sign-in method; set a password first"})
+		return
+	}
+
+	if err := s.repo.DeleteIdentity(&identity); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account unlinked successfully"})
+}
+
+// startSocialLogin issues the signed state, binds it to the browser and returns the provider URL
+func startSocialLogin(c *gin.Context, provider services.SocialProvider, linkUserID uint) (string, error) {
+	stateToken, state, err := middleware.GenerateOAuthState(provider.Name(), linkUserID)
+	if err != nil {
+		return "", err
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauthStateCookie, stateToken, int((10 * time.Minute).Seconds()), "/", "", c.Request.TLS != nil, true)
+
+	return provider.AuthCodeURL(stateToken, state.Nonce), nil
+}
+
+// findOrCreateSocialUser applies the account-linking rules for a login:
+// a known identity signs in its user; an unknown identity whose email matches
+// an existing account is linked only if the provider verified that email;
+// otherwise a new user is created.
+func (s *Server) findOrCreateSocialUser(identity *services.ExternalIdentity) (models.User, error) {
+	existing, err := s.repo.IdentityBySubject(identity.Provider, identity.Subject)
+	if err == nil {
+		user, err := s.repo.User(existing.UserID)
+		if err != nil {
+			return user, fmt.Errorf("identity %d points to missing user %d: %w", existing.ID, existing.UserID, err)
+		}
+		return user, s.repo.TouchIdentity(&existing, identity.Email, identity.EmailVerified)
+	}
+	if !isNotFound(err) {
+		return models.User{}, err
+	}
+
+	if identity.Email == "" {
+		return models.User{}, errors.New("provider did not return an email address")
+	}
+
+	user, err := s.repo.UserByEmail(identity.Email)
+	if err == nil {
+		if !identity.EmailVerified {
+			return models.User{}, errEmailNotVerified
+		}
+		return user, s.repo.CreateIdentity(newIdentity(user.ID, identity))
+	}
+	if !isNotFound(err) {
+		return user, err
+	}
+
+	username, err := s.uniqueUsername(identity)
+	if err != nil {
+		return user, err
+	}
+	user = models.User{
+		Email:     identity.Email,
+		Username:  username,
+		FirstName: identity.GivenName,
+		LastName:  identity.FamilyName,
+		IsActive:  true,
+	}
+
+	err = s.repo.Transaction(func(tx repository.Repository) error {
+		if err := tx.CreateUser(&user); err != nil {
+			return err
+		}
+		return tx.CreateIdentity(newIdentity(user.ID, identity))
+	})
+	return user, err
+}
+
+// linkIdentity attaches an identity to an already authenticated user
+func (s *Server) linkIdentity(userID uint, identity *services.ExternalIdentity) (models.User, error) {
+	user, err := s.repo.User(userID)
+	if err != nil {
+		return user, fmt.Errorf("user %d: %w", userID, err)
+	}
+
+	existing, err := s.repo.IdentityBySubject(identity.Provider, identity.Subject)
+	if err == nil {
+		if existing.UserID != userID {
+			return user, errIdentityTaken
+		}
+		return user, s.repo.TouchIdentity(&existing, identity.Email, identity.EmailVerified)
+	}
+	if !isNotFound(err) {
+		return user, err
+	}
+
+	return user, s.repo.CreateIdentity(newIdentity(userID, identity))
+}
+
+func newIdentity(userID uint, identity *services.ExternalIdentity) *models.UserIdentity {
+	return &models.UserIdentity{
+		UserID:        userID,
+		Provider:      identity.Provider,
+		Subject:       identity.Subject,
+		Email:         identity.Email,
+		EmailVerified: identity.EmailVerified,
+		LastLoginAt:   time.Now(),
+	}
+}
+
+// uniqueUsername derives a username from the provider profile, adding a suffix on collision
+func (s *Server) uniqueUsername(identity *services.ExternalIdentity) (string, error) {
+	base := identity.Username
+	if base == "" {
+		base = strings.SplitN(identity.Email, "@", 2)[0]
+	}
+	if base == "" {
+		base = identity.Provider + "-user"
+	}
+
+	candidate := base
+	for i := 2; ; i++ {
+		taken, err := s.repo.UsernameTaken(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s%d", base, i)
+	}
+}
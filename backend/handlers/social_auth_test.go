@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"food-app/models"
+	"food-app/services"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const mockClientID = "food-app-test"
+
+// mockIssuer is a minimal OpenID Connect issuer. Each code the test hands it
+// exchanges for an id_token carrying that code's claims.
+type mockIssuer struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]jwt.MapClaims
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	issuer := &mockIssuer{key: key, codes: map[string]jwt.MapClaims{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                issuer.URL,
+			"authorization_endpoint":                issuer.URL + "/authorize",
+			"token_endpoint":                        issuer.URL + "/token",
+			"jwks_uri":                              issuer.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": "test",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		issuer.mu.Lock()
+		claims, ok := issuer.codes[r.FormValue("code")]
+		issuer.mu.Unlock()
+		if !ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "test"
+		idToken, err := token.SignedString(key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access-" + r.FormValue("code"),
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     idToken,
+		})
+	})
+
+	issuer.Server = httptest.NewServer(mux)
+	t.Cleanup(issuer.Close)
+	return issuer
+}
+
+// issue registers a code for subject, bound to the nonce the app sent to the issuer
+func (issuer *mockIssuer) issue(subject, email string, verified bool, nonce string) string {
+	issuer.mu.Lock()
+	defer issuer.mu.Unlock()
+
+	code := fmt.Sprintf("code-%d", len(issuer.codes)+1)
+	now := time.Now()
+	issuer.codes[code] = jwt.MapClaims{
+		"iss":            issuer.URL,
+		"aud":            mockClientID,
+		"sub":            subject,
+		"email":          email,
+		"email_verified": verified,
+		"nonce":          nonce,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+	}
+	return code
+}
+
+// newSocialTestAPI serves the API with a "mock" login provider backed by issuer
+func newSocialTestAPI(t *testing.T, issuer *mockIssuer) *testAPI {
+	api := newTestAPI(t)
+	api.server.socialLogin = services.NewSocialLoginService(context.Background(), []services.SocialProviderConfig{{
+		Name:         "mock",
+		Kind:         services.ProviderKindOIDC,
+		IssuerURL:    issuer.URL,
+		ClientID:     mockClientID,
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost/api/v1/auth/mock/callback",
+	}})
+	if names := api.server.socialLogin.ProviderNames(); len(names) != 1 {
+		t.Fatalf("providers = %v, want the mock issuer", names)
+	}
+	return api
+}
+
+// socialLogin signs in through the mock provider. A token links the identity
+// to that user instead. It returns the callback response.
+func (api *testAPI) socialLogin(issuer *mockIssuer, token, subject, email string, verified bool) *httptest.ResponseRecorder {
+	api.t.Helper()
+
+	var authURL string
+	var start *httptest.ResponseRecorder
+	if token == "" {
+		start = api.expect(http.StatusFound, "GET", "/auth/mock/login", "", nil, nil)
+		authURL = start.Header().Get("Location")
+	} else {
+		var resp struct {
+			AuthURL string `json:"auth_url"`
+		}
+		start = api.expect(http.StatusOK, "POST", "/profile/identities/mock", token, nil, &resp)
+		authURL = resp.AuthURL
+	}
+
+	location, err := url.Parse(authURL)
+	if err != nil {
+		api.t.Fatalf("auth URL %q: %v", authURL, err)
+	}
+	query := location.Query()
+	code := issuer.issue(subject, email, verified, query.Get("nonce"))
+
+	req := httptest.NewRequest("GET", "/api/v1/auth/mock/callback?"+url.Values{
+		"code":  {code},
+		"state": {query.Get("state")},
+	}.Encode(), nil)
+	for _, cookie := range start.Result().Cookies() {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	api.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (api *testAPI) identities(token string) []models.UserIdentity {
+	api.t.Helper()
+
+	var resp struct {
+		Identities []models.UserIdentity `json:"identities"`
+	}
+	api.expect(http.StatusOK, "GET", "/profile/identities", token, nil, &resp)
+	return resp.Identities
+}
+
+func decodeAuth(t *testing.T, rec *httptest.ResponseRecorder) AuthResponse {
+	t.Helper()
+
+	var resp AuthResponse
+	if rec.Code != http.StatusOK {
+		t.Fatalf("callback: status %d: %s", rec.Code, rec.Body.String())
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode %s: %v", rec.Body.String(), err)
+	}
+	return resp
+}
+
+func TestSocialLoginCreatesAndSignsInUsers(t *testing.T) {
+	issuer := newMockIssuer(t)
+	api := newSocialTestAPI(t, issuer)
+
+	first := decodeAuth(t, api.socialLogin(issuer, "", "sub-1", "Sam@Example.com", true))
+	if first.User.Email != "sam@example.com" || first.User.Username != "sam" || first.User.Password != "" {
+		t.Errorf("created user %+v", first.User)
+	}
+	api.expect(http.StatusOK, "GET", "/profile/identities", first.Token, nil, nil)
+
+	again := decodeAuth(t, api.socialLogin(issuer, "", "sub-1", "sam@example.com", true))
+	if again.User.ID != first.User.ID {
+		t.Errorf("second login signed in user %d, want %d", again.User.ID, first.User.ID)
+	}
+	if identities := api.identities(again.Token); len(identities) != 1 {
+		t.Errorf("%d identities after two logins, want 1", len(identities))
+	}
+}
+
+func TestSocialLoginLinksOnlyVerifiedEmails(t *testing.T) {
+	issuer := newMockIssuer(t)
+	api := newSocialTestAPI(t, issuer)
+	token, user := api.register("ann")
+
+	if rec := api.socialLogin(issuer, "", "sub-unverified", "ann@example.com", false); rec.Code != http.StatusConflict {
+		t.Fatalf("unverified email: status %d, want 409: %s", rec.Code, rec.Body.String())
+	}
+	if identities := api.identities(token); len(identities) != 0 {
+		t.Errorf("unverified email linked %d identities", len(identities))
+	}
+
+	resp := decodeAuth(t, api.socialLogin(issuer, "", "sub-verified", "ann@example.com", true))
+	if resp.User.ID != user.ID {
+		t.Errorf("verified email signed in user %d, want %d", resp.User.ID, user.ID)
+	}
+	if identities := api.identities(token); len(identities) != 1 || identities[0].Subject != "sub-verified" {
+		t.Errorf("identities = %+v, want sub-verified", identities)
+	}
+}
+
+func TestSocialLoginRejectsTamperedState(t *testing.T) {
+	issuer := newMockIssuer(t)
+	api := newSocialTestAPI(t, issuer)
+	token, _ := api.register("ann")
+
+	// An access token is not a login state, even with a matching cookie
+	code := issuer.issue("sub-1", "ann@example.com", true, "")
+	req := httptest.NewRequest("GET", "/api/v1/auth/mock/callback?"+url.Values{"code": {code}, "state": {token}}.Encode(), nil)
+	req.AddCookie(&http.Cookie{Name: oauthStateCookie, Value: token})
+	rec := httptest.NewRecorder()
+	api.router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("access token as state: status %d, want 400", rec.Code)
+	}
+
+	// Nor is a login state an access token
+	start := api.expect(http.StatusFound, "GET", "/auth/mock/login", "", nil, nil)
+	location, _ := url.Parse(start.Header().Get("Location"))
+	api.expect(http.StatusUnauthorized, "GET", "/profile/identities", location.Query().Get("state"), nil, nil)
+}
+
+func TestLinkAndUnlinkIdentities(t *testing.T) {
+	issuer := newMockIssuer(t)
+	api := newSocialTestAPI(t, issuer)
+
+	social := decodeAuth(t, api.socialLogin(issuer, "", "sub-social", "sam@example.com", true))
+	annToken, ann := api.register("ann")
+
+	// Linking needs no email match, but an identity belongs to one user only
+	linked := decodeAuth(t, api.socialLogin(issuer, annToken, "sub-ann", "ann@elsewhere.com", false))
+	if linked.User.ID != ann.ID {
+		t.Errorf("linked to user %d, want %d", linked.User.ID, ann.ID)
+	}
+	if rec := api.socialLogin(issuer, annToken, "sub-social", "sam@example.com", true); rec.Code != http.StatusConflict {
+		t.Errorf("linking another user's identity: status %d, want 409", rec.Code)
+	}
+
+	// Ann still has a password, so her only identity can go
+	annIdentities := api.identities(annToken)
+	if len(annIdentities) != 1 {
+		t.Fatalf("ann has %d identities, want 1", len(annIdentities))
+	}
+	api.expect(http.StatusOK, "DELETE", fmt.Sprintf("/profile/identities/%d", annIdentities[0].ID), annToken, nil, nil)
+
+	// Sam signed up socially, so one identity must stay
+	decodeAuth(t, api.socialLogin(issuer, social.Token, "sub-social-2", "sam@example.com", true))
+	samIdentities := api.identities(social.Token)
+	if len(samIdentities) != 2 {
+		t.Fatalf("sam has %d identities, want 2", len(samIdentities))
+	}
+	api.expect(http.StatusNotFound, "DELETE", fmt.Sprintf("/profile/identities/%d", samIdentities[0].ID), annToken, nil, nil)
+	api.expect(http.StatusOK, "DELETE", fmt.Sprintf("/profile/identities/%d", samIdentities[0].ID), social.Token, nil, nil)
+	api.expect(http.StatusConflict, "DELETE", fmt.Sprintf("/profile/identities/%d", samIdentities[1].ID), social.Token, nil, nil)
+
+	if identities := api.identities(social.Token); len(identities) != 1 || identities[0].ID != samIdentities[1].ID {
+		t.Errorf("sam kept %+v, want identity %d", identities, samIdentities[1].ID)
+	}
+}
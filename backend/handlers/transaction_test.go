@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"food-app/models"
+	"food-app/repository"
+	"food-app/services"
+
+	"gorm.io/gorm"
+)
+
+var errInjected = errors.New("injected failure")
+
+// failingRepo makes chosen repository methods fail, inside transactions too,
+// after the rest of the transaction has already written
+type failingRepo struct {
+	repository.Repository
+	fail map[string]bool
+}
+
+func (r *failingRepo) WithContext(ctx context.Context) repository.Repository {
+	return &failingRepo{Repository: r.Repository.WithContext(ctx), fail: r.fail}
+}
+
+func (r *failingRepo) Transaction(fn func(tx repository.Repository) error) error {
+	return r.Repository.Transaction(func(tx repository.Repository) error {
+		return fn(&failingRepo{Repository: tx, fail: r.fail})
+	})
+}
+
+func (r *failingRepo) SavePlannedMeals(mealPlanID uint, planned []services.PlannedMeal, existing map[services.PlanSlot]uint) error {
+	if r.fail["SavePlannedMeals"] {
+		return errInjected
+	}
+	return r.Repository.SavePlannedMeals(mealPlanID, planned, existing)
+}
+
+func (r *failingRepo) SyncShoppingList(mealPlanID uint) error {
+	if r.fail["SyncShoppingList"] {
+		return errInjected
+	}
+	return r.Repository.SyncShoppingList(mealPlanID)
+}
+
+// newFailingAPI serves the API through a failingRepo; set its fail map to inject failures
+func newFailingAPI(t *testing.T) (*testAPI, *failingRepo) {
+	store := newTestStore(t)
+	repo := &failingRepo{Repository: store, fail: map[string]bool{}}
+	return newTestAPIOn(t, store, repo), repo
+}
+
+// failCreates makes every insert into table fail while *armed is set
+func failCreates(t *testing.T, db *gorm.DB, table string, armed *bool) {
+	t.Helper()
+	err := db.Callback().Create().Before("gorm:create").Register("test:fail_"+table, func(tx *gorm.DB) {
+		if *armed && tx.Statement.Table == table {
+			tx.AddError(errInjected)
+		}
+	})
+	if err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+}
+
+// planSnapshot is what a failed request must leave untouched
+type planSnapshot struct {
+	entries map[uint]uint // entry ID -> meal ID
+	items   map[uint]float64
+}
+
+func (api *testAPI) snapshot(mealPlanID uint) planSnapshot {
+	api.t.Helper()
+
+	plan, err := api.store.PlanWithMeals(mealPlanID)
+	if err != nil {
+		api.t.Fatalf("load plan: %v", err)
+	}
+	snap := planSnapshot{entries: map[uint]uint{}, items: map[uint]float64{}}
+	for _, entry := range plan.Meals {
+		snap.entries[entry.ID] = entry.MealID
+	}
+	for _, item := range api.shoppingItems(mealPlanID) {
+		snap.items[item.ID] = item.Quantity
+	}
+	return snap
+}
+
+func (api *testAPI) expectUnchanged(before planSnapshot, mealPlanID uint) {
+	api.t.Helper()
+
+	after := api.snapshot(mealPlanID)
+	if len(after.entries) != len(before.entries) || len(after.items) != len(before.items) {
+		api.t.Fatalf("plan changed from %d entries, %d items to %d, %d",
+			len(before.entries), len(before.items), len(after.entries), len(after.items))
+	}
+	for id, mealID := range before.entries {
+		if after.entries[id] != mealID {
+			api.t.Errorf("entry %d changed from meal %d to %d", id, mealID, after.entries[id])
+		}
+	}
+	for id, quantity := range before.items {
+		if after.items[id] != quantity {
+			api.t.Errorf("item %d changed from %v to %v", id, quantity, after.items[id])
+		}
+	}
+}
+
+func TestFailedPopulationRollsBack(t *testing.T) {
+	for _, method := range []string{"SavePlannedMeals", "SyncShoppingList"} {
+		t.Run(method, func(t *testing.T) {
+			api, repo := newFailingAPI(t)
+			meals := api.seedMeals()
+			token, _ := api.register("ann")
+			api.likeAll(token, meals)
+			plan := api.populate(token)
+			before := api.snapshot(plan.ID)
+
+			// Entries have been cleared by the time the method fails
+			repo.fail[method] = true
+			seed := int64(99)
+			api.expect(http.StatusInternalServerError, "POST", "/current-meal-plan/populate-from-liked", token,
+				PopulatePlanRequest{services.PlannerOptions{Seed: &seed}}, nil)
+
+			api.expectUnchanged(before, plan.ID)
+		})
+	}
+}
+
+func TestFailedRegenerationRollsBack(t *testing.T) {
+	for _, method := range []string{"SavePlannedMeals", "SyncShoppingList"} {
+		t.Run(method, func(t *testing.T) {
+			api, repo := newFailingAPI(t)
+			meals := api.seedMeals()
+			token, _ := api.register("ann")
+			api.likeAll(token, meals)
+			plan := api.populate(token)
+			api.expect(http.StatusOK, "PUT", "/current-meal-plan/locks", token, LockSlotRequest{
+				Day: "monday", MealType: "lunch", Locked: true,
+			}, nil)
+			before := api.snapshot(plan.ID)
+
+			repo.fail[method] = true
+			seed := int64(99)
+			api.expect(http.StatusInternalServerError, "POST", "/current-meal-plan/regenerate", token,
+				RegeneratePlanRequest{services.PlannerOptions{Seed: &seed}}, nil)
+
+			api.expectUnchanged(before, plan.ID)
+		})
+	}
+}
+
+func TestFailedShoppingListSyncRollsBack(t *testing.T) {
+	api := newTestAPI(t)
+	meals := api.seedMeals()
+	token, _ := api.register("ann")
+
+	var plan models.MealPlan
+	api.expect(http.StatusOK, "GET", "/current-meal-plan", token, nil, &plan)
+	api.expect(http.StatusOK, "PUT", "/current-meal-plan/meals", token, payload{
+		"day": "monday", "meal_type": "dinner", "meal_id": meals[0].ID,
+	}, nil)
+	before := api.snapshot(plan.ID)
+
+	// Tuesday's meal raises the rice already on the list, then needs a new
+	// protein line, whose insert fails
+	armed := false
+	failCreates(t, api.store.DB(), "shopping_list_items", &armed)
+	armed = true
+	api.expect(http.StatusInternalServerError, "PUT", "/current-meal-plan/meals", token, payload{
+		"day": "tuesday", "meal_type": "dinner", "meal_id": meals[3].ID,
+	}, nil)
+	// The legacy update replaces the entries in the same transaction as the sync
+	api.expect(http.StatusInternalServerError, "PUT", fmt.Sprintf("/meal-plans/%d", plan.ID), token, CreateMealPlanRequest{
+		Name:      "Renamed",
+		WeekStart: "2026-10-19",
+		Meals: []MealPlanEntryReq{
+			{MealID: meals[0].ID, Day: "monday", MealType: "dinner"},
+			{MealID: meals[3].ID, Day: "tuesday", MealType: "dinner"},
+		},
+	}, nil)
+	armed = false
+
+	api.expectUnchanged(before, plan.ID)
+	if _, err := api.store.EntryInSlot(plan.ID, "tuesday", "dinner"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("tuesday's entry survived the failed sync: %v", err)
+	}
+}
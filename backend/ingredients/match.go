@@ -0,0 +1,163 @@
+package ingredients
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"food-app/models"
+)
+
+// descriptors are words that describe an ingredient without changing what is
+// bought, so "2 ripe tomatoes" still matches "Tomato"
+var descriptors = map[string]bool{
+	"fresh": true, "large": true, "medium": true, "small": true, "ripe": true,
+	"organic": true, "whole": true, "raw": true, "uncooked": true, "cold": true,
+	"warm": true, "boneless": true, "skinless": true, "canned": true, "tinned": true,
+	"frozen": true, "dried": true, "extra": true, "virgin": true, "good": true, "quality": true,
+}
+
+// Matcher finds catalog ingredients for parsed names, tolerating case, plurals,
+// descriptors and small typos. An ingredient's aliases match as its name does.
+type Matcher struct {
+	entries []matchEntry
+}
+
+type matchEntry struct {
+	ingredient models.Ingredient
+	key        string
+}
+
+// NewMatcher creates a matcher over the given catalog
+func NewMatcher(catalog []models.Ingredient) *Matcher {
+	m := &Matcher{entries: make([]matchEntry, 0, len(catalog))}
+	for _, ingredient := range catalog {
+		m.Add(ingredient)
+	}
+	return m
+}
+
+// Add makes an ingredient and its loaded aliases matchable, e.g. one just
+// created for an unknown name
+func (m *Matcher) Add(ingredient models.Ingredient) {
+	m.entries = append(m.entries, matchEntry{ingredient: ingredient, key: matchKey(ingredient.Name, false)})
+	for _, alias := range ingredient.Aliases {
+		m.entries = append(m.entries, matchEntry{ingredient: ingredient, key: matchKey(alias.Name, false)})
+	}
+}
+
+// minFuzzyLength is the shortest normalized name matched despite a typo. Below
+// it one letter turns a word into another ingredient: beet and Beef, jam and Ham.
+const minFuzzyLength = 6
+
+// Match returns the catalog ingredient for a name. It tries, in order, an exact
+// match of the normalized names, a match with descriptors dropped, and the
+// nearest name within a small edit distance. The last needs a name of at least
+// minFuzzyLength letters that starts like the catalog name.
+func (m *Matcher) Match(name string) (models.Ingredient, bool) {
+	key := matchKey(name, false)
+	if key == "" {
+		return models.Ingredient{}, false
+	}
+	for _, entry := range m.entries {
+		if entry.key == key {
+			return entry.ingredient, true
+		}
+	}
+
+	if plain := matchKey(name, true); plain != "" && plain != key {
+		for _, entry := range m.entries {
+			if entry.key == plain {
+				return entry.ingredient, true
+			}
+		}
+		key = plain
+	}
+
+	// One typo, or two in names of ten letters or more, keeps 80% of the name
+	length := utf8.RuneCountInString(key)
+	if length < minFuzzyLength {
+		return models.Ingredient{}, false
+	}
+	limit := 1
+	if length >= 10 {
+		limit = 2
+	}
+	first, _ := utf8.DecodeRuneInString(key)
+	best, bestDistance := -1, limit+1
+	for i, entry := range m.entries {
+		if entryFirst, _ := utf8.DecodeRuneInString(entry.key); entryFirst != first {
+			continue
+		}
+		if distance := editDistance(key, entry.key, bestDistance); distance < bestDistance {
+			best, bestDistance = i, distance
+		}
+	}
+	if best < 0 {
+		return models.Ingredient{}, false
+	}
+	return m.entries[best].ingredient, true
+}
+
+// matchKey lower-cases a name, drops punctuation and reduces each word to its
+// singular, optionally leaving out descriptors
+func matchKey(name string, dropDescriptors bool) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	kept := words[:0]
+	for _, word := range words {
+		if dropDescriptors && descriptors[word] {
+			continue
+		}
+		kept = append(kept, singular(word))
+	}
+	return strings.Join(kept, " ")
+}
+
+// singular strips common English plural endings: tomatoes, berries, peaches, eggs
+func singular(word string) string {
+	switch {
+	case len(word) > 4 && strings.HasSuffix(word, "ies"):
+		return word[:len(word)-3] + "y"
+	case len(word) > 4 && strings.HasSuffix(word, "oes"),
+		strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"),
+		strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "xes"):
+		return word[:len(word)-2]
+	case len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss"):
+		return word[:len(word)-1]
+	}
+	return word
+}
+
+// editDistance is the Levenshtein distance between a and b, or limit once it
+// is certain to reach limit
+func editDistance(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	if diff := len(ra) - len(rb); diff >= limit || -diff >= limit {
+		return limit
+	}
+
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		rowMin := current[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+			rowMin = min(rowMin, current[j])
+		}
+		if rowMin >= limit {
+			return limit
+		}
+		previous, current = current, previous
+	}
+	return min(previous[len(rb)], limit)
+}
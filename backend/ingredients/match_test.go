@@ -0,0 +1,62 @@
+package ingredients
+
+import (
+	"testing"
+
+	"food-app/models"
+)
+
+func testMatcher() *Matcher {
+	names := []string{"Beef", "Pear", "Salt", "Ham", "Tomato", "Broccoli", "Chicken Breast", "Rice", "Parmesan"}
+	catalog := make([]models.Ingredient, 0, len(names))
+	for i, name := range names {
+		catalog = append(catalog, models.Ingredient{ID: uint(i + 1), Name: name})
+	}
+	catalog[7].Aliases = []models.IngredientAlias{{Name: "White Rice"}}
+	return NewMatcher(catalog)
+}
+
+func TestMatch(t *testing.T) {
+	matcher := testMatcher()
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		// Exact after normalizing case, plurals and descriptors
+		{"BEEF", "Beef"},
+		{"tomatoes", "Tomato"},
+		{"ripe tomatoes", "Tomato"},
+		{"pears", "Pear"},
+		{"white rice", "Rice"},
+
+		// Typos in longer names
+		{"tomatoe", "Tomato"},
+		{"tamato", "Tomato"},
+		{"brocoli", "Broccoli"},
+		{"parmesean", "Parmesan"},
+		{"chiken breasts", "Chicken Breast"},
+		{"chicken braest", "Chicken Breast"},
+	}
+	for _, tt := range tests {
+		if got, ok := matcher.Match(tt.name); !ok || got.Name != tt.want {
+			t.Errorf("Match(%q) = %q, %v, want %q", tt.name, got.Name, ok, tt.want)
+		}
+	}
+}
+
+func TestMatchRejectsNearMissesOfOtherIngredients(t *testing.T) {
+	matcher := testMatcher()
+
+	for _, name := range []string{
+		// Short names one letter from another ingredient
+		"beet", "beets", "peas", "malt", "jam", "rich",
+		// Long enough, but the typo is in the first letter or there are too many
+		"roccoli", "brocolli rabe", "potato",
+		"", "   ",
+	} {
+		if got, ok := matcher.Match(name); ok {
+			t.Errorf("Match(%q) = %q, want no match", name, got.Name)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+// Package ingredients reads free-text recipe ingredient lines such as
+// "1 (14 oz) can black beans, drained and rinsed" and matches them to the
+// ingredient catalog.
+package ingredients
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"food-app/services"
+)
+
+// Line is a recipe ingredient line split into its parts, e.g. "2-3 cups flour, sifted"
+// is 2 to 3 cup of "flour" with note "sifted"
+type Line struct {
+	Original    string  `json:"original"`
+	Quantity    float64 `json:"quantity"`               // 0 when the line has none, e.g. "salt to taste"
+	MaxQuantity float64 `json:"max_quantity,omitempty"` // top of a range such as "2-3"; 0 otherwise
+	Unit        string  `json:"unit"`                   // canonical unit, see services.NormalizeUnit; "" when none
+	Name        string  `json:"name"`
+	Note        string  `json:"note"`
+	Optional    bool    `json:"optional"`
+}
+
+// PlanQuantity is the quantity to plan and shop for: the top of a range, so
+// the shopping list never comes up short
+func (l Line) PlanQuantity() float64 {
+	if l.MaxQuantity > l.Quantity {
+		return l.MaxQuantity
+	}
+	return l.Quantity
+}
+
+// unicodeFractions are replaced by their ASCII form before parsing
+var unicodeFractions = map[rune]string{
+	'¼': "1/4", '½': "1/2", '¾': "3/4",
+	'⅓': "1/3", '⅔': "2/3",
+	'⅕': "1/5", '⅖': "2/5", '⅗': "3/5", '⅘': "4/5",
+	'⅙': "1/6", '⅚': "5/6",
+	'⅛': "1/8", '⅜': "3/8", '⅝': "5/8", '⅞': "7/8",
+}
+
+// prepWords are preparation words that may lead the name ("finely chopped onion");
+// they are moved to the note
+var prepWords = map[string]bool{
+	"chopped": true, "diced": true, "minced": true, "sliced": true, "grated": true,
+	"shredded": true, "crushed": true, "peeled": true, "melted": true, "softened": true,
+	"beaten": true, "cubed": true, "halved": true, "quartered": true, "julienned": true,
+	"packed": true, "sifted": true, "toasted": true, "drained": true, "rinsed": true,
+	"trimmed": true, "pitted": true, "seeded": true, "zested": true, "juiced": true,
+	"finely": true, "roughly": true, "coarsely": true, "thinly": true, "freshly": true,
+	"lightly": true, "firmly": true, "well": true,
+}
+
+// trailingNotes are phrases that end the name but belong in the note ("salt to taste")
+var trailingNotes = []string{"to taste", "for garnish", "for serving", "to serve", "as needed", "if desired", "optional"}
+
+// optionalMarkers flag a line as optional wherever they appear as a note
+var optionalMarkers = map[string]bool{"optional": true, "if desired": true, "if you like": true}
+
+var (
+	parenthetical = regexp.MustCompile(`\(([^()]*)\)`)
+	gluedUnit     = regexp.MustCompile(`^(\d+(?:[.,]\d+)?)-?([[:alpha:]]+\.?)$`)
+	numericRange  = regexp.MustCompile(`^(\d+(?:[.,/]\d+)?)-(\d+(?:[.,/]\d+)?)$`)
+	// noteComma is the first comma that is not a decimal comma ("1,5 dl")
+	noteComma = regexp.MustCompile(`\D,|,\D|,$`)
+)
+
+// Parse splits a free-text ingredient line into quantity, unit, name, note and
+// optional flag. It understands whole, decimal, mixed and unicode fractions
+// ("1½"), ranges ("2-3", "2 to 3"), metric and imperial units, and package
+// sizes: "2 (14 oz) cans beans" is 28 oz of beans.
+func Parse(line string) Line {
+	parsed := Line{Original: line}
+	text := normalizeText(line)
+
+	var notes []string
+	if lower := strings.ToLower(text); strings.HasPrefix(lower, "optional:") {
+		parsed.Optional = true
+		text = text[len("optional:"):]
+	}
+
+	// Parentheticals: a package size, an optional marker or a note
+	var size Line
+	text = parenthetical.ReplaceAllStringFunc(text, func(group string) string {
+		inner := strings.TrimSpace(group[1 : len(group)-1])
+		switch {
+		case optionalMarkers[strings.ToLower(inner)]:
+			parsed.Optional = true
+		case size.Unit == "" && isMeasure(inner):
+			size = parseMeasure(splitFields(inner))
+		case inner != "":
+			notes = append(notes, inner)
+		}
+		return " "
+	})
+
+	// Everything after the first comma is preparation notes
+	if comma := noteComma.FindStringIndex(text); comma != nil {
+		at := strings.IndexByte(text[comma[0]:], ',') + comma[0]
+		for _, note := range strings.Split(text[at+1:], ",") {
+			notes = append(notes, strings.TrimSpace(note))
+		}
+		text = text[:at]
+	}
+
+	measure := parseMeasure(splitFields(text))
+	parsed.Quantity, parsed.MaxQuantity, parsed.Unit = measure.Quantity, measure.MaxQuantity, measure.Unit
+	name := measure.Name
+
+	// "1 (14 oz) can" is one can of 14 oz: count the size, not the can
+	if size.Unit != "" && services.UnitDimension(size.Unit) != services.DimensionCount {
+		count, maxCount := parsed.Quantity, parsed.MaxQuantity
+		if count == 0 {
+			count = 1
+		}
+		parsed.Quantity, parsed.Unit = count*size.Quantity, size.Unit
+		if maxCount > 0 {
+			parsed.MaxQuantity = maxCount * size.Quantity
+		}
+	}
+
+	name, leading := splitLeadingPrep(name)
+	if leading != "" {
+		notes = append([]string{leading}, notes...)
+	}
+	lowerName := strings.ToLower(name)
+	for _, phrase := range trailingNotes {
+		if strings.HasSuffix(lowerName, " "+phrase) {
+			name = strings.TrimSpace(name[:len(name)-len(phrase)])
+			notes = append(notes, phrase)
+			break
+		}
+	}
+
+	kept := notes[:0]
+	for _, note := range notes {
+		if optionalMarkers[strings.ToLower(note)] {
+			parsed.Optional = true
+			continue
+		}
+		if note != "" {
+			kept = append(kept, note)
+		}
+	}
+	parsed.Note = strings.Join(kept, ", ")
+	parsed.Name = strings.Trim(name, " -–:;")
+	return parsed
+}
+
+// ParseLines parses each non-blank line
+func ParseLines(lines []string) []Line {
+	parsed := make([]Line, 0, len(lines))
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			parsed = append(parsed, Parse(line))
+		}
+	}
+	return parsed
+}
+
+// normalizeText expands unicode fractions, unifies dashes and drops list bullets
+func normalizeText(line string) string {
+	var b strings.Builder
+	for _, r := range line {
+		switch {
+		case unicodeFractions[r] != "":
+			b.WriteString(" " + unicodeFractions[r])
+		case r == '⁄':
+			b.WriteByte('/')
+		case r == '–' || r == '—':
+			b.WriteByte('-')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return strings.TrimLeft(strings.TrimSpace(b.String()), "-*•· ")
+}
+
+// splitFields splits on spaces and separates glued forms such as "200g", "15-ounce" and "1-2"
+func splitFields(text string) []string {
+	var fields []string
+	for _, field := range strings.Fields(text) {
+		if m := gluedUnit.FindStringSubmatch(field); m != nil && services.UnitDimension(m[2]) != "" {
+			fields = append(fields, m[1], m[2])
+			continue
+		}
+		if m := numericRange.FindStringSubmatch(field); m != nil {
+			fields = append(fields, m[1], "-", m[2])
+			continue
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// isMeasure reports whether text is just an amount and a known unit, like "14 oz"
+func isMeasure(text string) bool {
+	measure := parseMeasure(splitFields(text))
+	return measure.Quantity > 0 && measure.Unit != "" && measure.Name == ""
+}
+
+// parseMeasure reads a leading amount or range and unit; the remaining words are the name
+func parseMeasure(fields []string) Line {
+	var measure Line
+	quantity, rest := parseAmount(fields)
+	if len(rest) == len(fields) && len(fields) > 1 && isArticle(fields[0]) && unitLength(fields[1:]) > 0 {
+		quantity, rest = 1, fields[1:] // "a pinch of salt"
+	}
+	measure.Quantity = quantity
+
+	if quantity > 0 && len(rest) > 1 && (rest[0] == "-" || strings.EqualFold(rest[0], "to") || strings.EqualFold(rest[0], "or")) {
+		upper, after := parseAmount(rest[1:])
+		switch {
+		case upper > quantity:
+			measure.MaxQuantity, rest = upper, after
+		case upper > 0 && upper < 1 && rest[0] == "-":
+			measure.Quantity, rest = quantity+upper, after // "1-1/2" is one and a half
+		}
+	}
+
+	if quantity > 0 {
+		if words := unitLength(rest); words > 0 {
+			measure.Unit = services.NormalizeUnit(strings.Join(rest[:words], " "))
+			rest = rest[words:]
+		}
+	}
+	if len(rest) > 0 && strings.EqualFold(rest[0], "of") {
+		rest = rest[1:]
+	}
+	measure.Name = strings.Join(rest, " ")
+	return measure
+}
+
+// parseAmount reads whole numbers, decimals and fractions, possibly mixed ("2 1/2"),
+// returning the amount and the fields after it
+func parseAmount(fields []string) (float64, []string) {
+	var total float64
+	for len(fields) > 0 {
+		value, ok := parseNumber(fields[0])
+		if !ok {
+			break
+		}
+		total += value
+		fields = fields[1:]
+	}
+	return total, fields
+}
+
+// parseNumber reads "2", "0.5", "1,5" or "1/2"
+func parseNumber(field string) (float64, bool) {
+	if field == "" || !strings.ContainsAny(field[:1], "0123456789.") {
+		return 0, false
+	}
+	if numerator, denominator, isFraction := strings.Cut(field, "/"); isFraction {
+		n, errN := strconv.ParseFloat(numerator, 64)
+		d, errD := strconv.ParseFloat(denominator, 64)
+		if errN != nil || errD != nil || d == 0 {
+			return 0, false
+		}
+		return n / d, true
+	}
+	value, err := strconv.ParseFloat(strings.Replace(field, ",", ".", 1), 64)
+	if err != nil || value < 0 {
+		return 0, false
+	}
+	return value, true
+}
+
+// unitLength is how many of the leading fields name a known unit: 2 for "fl oz",
+// 1 for "cups" and 0 when there is none
+func unitLength(fields []string) int {
+	if len(fields) > 1 && services.UnitDimension(fields[0]+" "+fields[1]) != "" {
+		return 2
+	}
+	if len(fields) > 0 && services.UnitDimension(fields[0]) != "" {
+		return 1
+	}
+	return 0
+}
+
+func isArticle(field string) bool {
+	return strings.EqualFold(field, "a") || strings.EqualFold(field, "an")
+}
+
+// splitLeadingPrep moves leading preparation words out of the name
+func splitLeadingPrep(name string) (string, string) {
+	words := strings.Fields(name)
+	i := 0
+	for i < len(words)-1 && prepWords[strings.ToLower(strings.TrimSuffix(words[i], ","))] {
+		i++
+	}
+	return strings.Join(words[i:], " "), strings.Join(words[:i], " ")
+}
@@ -0,0 +1,72 @@
+package ingredients
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		line string
+		want Line
+	}{
+		// Mixed numbers and notes
+		{"2 1/2 cups flour, sifted", Line{Quantity: 2.5, Unit: "cup", Name: "flour", Note: "sifted"}},
+		{"1-1/2 cups water", Line{Quantity: 1.5, Unit: "cup", Name: "water"}},
+		{"1,5 dl cream", Line{Quantity: 1.5, Unit: "dl", Name: "cream"}},
+		{"200g butter, softened", Line{Quantity: 200, Unit: "g", Name: "butter", Note: "softened"}},
+		{"3 eggs", Line{Quantity: 3, Name: "eggs"}},
+
+		// Ranges
+		{"2-3 cups flour", Line{Quantity: 2, MaxQuantity: 3, Unit: "cup", Name: "flour"}},
+		{"2 to 3 tbsp olive oil", Line{Quantity: 2, MaxQuantity: 3, Unit: "tbsp", Name: "olive oil"}},
+		{"1–2 cloves garlic, minced", Line{Quantity: 1, MaxQuantity: 2, Unit: "piece", Name: "garlic", Note: "minced"}},
+
+		// Unicode fractions
+		{"½ tsp salt", Line{Quantity: 0.5, Unit: "tsp", Name: "salt"}},
+		{"1½ cups milk", Line{Quantity: 1.5, Unit: "cup", Name: "milk"}},
+		{"2 ½ cups stock", Line{Quantity: 2.5, Unit: "cup", Name: "stock"}},
+		{"⅓ cup sugar", Line{Quantity: 1.0 / 3, Unit: "cup", Name: "sugar"}},
+
+		// No quantity
+		{"salt to taste", Line{Name: "salt", Note: "to taste"}},
+		{"fresh parsley, for garnish", Line{Name: "fresh parsley", Note: "for garnish"}},
+		{"finely chopped onion", Line{Name: "onion", Note: "finely chopped"}},
+		{"a pinch of nutmeg", Line{Quantity: 1, Unit: "pinch", Name: "nutmeg"}},
+
+		// Package sizes and optional lines
+		{"1 (14 oz) can black beans, drained and rinsed", Line{Quantity: 14, Unit: "oz", Name: "black beans", Note: "drained and rinsed"}},
+		{"2 (15-ounce) cans tomatoes", Line{Quantity: 30, Unit: "oz", Name: "tomatoes"}},
+		{"Optional: chili flakes", Line{Name: "chili flakes", Optional: true}},
+		{"1 cup walnuts (optional)", Line{Quantity: 1, Unit: "cup", Name: "walnuts", Optional: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got := Parse(tt.line)
+			if got.Original != tt.line {
+				t.Errorf("Original = %q", got.Original)
+			}
+			if math.Abs(got.Quantity-tt.want.Quantity) > 1e-9 || got.MaxQuantity != tt.want.MaxQuantity ||
+				got.Unit != tt.want.Unit || got.Name != tt.want.Name || got.Note != tt.want.Note || got.Optional != tt.want.Optional {
+				tt.want.Original = tt.line
+				t.Errorf("Parse = %+v\nwant    %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanQuantityUsesTheTopOfARange(t *testing.T) {
+	if got := Parse("2-3 cups flour").PlanQuantity(); got != 3 {
+		t.Errorf("range plans %v, want 3", got)
+	}
+	if got := Parse("2 cups flour").PlanQuantity(); got != 2 {
+		t.Errorf("single quantity plans %v, want 2", got)
+	}
+}
+
+func TestParseLinesSkipsBlanks(t *testing.T) {
+	lines := ParseLines([]string{"1 cup rice", "  ", "", "2 eggs"})
+	if len(lines) != 2 || lines[0].Name != "rice" || lines[1].Name != "eggs" {
+		t.Errorf("ParseLines = %+v", lines)
+	}
+}
@@ -1,50 +1,148 @@
 package main
 
 import (
+	"context"
+	"food-app/config"
 	"food-app/database"
 	"food-app/handlers"
 	"food-app/middleware"
+	"food-app/repository"
+	"food-app/services"
 	"log"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
-// getEnv gets environment variable with fallback default value
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// socialProviderConfigs maps the OAuth settings onto login providers; providers without a client ID stay disabled
+func socialProviderConfigs(cfg config.OAuthConfig) []services.SocialProviderConfig {
+	redirectBase := strings.TrimRight(cfg.RedirectBaseURL, "/")
+
+	return []services.SocialProviderConfig{
+		{
+			Name:         "google",
+			Kind:         services.ProviderKindOIDC,
+			IssuerURL:    cfg.Google.IssuerURL,
+			ClientID:     cfg.Google.ClientID,
+			ClientSecret: cfg.Google.ClientSecret,
+			RedirectURL:  redirectBase + "/google/callback",
+		},
+		{
+			Name:         "github",
+			Kind:         services.ProviderKindGitHub,
+			ClientID:     cfg.GitHub.ClientID,
+			ClientSecret: cfg.GitHub.ClientSecret,
+			RedirectURL:  redirectBase + "/github/callback",
+		},
+		{
+			Name:         "oidc",
+			Kind:         services.ProviderKindOIDC,
+			IssuerURL:    cfg.OIDC.IssuerURL,
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+			RedirectURL:  redirectBase + "/oidc/callback",
+		},
 	}
-	return defaultValue
+}
+
+// recipeProviderConfigs maps the enabled recipe sources onto provider configs; providers
+// missing their credentials or file are skipped when the registry is built
+func recipeProviderConfigs(cfg config.RecipesConfig) []services.RecipeProviderConfig {
+	configs := []services.RecipeProviderConfig{}
+	for _, name := range cfg.Providers {
+		switch name {
+		case services.RecipeProviderSpoonacular:
+			configs = append(configs, services.RecipeProviderConfig{
+				Kind:       name,
+				APIKey:     cfg.Spoonacular.APIKey,
+				BaseURL:    cfg.Spoonacular.APIURL,
+				DailyQuota: cfg.Spoonacular.DailyQuota,
+			})
+		case services.RecipeProviderEdamam:
+			configs = append(configs, services.RecipeProviderConfig{
+				Kind:       name,
+				AppID:      cfg.Edamam.AppID,
+				APIKey:     cfg.Edamam.APIKey,
+				BaseURL:    cfg.Edamam.APIURL,
+				DailyQuota: cfg.Edamam.DailyQuota,
+			})
+		case services.RecipeProviderTheMealDB:
+			configs = append(configs, services.RecipeProviderConfig{
+				Kind:       name,
+				APIKey:     cfg.TheMealDB.APIKey,
+				BaseURL:    cfg.TheMealDB.APIURL,
+				DailyQuota: cfg.TheMealDB.DailyQuota,
+			})
+		case services.RecipeProviderLocal:
+			configs = append(configs, services.RecipeProviderConfig{
+				Kind: name,
+				Path: cfg.Local.File,
+			})
+		}
+	}
+	return configs
 }
 
 func main() {
+	cfg, err := config.Load(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cfg.IsDevelopment() && cfg.JWTSecret == config.DefaultJWTSecret {
+		log.Println("Warning: signing tokens with the default JWT_SECRET; set one before deploying")
+	}
+	if !cfg.IsDevelopment() {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	middleware.SetJWTSecret(cfg.JWTSecret)
+
 	// Initialize database
-	database.Connect()
+	database.Connect(cfg.Database)
 	database.Migrate()
-	database.SeedData()
+
+	// Subcommands such as "catalog import" run against the database and exit
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
+	if unresolved := database.SeedData(); len(unresolved) > 0 {
+		log.Printf("Warning: seed meal ingredients matched no catalog ingredient and were skipped: %s", strings.Join(unresolved, ", "))
+	}
+
+	// Configure external login providers
+	socialLogin := services.NewSocialLoginService(context.Background(), socialProviderConfigs(cfg.OAuth))
+
+	// Configure external recipe sources
+	cacheTTL := time.Duration(cfg.Recipes.CacheTTLSeconds) * time.Second
+	if cacheTTL <= 0 {
+		cacheTTL = -1 // caching disabled
+	}
+	recipeProviders := services.NewRecipeProviders(recipeProviderConfigs(cfg.Recipes), services.ProviderClientOptions{
+		CacheTTL: cacheTTL,
+		Usage:    repository.NewProviderUsage(database.DB),
+	})
+
+	// Handlers reach the database only through the repository
+	server := handlers.NewServer(repository.New(database.DB), socialLogin, recipeProviders)
 
 	// Create Gin router
 	r := gin.Default()
 
 	// CORS configuration
-	config := cors.DefaultConfig()
-	corsOrigins := getEnv("CORS_ORIGINS", "http://localhost:3000,http://localhost:3001,http://localhost:5173,http://localhost:5174,http://127.0.0.1:3000,http://127.0.0.1:3001,http://127.0.0.1:5173,http://127.0.0.1:5174")
-	if corsOrigins == "*" {
-		config.AllowAllOrigins = true
+	corsConfig := cors.DefaultConfig()
+	if len(cfg.CORSOrigins) == 1 && cfg.CORSOrigins[0] == "*" {
+		corsConfig.AllowAllOrigins = true
 	} else {
-		origins := strings.Split(corsOrigins, ",")
-		for i, origin := range origins {
-			origins[i] = strings.TrimSpace(origin)
-		}
-		config.AllowOrigins = origins
+		corsConfig.AllowOrigins = cfg.CORSOrigins
 	}
-	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
-	config.AllowCredentials = true
-	r.Use(cors.New(config))
+	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
+	corsConfig.AllowCredentials = true
+	r.Use(cors.New(corsConfig))
 
 	// Health check endpoint
 	r.GET("/health", func(c *gin.Context) {
@@ -54,60 +152,11 @@ func main() {
 	// API routes
 	api := r.Group("/api/v1")
 
-	// Public routes
-	public := api.Group("/")
-	{
-		// Authentication
-		public.POST("/register", handlers.Register)
-		public.POST("/login", handlers.Login)
-
-		// Public meal browsing
-		public.GET("/meals", handlers.GetMeals)
-		public.GET("/meals/:id", handlers.GetMeal)
-		public.GET("/meals/trending", handlers.GetTrendingMeals)
-		public.GET("/meals/:id/reviews", handlers.GetMealReviews)
-	}
-
-	// Protected routes
-	protected := api.Group("/")
-	protected.Use(middleware.AuthMiddleware())
-	{
-		// User profile
-		protected.GET("/profile", handlers.GetProfile)
-		protected.PUT("/profile", handlers.UpdateProfile)
-		protected.PUT("/profile/preferences", handlers.UpdatePreferences)
-
-		// Personalized meals
-		protected.GET("/meals/personalized", handlers.GetPersonalizedMeals)
-		protected.GET("/meals/liked", handlers.GetLikedMeals)
-		protected.POST("/meals/:id/like", handlers.LikeMeal)
-		protected.POST("/meals/:id/dislike", handlers.DislikeMeal)
-		protected.POST("/meals/:id/reviews", handlers.AddMealReview)
-
-		// Current Meal Plan (Single Plan Approach)
-		protected.GET("/current-meal-plan", handlers.GetCurrentMealPlan)
-		protected.POST("/current-meal-plan/populate-from-liked", handlers.PopulateFromLikedMeals)
-		protected.PUT("/current-meal-plan/meals", handlers.UpdateMealInPlan)
-		protected.PUT("/shopping-items/:item_id", handlers.ToggleShoppingItem)
-
-		// Legacy Meal planning (Multiple Plans)
-		protected.POST("/meal-plans", handlers.CreateMealPlan)
-		protected.POST("/meal-plans/auto-generate", handlers.AutoGenerateMealPlan)
-		protected.GET("/meal-plans", handlers.GetMealPlans)
-		protected.GET("/meal-plans/:id", handlers.GetMealPlan)
-		protected.PUT("/meal-plans/:id", handlers.UpdateMealPlan)
-		protected.DELETE("/meal-plans/:id", handlers.DeleteMealPlan)
-
-		// Shopping lists
-		protected.POST("/meal-plans/:id/shopping-list", handlers.GenerateShoppingList)
-		protected.GET("/shopping-lists", handlers.GetShoppingLists)
-		protected.PUT("/shopping-list-items/:item_id", handlers.UpdateShoppingListItem)
-	}
+	server.Routes(api, cfg.CatalogEditors)
 
 	// Start server
-	port := "8080"
-	log.Printf("Server starting on port %s", port)
-	if err := r.Run(":" + port); err != nil {
+	log.Printf("Server starting on port %d (%s)", cfg.Port, cfg.Env)
+	if err := r.Run(":" + strconv.Itoa(cfg.Port)); err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
 }
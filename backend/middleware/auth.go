@@ -1,18 +1,31 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
+	"food-app/config"
 	"food-app/models"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtSecret = []byte(getEnv("JWT_SECRET", "your-secret-key"))
+var jwtSecret = []byte(config.DefaultJWTSecret)
+
+// SetJWTSecret sets the key tokens are signed and checked with; main calls it
+// with the configured secret before serving
+func SetJWTSecret(secret string) {
+	jwtSecret = []byte(secret)
+}
+
+// accessTokenAudience marks API access tokens, so that other tokens signed
+// with the same secret are never accepted as a login
+const accessTokenAudience = "food-app:access"
+
+var errInvalidToken = errors.New("invalid token")
 
 type Claims struct {
 	UserID uint   `json:"user_id"`
@@ -25,6 +38,7 @@ func GenerateToken(user models.User) (string, error) {
 		UserID: user.ID,
 		Email:  user.Email,
 		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{accessTokenAudience},
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
@@ -34,6 +48,19 @@ func GenerateToken(user models.User) (string, error) {
 	return token.SignedString(jwtSecret)
 }
 
+// parseAccessToken validates a token made by GenerateToken; tokens for another
+// audience or without a user are rejected
+func parseAccessToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return jwtSecret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithAudience(accessTokenAudience))
+	if err != nil || !token.Valid || claims.UserID == 0 {
+		return nil, errInvalidToken
+	}
+	return claims, nil
+}
+
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -45,23 +72,13 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
 		
-		token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-			return jwtSecret, nil
-		})
-
-		if err != nil || !token.Valid {
+		claims, err := parseAccessToken(tokenString)
+		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			c.Abort()
 			return
 		}
 
-		claims, ok := token.Claims.(*Claims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-			c.Abort()
-			return
-		}
-
 		c.Set("userID", claims.UserID)
 		c.Set("email", claims.Email)
 		c.Next()
@@ -78,24 +95,25 @@ func OptionalAuthMiddleware() gin.HandlerFunc {
 
 		tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
 		
-		token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-			return jwtSecret, nil
-		})
-
-		if err == nil && token.Valid {
-			if claims, ok := token.Claims.(*Claims); ok {
-				c.Set("userID", claims.UserID)
-				c.Set("email", claims.Email)
-			}
+		if claims, err := parseAccessToken(tokenString); err == nil {
+			c.Set("userID", claims.UserID)
+			c.Set("email", claims.Email)
 		}
 
 		c.Next()
 	}
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// StreamAuthMiddleware authenticates like AuthMiddleware but also accepts the token
+// in the access_token query parameter, since browser EventSource cannot set headers
+func StreamAuthMiddleware() gin.HandlerFunc {
+	auth := AuthMiddleware()
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			if token := c.Query("access_token"); token != "" {
+				c.Request.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		auth(c)
 	}
-	return defaultValue
 }
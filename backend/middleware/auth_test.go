@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"food-app/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// authStatus runs a request through middleware and reports the status and the user it set
+func authStatus(middleware gin.HandlerFunc, target, bearer string) (int, uint) {
+	router := gin.New()
+	var userID uint
+	router.GET("/*path", middleware, func(c *gin.Context) {
+		userID = c.GetUint("userID")
+		c.Status(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec.Code, userID
+}
+
+func signed(t *testing.T, claims jwt.Claims, key []byte) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return token
+}
+
+func TestAuthMiddlewareAcceptsOnlyAccessTokens(t *testing.T) {
+	SetJWTSecret("test-secret")
+
+	access, err := GenerateToken(models.User{ID: 7, Email: "ann@example.com"})
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	state, _, err := GenerateOAuthState("google", 7)
+	if err != nil {
+		t.Fatalf("GenerateOAuthState: %v", err)
+	}
+	expires := jwt.NewNumericDate(time.Now().Add(time.Hour))
+
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"access token", access, http.StatusNoContent},
+		{"missing", "", http.StatusUnauthorized},
+		{"oauth state", state, http.StatusUnauthorized},
+		{"no audience", signed(t, Claims{UserID: 7, RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: expires}}, jwtSecret), http.StatusUnauthorized},
+		{"no user", signed(t, Claims{RegisteredClaims: jwt.RegisteredClaims{
+			Audience: jwt.ClaimStrings{accessTokenAudience}, ExpiresAt: expires,
+		}}, jwtSecret), http.StatusUnauthorized},
+		{"other key", signed(t, Claims{UserID: 7, RegisteredClaims: jwt.RegisteredClaims{
+			Audience: jwt.ClaimStrings{accessTokenAudience}, ExpiresAt: expires,
+		}}, []byte("other-secret")), http.StatusUnauthorized},
+		{"expired", signed(t, Claims{UserID: 7, RegisteredClaims: jwt.RegisteredClaims{
+			Audience: jwt.ClaimStrings{accessTokenAudience}, ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+		}}, jwtSecret), http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status, _ := authStatus(AuthMiddleware(), "/plan", tt.token); status != tt.want {
+				t.Errorf("header: status %d, want %d", status, tt.want)
+			}
+			if status, _ := authStatus(StreamAuthMiddleware(), "/events?access_token="+tt.token, ""); status != tt.want {
+				t.Errorf("query: status %d, want %d", status, tt.want)
+			}
+			wantUser := uint(0)
+			if tt.want == http.StatusNoContent {
+				wantUser = 7
+			}
+			if status, userID := authStatus(OptionalAuthMiddleware(), "/meals", tt.token); status != http.StatusNoContent || userID != wantUser {
+				t.Errorf("optional: status %d as user %d, want user %d", status, userID, wantUser)
+			}
+		})
+	}
+}
+
+func TestOAuthStateRejectsAccessTokens(t *testing.T) {
+	SetJWTSecret("test-secret")
+
+	stateToken, state, err := GenerateOAuthState("google", 3)
+	if err != nil {
+		t.Fatalf("GenerateOAuthState: %v", err)
+	}
+	parsed, err := ParseOAuthState(stateToken, "google")
+	if err != nil || parsed.Nonce != state.Nonce || parsed.LinkUserID != 3 {
+		t.Fatalf("ParseOAuthState = %+v, %v", parsed, err)
+	}
+	if _, err := ParseOAuthState(stateToken, "github"); err == nil {
+		t.Error("state for google accepted for github")
+	}
+
+	access, _ := GenerateToken(models.User{ID: 3})
+	if _, err := ParseOAuthState(access, "google"); err == nil {
+		t.Error("an access token was accepted as OAuth state")
+	}
+	// State signed with the JWT secret itself, as before the keys were split
+	legacy := signed(t, OAuthState{Provider: "google", Nonce: "n", RegisteredClaims: jwt.RegisteredClaims{
+		Audience: jwt.ClaimStrings{oauthStateAudience}, ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}}, jwtSecret)
+	if _, err := ParseOAuthState(legacy, "google"); err == nil {
+		t.Error("state signed with the access token key was accepted")
+	}
+}
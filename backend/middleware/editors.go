@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireEmail lets through only authenticated users whose email is in allowed,
+// compared ignoring case. It runs after AuthMiddleware. With an empty list every
+// request is refused.
+func RequireEmail(allowed []string) gin.HandlerFunc {
+	emails := make(map[string]bool, len(allowed))
+	for _, email := range allowed {
+		if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+			emails[email] = true
+		}
+	}
+	return func(c *gin.Context) {
+		if !emails[strings.ToLower(c.GetString("email"))] {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Not allowed to manage the catalog"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
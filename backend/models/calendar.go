@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// CalendarFeed is a user's iCalendar subscription of their meal plan. Calendar apps
+// fetch it without logging in, so the secret Token is the only credential.
+type CalendarFeed struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	UserID        uint      `json:"user_id" gorm:"unique"`
+	Token         string    `json:"-" gorm:"unique"`
+	Timezone      string    `json:"timezone" gorm:"default:'UTC'"`         // IANA name, e.g. Europe/Stockholm
+	BreakfastTime string    `json:"breakfast_time" gorm:"default:'08:00'"` // HH:MM
+	LunchTime     string    `json:"lunch_time" gorm:"default:'12:30'"`
+	DinnerTime    string    `json:"dinner_time" gorm:"default:'19:00'"`
+	SnackTime     string    `json:"snack_time" gorm:"default:'15:30'"`
+	MealDuration  int       `json:"meal_duration" gorm:"default:45"`    // minutes each event lasts
+	CookReminders bool      `json:"cook_reminders" gorm:"default:true"` // alarm at PrepTime+CookTime before the meal
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// MealTime returns the HH:MM time a meal type is eaten
+func (f CalendarFeed) MealTime(mealType string) string {
+	switch mealType {
+	case "breakfast":
+		return f.BreakfastTime
+	case "lunch":
+		return f.LunchTime
+	case "snack":
+		return f.SnackTime
+	}
+	return f.DinnerTime
+}
+
+// Location returns the feed's time zone, falling back to UTC
+func (f CalendarFeed) Location() *time.Location {
+	if location, err := time.LoadLocation(f.Timezone); err == nil {
+		return location
+	}
+	return time.UTC
+}
@@ -0,0 +1,50 @@
+package models
+
+import "strings"
+
+// Meal difficulties, easiest first
+const (
+	DifficultyEasy   = "easy"
+	DifficultyMedium = "medium"
+	DifficultyHard   = "hard"
+)
+
+// DifficultyRank orders difficulties from 1 (easy) to 3 (hard); unknown values rank 0
+func DifficultyRank(difficulty string) int {
+	switch strings.ToLower(difficulty) {
+	case DifficultyEasy:
+		return 1
+	case DifficultyMedium:
+		return 2
+	case DifficultyHard:
+		return 3
+	}
+	return 0
+}
+
+// ActiveTime is the hands-on time of a meal in minutes
+func (m Meal) ActiveTime() int {
+	return m.PrepTime + m.CookTime
+}
+
+// IsWeekend reports whether a plan day ("saturday", "sunday") falls on the weekend
+func IsWeekend(day string) bool {
+	day = strings.ToLower(day)
+	return day == "saturday" || day == "sunday"
+}
+
+// TimeBudgets returns the user's cooking time budget in minutes for each day
+// of the week; days without a budget are omitted
+func (u User) TimeBudgets(days []string) map[string]int {
+	budgets := map[string]int{}
+	for _, day := range days {
+		budget := u.WeekdayTimeBudget
+		if IsWeekend(day) {
+			budget = u.WeekendTimeBudget
+		}
+		if budget > 0 {
+			budgets[day] = budget
+		}
+	}
+	return budgets
+}
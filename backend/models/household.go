@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Household member roles
+const (
+	HouseholdRoleOwner  = "owner"
+	HouseholdRoleAdmin  = "admin"
+	HouseholdRoleMember = "member"
+)
+
+// Household invitation statuses
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationDeclined = "declined"
+)
+
+// Household groups users who share one meal plan and shopping list.
+// The shared plan is the owner's active MealPlan.
+type Household struct {
+	ID        uint              `json:"id" gorm:"primaryKey"`
+	Name      string            `json:"name" gorm:"not null"`
+	OwnerID   uint              `json:"owner_id"`
+	Members   []HouseholdMember `json:"members"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// HouseholdMember is a user's membership in a household; a user belongs to at most one
+type HouseholdMember struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	HouseholdID uint      `json:"household_id" gorm:"index"`
+	UserID      uint      `json:"user_id" gorm:"unique"`
+	Role        string    `json:"role" gorm:"default:'member'"` // owner, admin, member
+	CreatedAt   time.Time `json:"created_at"`
+	User        User      `json:"user"`
+}
+
+// HouseholdInvitation invites someone by email to join a household
+type HouseholdInvitation struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	HouseholdID uint      `json:"household_id" gorm:"index"`
+	Email       string    `json:"email" gorm:"index"`
+	Role        string    `json:"role"`
+	Token       string    `json:"token" gorm:"unique"`
+	Status      string    `json:"status" gorm:"default:'pending'"` // pending, accepted, declined
+	InvitedByID uint      `json:"invited_by_id"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Household   Household `json:"household"`
+}
+
+// CanManage reports whether the member may invite and remove other members
+func (m HouseholdMember) CanManage() bool {
+	return m.Role == HouseholdRoleOwner || m.Role == HouseholdRoleAdmin
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// UserIdentity links an external login provider account to a local user
+type UserIdentity struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	UserID        uint      `json:"user_id" gorm:"index;not null"`
+	Provider      string    `json:"provider" gorm:"uniqueIndex:idx_identity_provider_subject;not null"` // google, github, oidc
+	Subject       string    `json:"subject" gorm:"uniqueIndex:idx_identity_provider_subject;not null"`  // provider's stable user id
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	LastLoginAt   time.Time `json:"last_login_at"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	User          User      `json:"-"`
+}
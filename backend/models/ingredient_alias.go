@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// IngredientAlias is another name for a catalog ingredient, such as "White Rice"
+// for Rice. Names resolve to their ingredient through its aliases wherever
+// recipes are entered, imported or seeded.
+type IngredientAlias struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	IngredientID uint      `json:"ingredient_id" gorm:"index;not null"`
+	Name         string    `json:"name" gorm:"unique;not null"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -6,12 +6,12 @@ import (
 	"strings"
 	"time"
 
-	"github.com/jinzhu/gorm"
 	"github.com/lib/pq"
+	"gorm.io/gorm"
 )
 
 type Meal struct {
-	ID               uint           `json:"id" gorm:"primary_key"`
+	ID               uint           `json:"id" gorm:"primaryKey"`
 	Name             string         `json:"name" gorm:"not null"`
 	Description      string         `json:"description"`
 	ImageURL         string         `json:"image_url"`
@@ -22,32 +22,39 @@ type Meal struct {
 	Cuisine          string         `json:"cuisine"`
 	MealType         string         `json:"meal_type"` // breakfast, lunch, dinner, snack
 	Instructions     string `json:"instructions" gorm:"type:text"`
-	Ingredients      []Ingredient   `json:"ingredients" gorm:"many2many:meal_ingredients;"`
+	Ingredients      []Ingredient   `json:"ingredients" gorm:"-"` // the ingredients of IngredientLines, kept for older clients
+	IngredientLines  []MealIngredient `json:"ingredient_lines" gorm:"foreignKey:MealID"`
 	NutritionInfo    NutritionInfo  `json:"nutrition_info" gorm:"embedded"`
 	DietaryTags      StringArray `json:"dietary_tags" gorm:"type:text[]"`
 	Allergens        StringArray `json:"allergens" gorm:"type:text[]"`
 	LikesCount       int            `json:"likes_count" gorm:"default:0"`
+	SourceURL        string         `json:"source_url" gorm:"index"` // page the recipe was imported from
 	CreatedAt        time.Time      `json:"created_at"`
 	UpdatedAt        time.Time      `json:"updated_at"`
-	DeletedAt        *time.Time     `json:"deleted_at" sql:"index"`
+	DeletedAt        gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 }
 
 type Ingredient struct {
-	ID          uint    `json:"id" gorm:"primary_key"`
+	ID          uint    `json:"id" gorm:"primaryKey"`
 	Name        string  `json:"name" gorm:"unique;not null"`
 	Category    string  `json:"category"` // protein, vegetable, grain, etc.
 	Unit        string  `json:"unit"`     // cup, tbsp, piece, etc.
 	CaloriesPer100g float64 `json:"calories_per_100g"`
+	Aliases     []IngredientAlias `json:"aliases,omitempty" gorm:"foreignKey:IngredientID"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
+// MealIngredient is one line of a meal's recipe: how much of a catalog
+// ingredient the meal calls for at its own servings. A meal lists each
+// ingredient once, so the pair is the key.
 type MealIngredient struct {
-	MealID       uint        `json:"meal_id"`
-	IngredientID uint        `json:"ingredient_id"`
-	Quantity     float64     `json:"quantity"`
-	Unit         string      `json:"unit"`
-	Ingredient   Ingredient  `json:"ingredient"`
+	MealID       uint       `json:"meal_id" gorm:"primaryKey;autoIncrement:false"`
+	IngredientID uint       `json:"ingredient_id" gorm:"primaryKey;autoIncrement:false"`
+	Quantity     float64    `json:"quantity"`
+	Unit         string     `json:"unit"`
+	Note         string     `json:"note"` // preparation, e.g. "finely chopped"
+	Ingredient   Ingredient `json:"ingredient"`
 }
 
 type NutritionInfo struct {
@@ -61,7 +68,7 @@ type NutritionInfo struct {
 }
 
 type UserMealInteraction struct {
-	ID        uint      `json:"id" gorm:"primary_key"`
+	ID        uint      `json:"id" gorm:"primaryKey"`
 	UserID    uint      `json:"user_id"`
 	MealID    uint      `json:"meal_id"`
 	Liked     bool      `json:"liked"`
@@ -73,7 +80,7 @@ type UserMealInteraction struct {
 }
 
 type MealReview struct {
-	ID        uint      `json:"id" gorm:"primary_key"`
+	ID        uint      `json:"id" gorm:"primaryKey"`
 	UserID    uint      `json:"user_id"`
 	MealID    uint      `json:"meal_id"`
 	Rating    int       `json:"rating"` // 1-5 stars
@@ -113,7 +120,7 @@ func (sa *StringArray) Scan(value interface{}) error {
 			}
 		}
 		// Handle PostgreSQL array format
-		return pq.Array(sa).Scan(value)
+		return pq.Array((*[]string)(sa)).Scan(value)
 	case []byte:
 		// Handle JSON byte format
 		if len(v) > 0 && v[0] == '[' {
@@ -123,9 +130,9 @@ func (sa *StringArray) Scan(value interface{}) error {
 				return nil
 			}
 		}
-		return pq.Array(sa).Scan(value)
+		return pq.Array((*[]string)(sa)).Scan(value)
 	default:
-		return pq.Array(sa).Scan(value)
+		return pq.Array((*[]string)(sa)).Scan(value)
 	}
 }
 
@@ -144,6 +151,19 @@ func (sa *StringArray) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-func (m *Meal) BeforeCreate(scope *gorm.Scope) error {
-	return scope.SetColumn("CreatedAt", time.Now())
+func (m *Meal) BeforeCreate(tx *gorm.DB) error {
+	m.CreatedAt = time.Now()
+	return nil
+}
+
+// AfterFind fills Ingredients from IngredientLines when the lines were preloaded
+func (m *Meal) AfterFind(tx *gorm.DB) error {
+	if m.IngredientLines == nil {
+		return nil
+	}
+	m.Ingredients = make([]Ingredient, 0, len(m.IngredientLines))
+	for _, line := range m.IngredientLines {
+		m.Ingredients = append(m.Ingredients, line.Ingredient)
+	}
+	return nil
 }
@@ -3,78 +3,76 @@ package models
 import (
 	"time"
 
-	"github.com/jinzhu/gorm"
+	"gorm.io/gorm"
 )
 
+// MealPlan is a weekly plan. A user works on one plan at a time, pointed to by
+// User.ActiveMealPlanID; IsActive mirrors that pointer for older clients.
 type MealPlan struct {
-	ID        uint             `json:"id" gorm:"primary_key"`
-	UserID    uint             `json:"user_id"`
-	Name      string           `json:"name"`
-	WeekStart time.Time        `json:"week_start"`
-	IsActive  bool             `json:"is_active" gorm:"default:true"`
-	Meals     []MealPlanEntry  `json:"meals"`
-	CreatedAt time.Time        `json:"created_at"`
-	UpdatedAt time.Time        `json:"updated_at"`
-	User      User             `json:"user"`
-}
-
-// CurrentMealPlan represents the user's single active weekly meal plan
-type CurrentMealPlan struct {
-	ID                uint                  `json:"id" gorm:"primary_key"`
-	UserID            uint                  `json:"user_id" gorm:"unique"`
-	WeekStart         time.Time             `json:"week_start"`
-	Meals             []MealPlanEntry       `json:"meals"`
-	ShoppingList      *ShoppingList         `json:"shopping_list,omitempty"`
-	CreatedAt         time.Time             `json:"created_at"`
-	UpdatedAt         time.Time             `json:"updated_at"`
-	User              User                  `json:"user"`
+	ID            uint            `json:"id" gorm:"primaryKey"`
+	UserID        uint            `json:"user_id" gorm:"index"`
+	HouseholdID   *uint           `json:"household_id" gorm:"index"` // set when the plan is shared by a household
+	Name          string          `json:"name"`
+	WeekStart     time.Time       `json:"week_start"`
+	IsActive      bool            `json:"is_active"`
+	HouseholdSize int             `json:"household_size" gorm:"default:1"` // people each entry feeds unless overridden
+	Meals         []MealPlanEntry `json:"meals" gorm:"foreignKey:MealPlanID"`
+	ShoppingList  *ShoppingList   `json:"shopping_list,omitempty" gorm:"foreignKey:MealPlanID"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+	User          User            `json:"user"`
 }
 
 type MealPlanEntry struct {
-	ID         uint      `json:"id" gorm:"primary_key"`
+	ID         uint      `json:"id" gorm:"primaryKey"`
 	MealPlanID uint      `json:"meal_plan_id"`
 	MealID     uint      `json:"meal_id"`
 	Day        string    `json:"day"` // monday, tuesday, etc.
 	MealType   string    `json:"meal_type"` // breakfast, lunch, dinner
-	Servings   int       `json:"servings" gorm:"default:1"`
+	Servings   int       `json:"servings" gorm:"default:1"` // deprecated, use Headcount
+	Headcount  int       `json:"headcount"` // people eating this entry incl. guests; 0 uses the plan's household size
+	LeftoverOfID *uint   `json:"leftover_of_id" gorm:"index"` // source entry whose cook also covers this one
+	Locked     bool      `json:"locked" gorm:"default:false"` // kept when the plan is regenerated
+	AbsentMembers StringArray `json:"absent_members" gorm:"type:text[]"` // usernames of household members not eating this entry
 	CreatedAt  time.Time `json:"created_at"`
 	Meal       Meal      `json:"meal"`
 }
 
 type ShoppingList struct {
-	ID               uint                  `json:"id" gorm:"primary_key"`
+	ID               uint                  `json:"id" gorm:"primaryKey"`
 	UserID           uint                  `json:"user_id"`
+	HouseholdID      *uint                 `json:"household_id" gorm:"index"`
 	MealPlanID       uint                  `json:"meal_plan_id"`
 	Name             string                `json:"name"`
 	Items            []ShoppingListItem    `json:"items"`
 	IsCompleted      bool                  `json:"is_completed" gorm:"default:false"`
+	EstimatedTotal   float64               `json:"estimated_total"` // sum of priced items; see ShoppingListItem.EstimatedCost
 	CreatedAt        time.Time             `json:"created_at"`
 	UpdatedAt        time.Time             `json:"updated_at"`
 	User             User                  `json:"user"`
 	MealPlan         MealPlan              `json:"meal_plan"`
 }
 
+// ShoppingListItem is either an aggregated ingredient of the plan or, when Manual,
+// a free-text item such as "paper towels" that has no IngredientID
 type ShoppingListItem struct {
-	ID             uint      `json:"id" gorm:"primary_key"`
+	ID             uint      `json:"id" gorm:"primaryKey"`
 	ShoppingListID uint      `json:"shopping_list_id"`
-	IngredientID   uint      `json:"ingredient_id"`
+	IngredientID   uint      `json:"ingredient_id"` // 0 for manual items
+	Manual         bool      `json:"manual" gorm:"default:false"`
+	Name           string    `json:"name"`     // manual items only; see DisplayName
+	Category       string    `json:"category"` // manual items only; see ItemCategory
 	Quantity       float64   `json:"quantity"`
 	Unit           string    `json:"unit"`
 	IsPurchased    bool      `json:"is_purchased" gorm:"default:false"`
+	NoLongerNeeded bool      `json:"no_longer_needed" gorm:"default:false"` // bought, but the plan no longer calls for it
 	Notes          string    `json:"notes"`
+	EstimatedCost  *float64  `json:"estimated_cost"` // nil when no usable price is known
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 	Ingredient     Ingredient `json:"ingredient"`
 }
 
-type MealIngredient struct {
-	MealID       uint      `json:"meal_id"`
-	IngredientID uint      `json:"ingredient_id"`
-	Quantity     float64   `json:"quantity"`
-	Unit         string    `json:"unit"`
-	Ingredient   Ingredient `json:"ingredient"`
-}
-
-func (mp *MealPlan) BeforeCreate(scope *gorm.Scope) error {
+func (mp *MealPlan) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
\ No newline at end of file
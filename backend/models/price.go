@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// PriceCurrency is the currency prices are recorded in. Cost estimates add prices
+// up, so they only use prices in this currency.
+const PriceCurrency = "USD"
+
+// IngredientPrice is an observed price for an amount of an ingredient at a store
+type IngredientPrice struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	IngredientID uint       `json:"ingredient_id" gorm:"index;not null"`
+	Price        float64    `json:"price" gorm:"not null"`    // price paid for Quantity of Unit
+	Quantity     float64    `json:"quantity" gorm:"not null"` // e.g. 1 (lb), 500 (g)
+	Unit         string     `json:"unit" gorm:"not null"`
+	Currency     string     `json:"currency" gorm:"default:'USD'"`
+	Store        string     `json:"store"`
+	ObservedAt   time.Time  `json:"observed_at"`
+	CreatedByID  uint       `json:"created_by_id"`
+	CreatedAt    time.Time  `json:"created_at"`
+	Ingredient   Ingredient `json:"-"`
+}
+
+// UnitPrice returns the price of one Unit
+func (p IngredientPrice) UnitPrice() float64 {
+	if p.Quantity <= 0 {
+		return 0
+	}
+	return p.Price / p.Quantity
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// ProviderRequestCount is the number of requests made to an external recipe
+// provider on one UTC day, checked against the provider's daily quota
+type ProviderRequestCount struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Provider  string    `json:"provider" gorm:"uniqueIndex:idx_provider_day"`
+	Day       string    `json:"day" gorm:"uniqueIndex:idx_provider_day"` // YYYY-MM-DD
+	Requests  int       `json:"requests"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,105 @@
+package models
+
+// EffectiveHeadcount returns how many people an entry feeds. Without an override
+// that is the household, less any members who are absent.
+func (e MealPlanEntry) EffectiveHeadcount(householdSize int) int {
+	if e.Headcount > 0 {
+		return e.Headcount
+	}
+	if present := householdSize - len(e.AbsentMembers); present > 0 {
+		return present
+	}
+	return 1
+}
+
+// IngredientScale returns the factor applied to the meal's recipe quantities,
+// which are written for Meal.Servings portions. Meal must be loaded.
+func (e MealPlanEntry) IngredientScale(householdSize int) float64 {
+	recipeServings := e.Meal.Servings
+	if recipeServings <= 0 {
+		recipeServings = 1
+	}
+	return float64(e.EffectiveHeadcount(householdSize)) / float64(recipeServings)
+}
+
+// Scaled multiplies every nutrient by factor
+func (n NutritionInfo) Scaled(factor float64) NutritionInfo {
+	return NutritionInfo{
+		Calories:      n.Calories * factor,
+		Protein:       n.Protein * factor,
+		Carbohydrates: n.Carbohydrates * factor,
+		Fat:           n.Fat * factor,
+		Fiber:         n.Fiber * factor,
+		Sugar:         n.Sugar * factor,
+		Sodium:        n.Sodium * factor,
+	}
+}
+
+// Add accumulates other into n
+func (n *NutritionInfo) Add(other NutritionInfo) {
+	n.Calories += other.Calories
+	n.Protein += other.Protein
+	n.Carbohydrates += other.Carbohydrates
+	n.Fat += other.Fat
+	n.Fiber += other.Fiber
+	n.Sugar += other.Sugar
+	n.Sodium += other.Sodium
+}
+
+var mealTypeOrder = map[string]int{"breakfast": 0, "lunch": 1, "dinner": 2, "snack": 3}
+
+var dayOrder = map[string]int{
+	"monday": 0, "tuesday": 1, "wednesday": 2, "thursday": 3, "friday": 4, "saturday": 5, "sunday": 6,
+}
+
+// DayIndex returns a plan day's offset from Monday; ok is false for an unknown day
+func DayIndex(day string) (int, bool) {
+	index, ok := dayOrder[day]
+	return index, ok
+}
+
+// SlotIndex orders plan slots through the week, e.g. Monday dinner comes before
+// Tuesday lunch; ok is false for an unknown day or meal type
+func SlotIndex(day, mealType string) (int, bool) {
+	dayIndex, dayOK := dayOrder[day]
+	mealTypeIndex, mealTypeOK := mealTypeOrder[mealType]
+	return dayIndex*len(mealTypeOrder) + mealTypeIndex, dayOK && mealTypeOK
+}
+
+// IsLeftover reports whether the entry eats food cooked for another entry
+func (e MealPlanEntry) IsLeftover() bool {
+	return e.LeftoverOfID != nil
+}
+
+// CookScales returns, per cooking entry ID, the factor applied to the meal's recipe
+// quantities. A cook is scaled up to also cover its leftover entries, and leftover
+// entries get no scale of their own so ingredients are counted once per cook.
+// Meals must be loaded.
+func CookScales(entries []MealPlanEntry, householdSize int) map[uint]float64 {
+	scales := make(map[uint]float64, len(entries))
+	byID := make(map[uint]MealPlanEntry, len(entries))
+	for _, entry := range entries {
+		byID[entry.ID] = entry
+	}
+
+	for _, entry := range entries {
+		if entry.IsLeftover() {
+			if source, ok := byID[*entry.LeftoverOfID]; ok && !source.IsLeftover() {
+				scales[source.ID] += source.leftoverScale(entry, householdSize)
+				continue
+			}
+		}
+		scales[entry.ID] += entry.IngredientScale(householdSize)
+	}
+
+	return scales
+}
+
+// leftoverScale is the extra recipe fraction a cook needs to feed a leftover entry
+func (e MealPlanEntry) leftoverScale(leftover MealPlanEntry, householdSize int) float64 {
+	recipeServings := e.Meal.Servings
+	if recipeServings <= 0 {
+		recipeServings = 1
+	}
+	return float64(leftover.EffectiveHeadcount(householdSize)) / float64(recipeServings)
+}
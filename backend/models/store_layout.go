@@ -0,0 +1,60 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// UncategorizedGroup collects shopping items with no category or no matching aisle
+const UncategorizedGroup = "Other"
+
+// StoreLayout is a user's walk through a store: its aisles in visiting order
+type StoreLayout struct {
+	ID        uint         `json:"id" gorm:"primaryKey"`
+	UserID    uint         `json:"user_id" gorm:"index"`
+	Name      string       `json:"name" gorm:"not null"`
+	Aisles    []StoreAisle `json:"aisles" gorm:"foreignKey:StoreLayoutID"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// StoreAisle is a stop in a StoreLayout holding the ingredient categories shelved there
+type StoreAisle struct {
+	ID            uint        `json:"id" gorm:"primaryKey"`
+	StoreLayoutID uint        `json:"store_layout_id" gorm:"index"`
+	Name          string      `json:"name"`
+	Position      int         `json:"position"` // 0 is visited first
+	Categories    StringArray `json:"categories" gorm:"type:text[]"`
+}
+
+// AisleFor returns the aisle shelving a category, matched case-insensitively
+func (l StoreLayout) AisleFor(category string) (StoreAisle, bool) {
+	for _, aisle := range l.Aisles {
+		for _, shelved := range aisle.Categories {
+			if strings.EqualFold(shelved, category) {
+				return aisle, true
+			}
+		}
+	}
+	return StoreAisle{}, false
+}
+
+// DisplayName is the manual item's text or the ingredient's name
+func (i ShoppingListItem) DisplayName() string {
+	if i.Manual {
+		return i.Name
+	}
+	return i.Ingredient.Name
+}
+
+// ItemCategory is the manual item's category or the ingredient's, defaulting to UncategorizedGroup
+func (i ShoppingListItem) ItemCategory() string {
+	category := i.Category
+	if !i.Manual {
+		category = i.Ingredient.Category
+	}
+	if category == "" {
+		return UncategorizedGroup
+	}
+	return category
+}
@@ -3,12 +3,12 @@ package models
 import (
 	"time"
 
-	"github.com/jinzhu/gorm"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 type User struct {
-	ID                uint      `json:"id" gorm:"primary_key"`
+	ID                uint      `json:"id" gorm:"primaryKey"`
 	Email             string    `json:"email" gorm:"unique;not null"`
 	Username          string    `json:"username" gorm:"unique;not null"`
 	Password          string    `json:"-" gorm:"not null"`
@@ -18,10 +18,14 @@ type User struct {
 	PreferredMealTypes  StringArray `json:"preferred_meal_types" gorm:"type:text[]"`
 	Allergies           StringArray `json:"allergies" gorm:"type:text[]"`
 	CalorieGoal         int      `json:"calorie_goal"`
+	WeekdayTimeBudget   int      `json:"weekday_time_budget"` // minutes per day, 0 for no limit
+	WeekendTimeBudget   int      `json:"weekend_time_budget"` // minutes per day, 0 for no limit
+	MaxDifficulty       string   `json:"max_difficulty"`      // easy, medium, hard or empty for no cap
+	ActiveMealPlanID    *uint    `json:"active_meal_plan_id"` // the plan /current-meal-plan works on
 	IsActive            bool     `json:"is_active" gorm:"default:true"`
 	CreatedAt           time.Time `json:"created_at"`
 	UpdatedAt           time.Time `json:"updated_at"`
-	DeletedAt           *time.Time `json:"deleted_at" sql:"index"`
+	DeletedAt           gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 }
 
 type UserPreferences struct {
@@ -30,6 +34,9 @@ type UserPreferences struct {
 	PreferredMealTypes  StringArray `json:"preferred_meal_types"`
 	Allergies           StringArray `json:"allergies"`
 	CalorieGoal         int         `json:"calorie_goal"`
+	WeekdayTimeBudget   int         `json:"weekday_time_budget" binding:"min=0"`
+	WeekendTimeBudget   int         `json:"weekend_time_budget" binding:"min=0"`
+	MaxDifficulty       string      `json:"max_difficulty" binding:"omitempty,oneof=easy medium hard"`
 }
 
 func (u *User) HashPassword(password string) error {
@@ -45,6 +52,7 @@ func (u *User) CheckPassword(password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
 }
 
-func (u *User) BeforeCreate(scope *gorm.Scope) error {
-	return scope.SetColumn("CreatedAt", time.Now())
+func (u *User) BeforeCreate(tx *gorm.DB) error {
+	u.CreatedAt = time.Now()
+	return nil
 }
@@ -0,0 +1,30 @@
+package repository
+
+import "food-app/models"
+
+// CalendarFeed loads a user's calendar feed
+func (s *Store) CalendarFeed(userID uint) (models.CalendarFeed, error) {
+	var feed models.CalendarFeed
+	err := first(s.db.Where("user_id = ?", userID), &feed)
+	return feed, err
+}
+
+// CalendarFeedByToken loads the feed with a secret token
+func (s *Store) CalendarFeedByToken(token string) (models.CalendarFeed, error) {
+	var feed models.CalendarFeed
+	err := first(s.db.Where("token = ?", token), &feed)
+	return feed, err
+}
+
+// CreateCalendarFeed inserts a feed and reloads it to pick up the column defaults
+func (s *Store) CreateCalendarFeed(feed *models.CalendarFeed) error {
+	if err := s.db.Create(feed).Error; err != nil {
+		return err
+	}
+	return s.db.First(feed, feed.ID).Error
+}
+
+// UpdateCalendarFeed updates the given columns of a feed
+func (s *Store) UpdateCalendarFeed(feed *models.CalendarFeed, fields map[string]interface{}) error {
+	return s.db.Model(feed).Updates(fields).Error
+}
@@ -0,0 +1,312 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"food-app/models"
+	"food-app/services"
+
+	"gorm.io/gorm"
+)
+
+// CatalogImportResult counts what a catalog import created and updated.
+// UnresolvedIngredients lists the meal ingredient names that matched no
+// ingredient or alias, which the import added to the catalog as new ingredients.
+type CatalogImportResult struct {
+	DryRun                bool     `json:"dry_run"`
+	IngredientsCreated    int      `json:"ingredients_created"`
+	IngredientsUpdated    int      `json:"ingredients_updated"`
+	MealsCreated          int      `json:"meals_created"`
+	MealsUpdated          int      `json:"meals_updated"`
+	UnresolvedIngredients []string `json:"unresolved_ingredients"`
+}
+
+// errDryRun rolls back a dry-run import once it has run
+var errDryRun = errors.New("dry run")
+
+// defaultServings is used for catalog meals that do not give a serving count
+const defaultServings = 4
+
+// ExportCatalog reads every ingredient and meal, with meal ingredient quantities,
+// into the portable catalog form
+func (s *Store) ExportCatalog() (services.Catalog, error) {
+	catalog := services.Catalog{Version: services.CatalogVersion}
+
+	var ingredients []models.Ingredient
+	if err := s.db.Preload("Aliases", func(db *gorm.DB) *gorm.DB { return db.Order("name") }).Order("name").Find(&ingredients).Error; err != nil {
+		return catalog, err
+	}
+	names := make(map[uint]string, len(ingredients))
+	catalog.Ingredients = make([]services.CatalogIngredient, 0, len(ingredients))
+	for _, ingredient := range ingredients {
+		names[ingredient.ID] = ingredient.Name
+		catalog.Ingredients = append(catalog.Ingredients, services.CatalogIngredient{
+			Name:            ingredient.Name,
+			Category:        ingredient.Category,
+			Unit:            ingredient.Unit,
+			CaloriesPer100g: ingredient.CaloriesPer100g,
+			Aliases:         aliasNames(ingredient.Aliases),
+		})
+	}
+
+	var meals []models.Meal
+	if err := s.db.Preload("IngredientLines").Order("name").Find(&meals).Error; err != nil {
+		return catalog, err
+	}
+	catalog.Meals = make([]services.CatalogMeal, 0, len(meals))
+	for _, meal := range meals {
+		entry := services.CatalogMeal{
+			Name:         meal.Name,
+			Description:  meal.Description,
+			ImageURL:     meal.ImageURL,
+			PrepTime:     meal.PrepTime,
+			CookTime:     meal.CookTime,
+			Servings:     meal.Servings,
+			Difficulty:   meal.Difficulty,
+			Cuisine:      meal.Cuisine,
+			MealType:     meal.MealType,
+			Instructions: meal.Instructions,
+			Nutrition:    meal.NutritionInfo,
+			DietaryTags:  append([]string{}, meal.DietaryTags...),
+			Allergens:    append([]string{}, meal.Allergens...),
+			SourceURL:    meal.SourceURL,
+			Ingredients:  make([]services.CatalogMealIngredient, 0, len(meal.IngredientLines)),
+		}
+		for _, mealIngredient := range meal.IngredientLines {
+			entry.Ingredients = append(entry.Ingredients, services.CatalogMealIngredient{
+				Name:     names[mealIngredient.IngredientID],
+				Quantity: mealIngredient.Quantity,
+				Unit:     mealIngredient.Unit,
+				Note:     mealIngredient.Note,
+			})
+		}
+		catalog.Meals = append(catalog.Meals, entry)
+	}
+	return catalog, nil
+}
+
+// ImportCatalog validates a catalog and upserts it in one transaction: ingredients
+// and meals are matched by name ignoring case, updated when found and created
+// otherwise, and each imported meal's ingredients are replaced by the file's.
+// Meal ingredients that name no known ingredient add it to the catalog. A dry run
+// does all of this and then rolls back, so the counts preview a real import.
+// Names resolve through aliases, so a meal listing "White Rice" uses Rice when
+// that is its alias, and an entry's aliases are added to its ingredient.
+// It must be called on a store outside a transaction.
+func (s *Store) ImportCatalog(catalog services.Catalog, dryRun bool) (CatalogImportResult, error) {
+	result := CatalogImportResult{DryRun: dryRun, UnresolvedIngredients: []string{}}
+	if err := catalog.Validate(); err != nil {
+		return result, err
+	}
+
+	err := s.transaction(func(tx *Store) error {
+		counted := CatalogImportResult{DryRun: dryRun, UnresolvedIngredients: []string{}}
+		ingredientIDs := map[string]uint{}
+
+		for i, entry := range catalog.Ingredients {
+			ingredient, created, err := tx.upsertCatalogIngredient(entry)
+			if err != nil {
+				return err
+			}
+			if created {
+				counted.IngredientsCreated++
+			} else {
+				counted.IngredientsUpdated++
+			}
+			if err := tx.addCatalogAliases(ingredient, entry.Aliases, fmt.Sprintf("ingredients[%d].aliases", i)); err != nil {
+				return err
+			}
+			ingredientIDs[strings.ToLower(strings.TrimSpace(entry.Name))] = ingredient.ID
+			for _, alias := range entry.Aliases {
+				ingredientIDs[strings.ToLower(strings.TrimSpace(alias))] = ingredient.ID
+			}
+		}
+
+		for _, entry := range catalog.Meals {
+			meal, created, err := tx.upsertCatalogMeal(entry)
+			if err != nil {
+				return err
+			}
+			if created {
+				counted.MealsCreated++
+			} else {
+				counted.MealsUpdated++
+			}
+
+			if err := tx.db.Where("meal_id = ?", meal.ID).Delete(models.MealIngredient{}).Error; err != nil {
+				return err
+			}
+			linked := map[uint]*models.MealIngredient{}
+			var order []uint
+			for _, item := range entry.Ingredients {
+				key := strings.ToLower(strings.TrimSpace(item.Name))
+				ingredientID, ok := ingredientIDs[key]
+				if !ok {
+					ingredient, created, err := tx.upsertCatalogIngredient(services.CatalogIngredient{Name: item.Name, Unit: item.Unit})
+					if err != nil {
+						return err
+					}
+					if created {
+						counted.IngredientsCreated++
+						counted.UnresolvedIngredients = append(counted.UnresolvedIngredients, ingredient.Name)
+					}
+					ingredientID = ingredient.ID
+					ingredientIDs[key] = ingredientID
+				}
+
+				// Two names for one ingredient make one line
+				if existing, ok := linked[ingredientID]; ok {
+					addToLine(existing, item.Quantity, item.Unit, item.Note)
+					continue
+				}
+				linked[ingredientID] = &models.MealIngredient{
+					MealID:       meal.ID,
+					IngredientID: ingredientID,
+					Quantity:     item.Quantity,
+					Unit:         item.Unit,
+					Note:         item.Note,
+				}
+				order = append(order, ingredientID)
+			}
+			for _, ingredientID := range order {
+				if err := tx.db.Create(linked[ingredientID]).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		result = counted
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, errDryRun) {
+		err = nil
+	}
+	return result, err
+}
+
+// upsertCatalogIngredient updates the ingredient the entry's name resolves to, or
+// creates it. Only entries with details overwrite an existing ingredient, so a
+// bare name from a meal's ingredient list leaves the catalog entry alone.
+func (s *Store) upsertCatalogIngredient(entry services.CatalogIngredient) (models.Ingredient, bool, error) {
+	name := strings.TrimSpace(entry.Name)
+
+	ingredient, err := s.IngredientNamed(name)
+	if err == ErrNotFound {
+		ingredient = models.Ingredient{
+			Name:            name,
+			Category:        entry.Category,
+			Unit:            entry.Unit,
+			CaloriesPer100g: entry.CaloriesPer100g,
+		}
+		err := s.db.Create(&ingredient).Error
+		return ingredient, true, err
+	}
+	if err != nil {
+		return ingredient, false, err
+	}
+	if entry.Category == "" && entry.Unit == "" && entry.CaloriesPer100g == 0 {
+		return ingredient, false, nil
+	}
+
+	err = s.db.Model(&ingredient).Updates(map[string]interface{}{
+		"category":         entry.Category,
+		"unit":             entry.Unit,
+		"calories_per100g": entry.CaloriesPer100g,
+	}).Error
+	return ingredient, false, err
+}
+
+// addCatalogAliases gives an ingredient the aliases it does not have yet. An
+// alias that already names another ingredient is a problem at path.
+func (s *Store) addCatalogAliases(ingredient models.Ingredient, aliases []string, path string) error {
+	for i, name := range aliases {
+		name = strings.TrimSpace(name)
+		existing, err := s.IngredientNamed(name)
+		switch {
+		case err == ErrNotFound:
+			if err := s.CreateIngredientAlias(&models.IngredientAlias{IngredientID: ingredient.ID, Name: name}); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		case existing.ID != ingredient.ID:
+			return &services.CatalogError{Problems: []services.CatalogProblem{{
+				Path:    fmt.Sprintf("%s[%d]", path, i),
+				Message: fmt.Sprintf("%q already names ingredient %q", name, existing.Name),
+			}}}
+		}
+	}
+	return nil
+}
+
+// aliasNames lists the names of aliases
+func aliasNames(aliases []models.IngredientAlias) []string {
+	names := make([]string, 0, len(aliases))
+	for _, alias := range aliases {
+		names = append(names, alias.Name)
+	}
+	return names
+}
+
+// upsertCatalogMeal updates the meal with the entry's name, or creates it
+func (s *Store) upsertCatalogMeal(entry services.CatalogMeal) (models.Meal, bool, error) {
+	servings := entry.Servings
+	if servings == 0 {
+		servings = defaultServings
+	}
+	fields := models.Meal{
+		Name:          strings.TrimSpace(entry.Name),
+		Description:   entry.Description,
+		ImageURL:      entry.ImageURL,
+		PrepTime:      entry.PrepTime,
+		CookTime:      entry.CookTime,
+		Servings:      servings,
+		Difficulty:    entry.Difficulty,
+		Cuisine:       entry.Cuisine,
+		MealType:      entry.MealType,
+		Instructions:  entry.Instructions,
+		NutritionInfo: entry.Nutrition,
+		DietaryTags:   models.StringArray(append([]string{}, entry.DietaryTags...)),
+		Allergens:     models.StringArray(append([]string{}, entry.Allergens...)),
+		SourceURL:     entry.SourceURL,
+	}
+
+	var meal models.Meal
+	err := first(s.db.Where("LOWER(name) = ?", strings.ToLower(fields.Name)), &meal)
+	if err == ErrNotFound {
+		err = s.db.Create(&fields).Error
+		return fields, true, err
+	}
+	if err != nil {
+		return meal, false, err
+	}
+
+	n := entry.Nutrition
+	err = s.db.Model(&meal).Updates(map[string]interface{}{
+		"description":   fields.Description,
+		"image_url":     fields.ImageURL,
+		"prep_time":     fields.PrepTime,
+		"cook_time":     fields.CookTime,
+		"servings":      fields.Servings,
+		"difficulty":    fields.Difficulty,
+		"cuisine":       fields.Cuisine,
+		"meal_type":     fields.MealType,
+		"instructions":  fields.Instructions,
+		"calories":      n.Calories,
+		"protein":       n.Protein,
+		"carbohydrates": n.Carbohydrates,
+		"fat":           n.Fat,
+		"fiber":         n.Fiber,
+		"sugar":         n.Sugar,
+		"sodium":        n.Sodium,
+		"dietary_tags":  fields.DietaryTags,
+		"allergens":     fields.Allergens,
+		"source_url":    fields.SourceURL,
+	}).Error
+	return meal, false, err
+}
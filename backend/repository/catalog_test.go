@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"food-app/models"
+	"food-app/services"
+)
+
+func TestCatalogImportKeepsAliasLinesInOtherUnits(t *testing.T) {
+	s := newTestStore(t)
+
+	// Two names for one ingredient in different units
+	_, err := s.ImportCatalog(services.Catalog{
+		Ingredients: []services.CatalogIngredient{{Name: "Rice", Unit: "cup", Aliases: []string{"White Rice"}}},
+		Meals: []services.CatalogMeal{{
+			Name: "Rice Bowl",
+			Ingredients: []services.CatalogMealIngredient{
+				{Name: "Rice", Quantity: 1, Unit: "cup", Note: "rinsed"},
+				{Name: "White Rice", Quantity: 200, Unit: "g"},
+			},
+		}},
+	}, false)
+	if err != nil {
+		t.Fatalf("ImportCatalog: %v", err)
+	}
+
+	var meal models.Meal
+	if err := s.db.Where("name = ?", "Rice Bowl").First(&meal).Error; err != nil {
+		t.Fatalf("load meal: %v", err)
+	}
+	lines, err := s.MealIngredients([]uint{meal.ID})
+	if err != nil || len(lines) != 1 {
+		t.Fatalf("MealIngredients = %+v, %v", lines, err)
+	}
+	if rice := lines[0]; rice.Quantity != 1 || rice.Unit != "cup" || rice.Note != "rinsed; also 200 g" {
+		t.Errorf("rice = %g %s %q, want 1 cup noting the 200 g", rice.Quantity, rice.Unit, rice.Note)
+	}
+}
+
+// countCatalog counts the rows an import writes
+func countCatalog(t *testing.T, s *Store) map[string]int64 {
+	t.Helper()
+
+	counts := map[string]int64{}
+	for name, model := range map[string]interface{}{
+		"ingredients":      &models.Ingredient{},
+		"aliases":          &models.IngredientAlias{},
+		"meals":            &models.Meal{},
+		"meal_ingredients": &models.MealIngredient{},
+	} {
+		var n int64
+		if err := s.db.Model(model).Count(&n).Error; err != nil {
+			t.Fatalf("count %s: %v", name, err)
+		}
+		counts[name] = n
+	}
+	return counts
+}
+
+func TestCatalogDryRunReportsTheImportAndRollsBack(t *testing.T) {
+	s := newTestStore(t)
+	seedMeal(t, s, "Rice Bowl", "Rice")
+	before := countCatalog(t, s)
+
+	catalog := services.Catalog{
+		Ingredients: []services.CatalogIngredient{
+			{Name: "rice", Category: "grain", Unit: "cup", Aliases: []string{"White Rice"}},
+			{Name: "Tofu", Category: "protein", Unit: "lb"},
+		},
+		Meals: []services.CatalogMeal{
+			{Name: "Rice Bowl", Ingredients: []services.CatalogMealIngredient{{Name: "White Rice", Quantity: 2, Unit: "cup"}}},
+			{Name: "Tofu Stir Fry", Ingredients: []services.CatalogMealIngredient{
+				{Name: "Tofu", Quantity: 1, Unit: "lb"},
+				{Name: "Bok Choy", Quantity: 2, Unit: "cup"},
+			}},
+		},
+	}
+
+	preview, err := s.ImportCatalog(catalog, true)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if after := countCatalog(t, s); !reflect.DeepEqual(after, before) {
+		t.Errorf("dry run wrote rows: %v, before %v", after, before)
+	}
+
+	imported, err := s.ImportCatalog(catalog, false)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if !preview.DryRun || imported.DryRun {
+		t.Errorf("dry run flags %v and %v", preview.DryRun, imported.DryRun)
+	}
+	preview.DryRun = false
+	if !reflect.DeepEqual(preview, imported) {
+		t.Errorf("dry run reported %+v, import %+v", preview, imported)
+	}
+	want := CatalogImportResult{
+		IngredientsCreated: 2, IngredientsUpdated: 1, MealsCreated: 1, MealsUpdated: 1,
+		UnresolvedIngredients: []string{"Bok Choy"},
+	}
+	if !reflect.DeepEqual(imported, want) {
+		t.Errorf("import = %+v, want %+v", imported, want)
+	}
+}
+
+func TestCatalogImportUpdatesByNameIgnoringCase(t *testing.T) {
+	s := newTestStore(t)
+	existing := seedMeal(t, s, "Rice Bowl", "Rice")
+
+	_, err := s.ImportCatalog(services.Catalog{
+		Ingredients: []services.CatalogIngredient{{Name: "  RICE ", Category: "grain", Unit: "cup", CaloriesPer100g: 130}},
+		Meals: []services.CatalogMeal{{
+			Name: "rice bowl", Servings: 3, Cuisine: "japanese",
+			Ingredients: []services.CatalogMealIngredient{{Name: "rice", Quantity: 2, Unit: "cup"}},
+		}},
+	}, false)
+	if err != nil {
+		t.Fatalf("ImportCatalog: %v", err)
+	}
+
+	var meals []models.Meal
+	s.db.Find(&meals)
+	if len(meals) != 1 || meals[0].ID != existing.ID || meals[0].Name != "Rice Bowl" || meals[0].Servings != 3 || meals[0].Cuisine != "japanese" {
+		t.Errorf("meals = %+v, want Rice Bowl updated in place", meals)
+	}
+	var ingredients []models.Ingredient
+	s.db.Find(&ingredients)
+	if len(ingredients) != 1 || ingredients[0].Name != "Rice" || ingredients[0].Category != "grain" || ingredients[0].CaloriesPer100g != 130 {
+		t.Errorf("ingredients = %+v, want Rice updated in place", ingredients)
+	}
+	lines, _ := s.MealIngredients([]uint{existing.ID})
+	if len(lines) != 1 || lines[0].Quantity != 2 {
+		t.Errorf("lines = %+v, want the file's 2 cups", lines)
+	}
+}
+
+func TestCatalogImportRejectsAliasesOfOtherIngredients(t *testing.T) {
+	s := newTestStore(t)
+	seedMeal(t, s, "Rice Bowl", "Rice", "Tofu")
+	before := countCatalog(t, s)
+
+	_, err := s.ImportCatalog(services.Catalog{
+		Ingredients: []services.CatalogIngredient{
+			{Name: "Bok Choy", Unit: "cup"},
+			{Name: "Rice", Unit: "cup", Aliases: []string{"Brown Rice", "tofu"}},
+		},
+	}, false)
+	var invalid *services.CatalogError
+	if !errors.As(err, &invalid) || len(invalid.Problems) != 1 {
+		t.Fatalf("ImportCatalog = %v, want an alias problem", err)
+	}
+	if problem := invalid.Problems[0]; problem.Path != "ingredients[1].aliases[1]" || !strings.Contains(problem.Message, `"Tofu"`) {
+		t.Errorf("problem = %+v", problem)
+	}
+	if after := countCatalog(t, s); !reflect.DeepEqual(after, before) {
+		t.Errorf("a rejected import wrote rows: %v, before %v", after, before)
+	}
+}
+
+func TestCatalogExportImportsIntoAnotherDatabase(t *testing.T) {
+	source := newTestStore(t)
+	if _, err := source.ImportCatalog(services.Catalog{
+		Ingredients: []services.CatalogIngredient{{Name: "Rice", Category: "grain", Unit: "cup", Aliases: []string{"White Rice"}}},
+		Meals: []services.CatalogMeal{{
+			Name: "Rice Bowl", Servings: 2, MealType: "lunch", DietaryTags: []string{"vegan"}, Allergens: []string{},
+			Ingredients: []services.CatalogMealIngredient{{Name: "Rice", Quantity: 1, Unit: "cup", Note: "rinsed"}},
+		}},
+	}, false); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	exported, err := source.ExportCatalog()
+	if err != nil {
+		t.Fatalf("ExportCatalog: %v", err)
+	}
+
+	target := newTestStore(t)
+	if _, err := target.ImportCatalog(exported, false); err != nil {
+		t.Fatalf("ImportCatalog: %v", err)
+	}
+	again, err := target.ExportCatalog()
+	if err != nil {
+		t.Fatalf("ExportCatalog: %v", err)
+	}
+	if !reflect.DeepEqual(again, exported) {
+		t.Errorf("export after import = %+v, want %+v", again, exported)
+	}
+}
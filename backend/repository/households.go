@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"time"
+
+	"food-app/models"
+)
+
+// Membership loads the user's household membership
+func (s *Store) Membership(userID uint) (models.HouseholdMember, error) {
+	var membership models.HouseholdMember
+	err := first(s.db.Where("user_id = ?", userID), &membership)
+	return membership, err
+}
+
+// Household loads a household without its members
+func (s *Store) Household(id uint) (models.Household, error) {
+	var household models.Household
+	err := first(s.db, &household, id)
+	return household, err
+}
+
+// HouseholdWithMembers loads a household with its members and their users
+func (s *Store) HouseholdWithMembers(id uint) (models.Household, error) {
+	var household models.Household
+	err := first(s.db.Preload("Members").Preload("Members.User"), &household, id)
+	return household, err
+}
+
+// CreateHousehold inserts a household with its owner as the first member
+func (s *Store) CreateHousehold(household *models.Household) error {
+	if err := s.db.Create(household).Error; err != nil {
+		return err
+	}
+	return s.db.Create(&models.HouseholdMember{
+		HouseholdID: household.ID,
+		UserID:      household.OwnerID,
+		Role:        models.HouseholdRoleOwner,
+	}).Error
+}
+
+// DeleteHousehold removes a household with its members and invitations and
+// returns its shared plans to their owners
+func (s *Store) DeleteHousehold(householdID uint) error {
+	if err := s.UnshareHouseholdPlans(householdID); err != nil {
+		return err
+	}
+	if err := s.db.Where("household_id = ?", householdID).Delete(&models.HouseholdInvitation{}).Error; err != nil {
+		return err
+	}
+	if err := s.db.Where("household_id = ?", householdID).Delete(&models.HouseholdMember{}).Error; err != nil {
+		return err
+	}
+	return s.db.Delete(&models.Household{}, householdID).Error
+}
+
+// Member loads a user's membership of a household
+func (s *Store) Member(householdID, userID uint) (models.HouseholdMember, error) {
+	var member models.HouseholdMember
+	err := first(s.db.Where("household_id = ? AND user_id = ?", householdID, userID), &member)
+	return member, err
+}
+
+// SetMemberRole changes a member's role
+func (s *Store) SetMemberRole(member *models.HouseholdMember, role string) error {
+	return s.db.Model(member).Update("role", role).Error
+}
+
+// DeleteMember removes a member from their household
+func (s *Store) DeleteMember(member *models.HouseholdMember) error {
+	return s.db.Delete(member).Error
+}
+
+// CreateInvitation inserts an invitation
+func (s *Store) CreateInvitation(invitation *models.HouseholdInvitation) error {
+	return s.db.Create(invitation).Error
+}
+
+// PendingInvitations lists a household's pending invitations, newest first
+func (s *Store) PendingInvitations(householdID uint) ([]models.HouseholdInvitation, error) {
+	var invitations []models.HouseholdInvitation
+	err := s.db.Where("household_id = ? AND status = ?", householdID, models.InvitationPending).
+		Order("created_at DESC").Find(&invitations).Error
+	return invitations, err
+}
+
+// InvitationsFor lists the unexpired pending invitations addressed to an email,
+// newest first, with their households
+func (s *Store) InvitationsFor(email string) ([]models.HouseholdInvitation, error) {
+	var invitations []models.HouseholdInvitation
+	err := s.db.Preload("Household").
+		Where("LOWER(email) = LOWER(?) AND status = ? AND expires_at > ?", email, models.InvitationPending, time.Now()).
+		Order("created_at DESC").Find(&invitations).Error
+	return invitations, err
+}
+
+// PendingInvitation loads the pending invitation with a token addressed to an
+// email; it may have expired
+func (s *Store) PendingInvitation(token, email string) (models.HouseholdInvitation, error) {
+	var invitation models.HouseholdInvitation
+	err := first(s.db.Where("token = ? AND LOWER(email) = LOWER(?) AND status = ?", token, email, models.InvitationPending), &invitation)
+	return invitation, err
+}
+
+// AcceptInvitation adds the user to the inviting household with the invited role
+func (s *Store) AcceptInvitation(invitation *models.HouseholdInvitation, userID uint) error {
+	member := models.HouseholdMember{
+		HouseholdID: invitation.HouseholdID,
+		UserID:      userID,
+		Role:        invitation.Role,
+	}
+	if err := s.db.Create(&member).Error; err != nil {
+		return err
+	}
+	return s.SetInvitationStatus(invitation, models.InvitationAccepted)
+}
+
+// SetInvitationStatus marks an invitation accepted or declined
+func (s *Store) SetInvitationStatus(invitation *models.HouseholdInvitation, status string) error {
+	return s.db.Model(invitation).Update("status", status).Error
+}
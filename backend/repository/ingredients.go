@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"strings"
+
+	"food-app/ingredients"
+	"food-app/models"
+)
+
+// IngredientFilter narrows an ingredient search
+type IngredientFilter struct {
+	Query    string // part of the name or of an alias, ignoring case
+	Category string
+	Offset   int
+	Limit    int // 0 is no limit
+}
+
+// Ingredients lists the catalog ingredients matching filter by name, with their aliases
+func (s *Store) Ingredients(filter IngredientFilter) ([]models.Ingredient, error) {
+	query := s.db.Preload("Aliases")
+
+	if filter.Query != "" {
+		pattern := "%" + strings.ToLower(filter.Query) + "%"
+		query = query.Where("LOWER(name) LIKE ? OR id IN (?)", pattern,
+			s.db.Model(&models.IngredientAlias{}).Select("ingredient_id").Where("LOWER(name) LIKE ?", pattern))
+	}
+	if filter.Category != "" {
+		query = query.Where("category = ?", filter.Category)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var catalog []models.Ingredient
+	err := query.Order("name").Find(&catalog).Error
+	return catalog, err
+}
+
+// Ingredient loads a catalog ingredient with its aliases
+func (s *Store) Ingredient(id uint) (models.Ingredient, error) {
+	var ingredient models.Ingredient
+	err := first(s.db.Preload("Aliases"), &ingredient, id)
+	return ingredient, err
+}
+
+// IngredientNamed resolves a name to its canonical ingredient: the one with
+// that name or alias, ignoring case
+func (s *Store) IngredientNamed(name string) (models.Ingredient, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	var ingredient models.Ingredient
+	err := first(s.db.Preload("Aliases").Where("LOWER(name) = ?", name), &ingredient)
+	if err != ErrNotFound {
+		return ingredient, err
+	}
+
+	var alias models.IngredientAlias
+	if err := first(s.db.Where("LOWER(name) = ?", name), &alias); err != nil {
+		return ingredient, err
+	}
+	return s.Ingredient(alias.IngredientID)
+}
+
+// IngredientMatcher loads the ingredient catalog and its aliases for fuzzy name matching
+func (s *Store) IngredientMatcher() (*ingredients.Matcher, error) {
+	var catalog []models.Ingredient
+	if err := s.db.Preload("Aliases").Order("id").Find(&catalog).Error; err != nil {
+		return nil, err
+	}
+	return ingredients.NewMatcher(catalog), nil
+}
+
+// CreateIngredient inserts an ingredient
+func (s *Store) CreateIngredient(ingredient *models.Ingredient) error {
+	return s.db.Create(ingredient).Error
+}
+
+// UpdateIngredient updates the given columns of an ingredient
+func (s *Store) UpdateIngredient(ingredient *models.Ingredient, fields map[string]interface{}) error {
+	return s.db.Model(ingredient).Updates(fields).Error
+}
+
+// IngredientInUse reports whether any meal, shopping list or price refers to an ingredient
+func (s *Store) IngredientInUse(id uint) (bool, error) {
+	for _, model := range []interface{}{&models.MealIngredient{}, &models.ShoppingListItem{}, &models.IngredientPrice{}} {
+		var count int64
+		if err := s.db.Model(model).Where("ingredient_id = ?", id).Count(&count).Error; err != nil {
+			return false, err
+		}
+		if count > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// DeleteIngredient removes an ingredient and its aliases
+func (s *Store) DeleteIngredient(ingredient *models.Ingredient) error {
+	if err := s.db.Where("ingredient_id = ?", ingredient.ID).Delete(&models.IngredientAlias{}).Error; err != nil {
+		return err
+	}
+	return s.db.Delete(ingredient).Error
+}
+
+// IngredientAlias loads one of an ingredient's aliases
+func (s *Store) IngredientAlias(id, ingredientID uint) (models.IngredientAlias, error) {
+	var alias models.IngredientAlias
+	err := first(s.db.Where("id = ? AND ingredient_id = ?", id, ingredientID), &alias)
+	return alias, err
+}
+
+// CreateIngredientAlias inserts an alias
+func (s *Store) CreateIngredientAlias(alias *models.IngredientAlias) error {
+	return s.db.Create(alias).Error
+}
+
+// DeleteIngredientAlias removes an alias
+func (s *Store) DeleteIngredientAlias(alias *models.IngredientAlias) error {
+	return s.db.Delete(alias).Error
+}
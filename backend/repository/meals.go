@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"food-app/ingredients"
+	"food-app/models"
+)
+
+// Meal orderings for FindMeals
+const (
+	MealOrderPopular      = "popular"      // most liked first
+	MealOrderPersonalized = "personalized" // most liked first, shuffled among ties
+)
+
+// ingredientLines preloads a meal's ingredient lines with their ingredients,
+// one query for each; prefix it to preload the meals of another record
+const ingredientLines = "IngredientLines.Ingredient"
+
+// MealFilter narrows FindMeals; zero fields do not filter
+type MealFilter struct {
+	Cuisine          string
+	MealType         string
+	MealTypes        []string // any of these
+	Difficulty       string
+	MaxPrepTime      int
+	DietaryTags      []string // all of these
+	ExcludeAllergens []string
+	ExcludeIDs       []uint
+	Order            string
+	Offset           int
+	Limit            int // 0 is no limit
+}
+
+// FindMeals lists the meals matching filter with their ingredient lines
+func (s *Store) FindMeals(filter MealFilter) ([]models.Meal, error) {
+	query := s.db.Preload(ingredientLines)
+
+	if filter.Cuisine != "" {
+		query = query.Where("cuisine = ?", filter.Cuisine)
+	}
+	if filter.MealType != "" {
+		query = query.Where("meal_type = ?", filter.MealType)
+	}
+	if len(filter.MealTypes) > 0 {
+		query = query.Where("meal_type IN (?)", filter.MealTypes)
+	}
+	if filter.Difficulty != "" {
+		query = query.Where("difficulty = ?", filter.Difficulty)
+	}
+	if filter.MaxPrepTime > 0 {
+		query = query.Where("prep_time <= ?", filter.MaxPrepTime)
+	}
+	for _, tag := range filter.DietaryTags {
+		query = query.Where("? = ANY(dietary_tags)", tag)
+	}
+	for _, allergen := range filter.ExcludeAllergens {
+		query = query.Where("NOT (? = ANY(allergens))", allergen)
+	}
+	if len(filter.ExcludeIDs) > 0 {
+		query = query.Where("id NOT IN (?)", filter.ExcludeIDs)
+	}
+
+	switch filter.Order {
+	case MealOrderPopular:
+		query = query.Order("likes_count DESC")
+	case MealOrderPersonalized:
+		query = query.Order("likes_count DESC, RANDOM()")
+	}
+
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var meals []models.Meal
+	err := query.Find(&meals).Error
+	return meals, err
+}
+
+// Meal loads a meal with its ingredient lines
+func (s *Store) Meal(id uint) (models.Meal, error) {
+	var meal models.Meal
+	err := first(s.db.Preload(ingredientLines), &meal, id)
+	return meal, err
+}
+
+// MealBySourceURL loads the meal imported from a recipe page with its ingredient lines
+func (s *Store) MealBySourceURL(sourceURL string) (models.Meal, error) {
+	var meal models.Meal
+	err := first(s.db.Preload(ingredientLines).Where("source_url = ?", sourceURL), &meal)
+	return meal, err
+}
+
+// MealIngredients loads the ingredient lines of the given meals with their ingredients
+func (s *Store) MealIngredients(mealIDs []uint) ([]models.MealIngredient, error) {
+	var mealIngredients []models.MealIngredient
+	err := s.db.Preload("Ingredient").Where("meal_id IN (?)", mealIDs).Find(&mealIngredients).Error
+	return mealIngredients, err
+}
+
+// ReactToMeal records that a user liked or disliked a meal and refreshes its likes count
+func (s *Store) ReactToMeal(userID, mealID uint, liked bool) error {
+	var interaction models.UserMealInteraction
+	err := first(s.db.Where("user_id = ? AND meal_id = ?", userID, mealID), &interaction)
+	if err != nil && err != ErrNotFound {
+		return err
+	}
+
+	interaction.UserID = userID
+	interaction.MealID = mealID
+	interaction.Liked = liked
+	interaction.Disliked = !liked
+	if err := s.db.Save(&interaction).Error; err != nil {
+		return err
+	}
+
+	return s.db.Model(&models.Meal{}).Where("id = ?", mealID).
+		UpdateColumn("likes_count", s.db.Model(&models.UserMealInteraction{}).
+			Where("meal_id = ? AND liked = true", mealID).Select("count(*)")).Error
+}
+
+// LikedMeals loads the meals a user liked with their ingredient lines, skipping
+// meals deleted since
+func (s *Store) LikedMeals(userID uint) ([]models.Meal, error) {
+	var interactions []models.UserMealInteraction
+	if err := s.db.Preload("Meal."+ingredientLines).
+		Where("user_id = ? AND liked = true", userID).Find(&interactions).Error; err != nil {
+		return nil, err
+	}
+
+	meals := make([]models.Meal, 0, len(interactions))
+	for _, interaction := range interactions {
+		if interaction.Meal.ID != 0 {
+			meals = append(meals, interaction.Meal)
+		}
+	}
+	return meals, nil
+}
+
+// DislikedMealIDs lists the meals a user disliked
+func (s *Store) DislikedMealIDs(userID uint) ([]uint, error) {
+	var mealIDs []uint
+	err := s.db.Model(&models.UserMealInteraction{}).
+		Where("user_id = ? AND disliked = true", userID).Pluck("meal_id", &mealIDs).Error
+	return mealIDs, err
+}
+
+// CreateReview inserts a meal review
+func (s *Store) CreateReview(review *models.MealReview) error {
+	return s.db.Create(review).Error
+}
+
+// MealReviews lists a meal's reviews with their authors
+func (s *Store) MealReviews(mealID uint) ([]models.MealReview, error) {
+	var reviews []models.MealReview
+	err := s.db.Preload("User").Where("meal_id = ?", mealID).Find(&reviews).Error
+	return reviews, err
+}
+
+// CreateImportedMeal saves an imported recipe and links its ingredients, matching
+// names against the catalog and adding any it does not have yet. Lines naming the
+// same ingredient are combined, see addToLine. Ranges count at their top end.
+func (s *Store) CreateImportedMeal(meal *models.Meal, lines []ingredients.Line) error {
+	matcher, err := s.IngredientMatcher()
+	if err != nil {
+		return err
+	}
+	if err := s.db.Create(meal).Error; err != nil {
+		return err
+	}
+
+	linked := map[uint]*models.MealIngredient{}
+	var order []uint
+	for _, line := range lines {
+		if line.Name == "" {
+			continue
+		}
+
+		ingredient, err := s.findOrCreateIngredient(matcher, line.Name, line.Unit)
+		if err != nil {
+			return err
+		}
+
+		if existing, ok := linked[ingredient.ID]; ok {
+			addToLine(existing, line.PlanQuantity(), line.Unit, line.Note)
+			continue
+		}
+		linked[ingredient.ID] = &models.MealIngredient{
+			MealID:       meal.ID,
+			IngredientID: ingredient.ID,
+			Quantity:     line.PlanQuantity(),
+			Unit:         line.Unit,
+			Note:         line.Note,
+		}
+		order = append(order, ingredient.ID)
+	}
+
+	for _, ingredientID := range order {
+		if err := s.db.Create(linked[ingredientID]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addToLine folds another line for the same ingredient into line. Quantities in
+// the same unit add up; one in another unit cannot, so it is kept in the note
+// rather than dropped, e.g. "melted; also 2 tbsp".
+func addToLine(line *models.MealIngredient, quantity float64, unit, note string) {
+	if strings.EqualFold(strings.TrimSpace(line.Unit), strings.TrimSpace(unit)) {
+		line.Quantity += quantity
+		if note != "" && note != line.Note {
+			line.Note = joinNote(line.Note, note)
+		}
+		return
+	}
+
+	var extra []string
+	if quantity > 0 {
+		extra = append(extra, strconv.FormatFloat(quantity, 'f', -1, 64))
+	}
+	if unit != "" {
+		extra = append(extra, unit)
+	}
+	if note != "" {
+		extra = append(extra, note)
+	}
+	if len(extra) > 0 {
+		line.Note = joinNote(line.Note, "also "+strings.Join(extra, " "))
+	}
+}
+
+func joinNote(note, more string) string {
+	if note == "" {
+		return more
+	}
+	return note + "; " + more
+}
+
+// findOrCreateIngredient returns the catalog ingredient matching name, creating it
+// (and adding it to the matcher) when there is none
+func (s *Store) findOrCreateIngredient(matcher *ingredients.Matcher, name, unit string) (models.Ingredient, error) {
+	if ingredient, ok := matcher.Match(name); ok {
+		return ingredient, nil
+	}
+
+	first, size := utf8.DecodeRuneInString(name)
+	ingredient := models.Ingredient{Name: string(unicode.ToUpper(first)) + name[size:], Unit: unit}
+	if err := s.db.Create(&ingredient).Error; err != nil {
+		return ingredient, err
+	}
+	matcher.Add(ingredient)
+	return ingredient, nil
+}
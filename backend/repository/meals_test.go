@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"testing"
+
+	"food-app/ingredients"
+	"food-app/models"
+)
+
+func TestMealIngredientLinesAreKeyed(t *testing.T) {
+	s := newTestStore(t)
+	meal := seedMeal(t, s, "Stir Fry", "Rice", "Broccoli")
+
+	lines, err := s.MealIngredients([]uint{meal.ID})
+	if err != nil || len(lines) != 2 {
+		t.Fatalf("MealIngredients = %d lines, %v", len(lines), err)
+	}
+
+	duplicate := models.MealIngredient{MealID: meal.ID, IngredientID: lines[0].IngredientID, Quantity: 9}
+	if err := s.db.Create(&duplicate).Error; err == nil {
+		t.Error("a second line for the same ingredient was inserted")
+	}
+
+	rice := lines[0]
+	rice.Quantity = 5
+	rice.Note = "rinsed"
+	if err := s.db.Omit("Ingredient").Save(&rice).Error; err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := s.db.Delete(&lines[1]).Error; err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	lines, err = s.MealIngredients([]uint{meal.ID})
+	if err != nil {
+		t.Fatalf("MealIngredients: %v", err)
+	}
+	if len(lines) != 1 || lines[0].IngredientID != rice.IngredientID || lines[0].Quantity != 5 || lines[0].Note != "rinsed" {
+		t.Errorf("lines after save and delete = %+v, want only the updated rice line", lines)
+	}
+}
+
+func TestImportedMealKeepsLinesInOtherUnits(t *testing.T) {
+	s := newTestStore(t)
+
+	meal := models.Meal{Name: "Shortbread", MealType: "snack"}
+	err := s.CreateImportedMeal(&meal, []ingredients.Line{
+		{Quantity: 2, Unit: "tbsp", Name: "butter", Note: "melted"},
+		{Quantity: 50, Unit: "g", Name: "Butter", Note: "cold"},
+		{Quantity: 1, Unit: "tbsp", Name: "butter"},
+		{Name: "salt"},
+		{Quantity: 1, Unit: "pinch", Name: "salt"},
+	})
+	if err != nil {
+		t.Fatalf("CreateImportedMeal: %v", err)
+	}
+
+	lines, err := s.MealIngredients([]uint{meal.ID})
+	if err != nil || len(lines) != 2 {
+		t.Fatalf("MealIngredients = %+v, %v", lines, err)
+	}
+	butter, salt := lines[0], lines[1]
+	if butter.Quantity != 3 || butter.Unit != "tbsp" || butter.Note != "melted; also 50 g cold" {
+		t.Errorf("butter = %g %s %q, want 3 tbsp noting the 50 g", butter.Quantity, butter.Unit, butter.Note)
+	}
+	if salt.Quantity != 0 || salt.Note != "also 1 pinch" {
+		t.Errorf("salt = %g %s %q, want the pinch in the note", salt.Quantity, salt.Unit, salt.Note)
+	}
+}
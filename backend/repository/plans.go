@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"errors"
+
+	"food-app/models"
+	"food-app/services"
+)
+
+// Plan loads a plan without its entries
+func (s *Store) Plan(id uint) (models.MealPlan, error) {
+	var mealPlan models.MealPlan
+	err := first(s.db, &mealPlan, id)
+	return mealPlan, err
+}
+
+// PlanWithMeals loads a plan with its entries and their meals and ingredient lines
+func (s *Store) PlanWithMeals(id uint) (models.MealPlan, error) {
+	var mealPlan models.MealPlan
+	err := first(s.db.Preload("Meals.Meal."+ingredientLines), &mealPlan, id)
+	return mealPlan, err
+}
+
+// PlanWithShoppingList loads a plan as PlanWithMeals does, plus its shopping list and items
+func (s *Store) PlanWithShoppingList(id uint) (models.MealPlan, error) {
+	var mealPlan models.MealPlan
+	err := first(s.db.Preload("Meals.Meal."+ingredientLines).
+		Preload("ShoppingList").Preload("ShoppingList.Items").Preload("ShoppingList.Items.Ingredient"), &mealPlan, id)
+	return mealPlan, err
+}
+
+// UserPlans lists the plans a user owns with their entries' meals, newest first
+func (s *Store) UserPlans(userID uint) ([]models.MealPlan, error) {
+	var mealPlans []models.MealPlan
+	err := s.db.Preload("Meals.Meal."+ingredientLines).
+		Where("user_id = ?", userID).Order("created_at DESC").Find(&mealPlans).Error
+	return mealPlans, err
+}
+
+// Entry loads a plan entry with its meal
+func (s *Store) Entry(id uint) (models.MealPlanEntry, error) {
+	var entry models.MealPlanEntry
+	err := first(s.db.Preload("Meal."+ingredientLines), &entry, id)
+	return entry, err
+}
+
+// EntryInSlot loads the entry planned for a day and meal type
+func (s *Store) EntryInSlot(mealPlanID uint, day, mealType string) (models.MealPlanEntry, error) {
+	var entry models.MealPlanEntry
+	err := first(s.db.Where("meal_plan_id = ? AND day = ? AND meal_type = ?", mealPlanID, day, mealType), &entry)
+	return entry, err
+}
+
+// CreatePlan inserts a plan
+func (s *Store) CreatePlan(mealPlan *models.MealPlan) error {
+	return s.db.Create(mealPlan).Error
+}
+
+// UpdatePlan updates the given columns of a plan
+func (s *Store) UpdatePlan(mealPlan *models.MealPlan, fields map[string]interface{}) error {
+	return s.db.Model(mealPlan).Updates(fields).Error
+}
+
+// ActivatePlan points the plan owner's active plan at mealPlan and keeps IsActive in step
+func (s *Store) ActivatePlan(mealPlan models.MealPlan) error {
+	if err := s.db.Model(&models.User{}).Where("id = ?", mealPlan.UserID).
+		UpdateColumn("active_meal_plan_id", mealPlan.ID).Error; err != nil {
+		return err
+	}
+	if err := s.db.Model(&models.MealPlan{}).Where("user_id = ? AND id <> ?", mealPlan.UserID, mealPlan.ID).
+		UpdateColumn("is_active", false).Error; err != nil {
+		return err
+	}
+	return s.db.Model(&models.MealPlan{}).Where("id = ?", mealPlan.ID).UpdateColumn("is_active", true).Error
+}
+
+// SharePlan sets the household a plan and its shopping list are shared with; nil unshares them
+func (s *Store) SharePlan(mealPlanID uint, householdID *uint) error {
+	if err := s.db.Model(&models.MealPlan{}).Where("id = ?", mealPlanID).
+		UpdateColumn("household_id", householdID).Error; err != nil {
+		return err
+	}
+	return s.db.Model(&models.ShoppingList{}).Where("meal_plan_id = ?", mealPlanID).
+		UpdateColumn("household_id", householdID).Error
+}
+
+// UnshareHouseholdPlans returns every plan and list shared with a household to its owner
+func (s *Store) UnshareHouseholdPlans(householdID uint) error {
+	if err := s.db.Model(&models.MealPlan{}).Where("household_id = ?", householdID).
+		UpdateColumn("household_id", nil).Error; err != nil {
+		return err
+	}
+	return s.db.Model(&models.ShoppingList{}).Where("household_id = ?", householdID).
+		UpdateColumn("household_id", nil).Error
+}
+
+// DeletePlan removes a plan with its entries and shopping list, clearing it as anyone's active plan
+func (s *Store) DeletePlan(mealPlan *models.MealPlan) error {
+	if err := s.db.Model(&models.User{}).Where("active_meal_plan_id = ?", mealPlan.ID).
+		UpdateColumn("active_meal_plan_id", nil).Error; err != nil {
+		return err
+	}
+	if err := s.db.Where("meal_plan_id = ?", mealPlan.ID).Delete(&models.MealPlanEntry{}).Error; err != nil {
+		return err
+	}
+	if err := s.deleteShoppingLists(mealPlan.ID); err != nil {
+		return err
+	}
+	return s.db.Delete(mealPlan).Error
+}
+
+// ClearEntries deletes a plan's entries except those in keepIDs
+func (s *Store) ClearEntries(mealPlanID uint, keepIDs []uint) error {
+	query := s.db.Where("meal_plan_id = ?", mealPlanID)
+	if len(keepIDs) > 0 {
+		query = query.Where("id NOT IN (?)", keepIDs)
+	}
+	return query.Delete(&models.MealPlanEntry{}).Error
+}
+
+// ErrLockedLeftover is returned when clearing a slot would delete a locked leftover of its cook
+var ErrLockedLeftover = errors.New("a locked leftover depends on this slot")
+
+// ClearSlot removes the entry in a slot along with any leftover entries it cooks for.
+// It returns ErrLockedLeftover, deleting nothing, if one of those leftovers is locked.
+func (s *Store) ClearSlot(mealPlanID uint, day, mealType string) error {
+	var entryIDs []uint
+	if err := s.db.Model(&models.MealPlanEntry{}).
+		Where("meal_plan_id = ? AND day = ? AND meal_type = ?", mealPlanID, day, mealType).
+		Pluck("id", &entryIDs).Error; err != nil {
+		return err
+	}
+
+	if len(entryIDs) == 0 {
+		return nil
+	}
+	var lockedLeftovers int64
+	if err := s.db.Model(&models.MealPlanEntry{}).
+		Where("leftover_of_id IN (?) AND locked = ?", entryIDs, true).
+		Count(&lockedLeftovers).Error; err != nil {
+		return err
+	}
+	if lockedLeftovers > 0 {
+		return ErrLockedLeftover
+	}
+	if err := s.db.Where("leftover_of_id IN (?)", entryIDs).Delete(&models.MealPlanEntry{}).Error; err != nil {
+		return err
+	}
+	return s.db.Where("id IN (?)", entryIDs).Delete(&models.MealPlanEntry{}).Error
+}
+
+// CreateEntry adds an entry to a plan
+func (s *Store) CreateEntry(entry *models.MealPlanEntry) error {
+	return s.db.Create(entry).Error
+}
+
+// SetEntryLocked locks or unlocks an entry
+func (s *Store) SetEntryLocked(entry *models.MealPlanEntry, locked bool) error {
+	return s.db.Model(entry).Update("locked", locked).Error
+}
+
+// SetEntryAbsentMembers records which household members will not eat an entry
+func (s *Store) SetEntryAbsentMembers(entry *models.MealPlanEntry, usernames []string) error {
+	return s.db.Model(entry).Update("absent_members", models.StringArray(usernames)).Error
+}
+
+// SavePlannedMeals stores generated meals as plan entries, linking leftovers to their cook.
+// Slots in existing are already stored under the given entry IDs and are skipped.
+func (s *Store) SavePlannedMeals(mealPlanID uint, planned []services.PlannedMeal, existing map[services.PlanSlot]uint) error {
+	entryIDs := map[services.PlanSlot]uint{}
+	for slot, id := range existing {
+		entryIDs[slot] = id
+	}
+
+	for _, meal := range planned {
+		if _, stored := existing[meal.PlanSlot]; stored {
+			continue
+		}
+
+		entry := models.MealPlanEntry{
+			MealPlanID: mealPlanID,
+			MealID:     meal.Meal.ID,
+			Day:        meal.Day,
+			MealType:   meal.MealType,
+			Servings:   1,
+		}
+		if meal.LeftoverOf != nil {
+			if sourceID, ok := entryIDs[*meal.LeftoverOf]; ok {
+				entry.LeftoverOfID = &sourceID
+			}
+		}
+
+		if err := s.db.Create(&entry).Error; err != nil {
+			return err
+		}
+		entryIDs[meal.PlanSlot] = entry.ID
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"food-app/models"
+
+	"gorm.io/gorm"
+)
+
+// ProviderUsage counts external recipe provider requests in the database. It
+// implements services.UsageStore, so daily quotas hold across restarts.
+type ProviderUsage struct {
+	db *gorm.DB
+}
+
+// NewProviderUsage wraps a database handle
+func NewProviderUsage(db *gorm.DB) *ProviderUsage {
+	return &ProviderUsage{db: db}
+}
+
+// Reserve counts one request to provider on day unless limit were already made
+func (s *ProviderUsage) Reserve(provider, day string, limit int) (bool, error) {
+	// The conditional update is atomic, so concurrent requests cannot overshoot
+	for attempt := 0; attempt < 2; attempt++ {
+		result := s.db.Model(&models.ProviderRequestCount{}).
+			Where("provider = ? AND day = ? AND requests < ?", provider, day, limit).
+			Updates(map[string]interface{}{"requests": gorm.Expr("requests + 1"), "updated_at": time.Now()})
+		if result.Error != nil {
+			return false, result.Error
+		}
+		if result.RowsAffected == 1 {
+			return true, nil
+		}
+
+		var count int64
+		if err := s.db.Model(&models.ProviderRequestCount{}).Where("provider = ? AND day = ?", provider, day).Count(&count).Error; err != nil {
+			return false, err
+		}
+		if count > 0 || limit <= 0 {
+			return false, nil // today's quota is used up
+		}
+		// First request of the day. A concurrent insert trips the unique index,
+		// and the next attempt then takes the update path.
+		if err := s.db.Create(&models.ProviderRequestCount{Provider: provider, Day: day, Requests: 1}).Error; err == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Used returns the requests counted for provider on day
+func (s *ProviderUsage) Used(provider, day string) (int, error) {
+	var counter models.ProviderRequestCount
+	err := s.db.Where("provider = ? AND day = ?", provider, day).First(&counter).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	return counter.Requests, err
+}
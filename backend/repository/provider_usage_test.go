@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"food-app/database"
+
+	"gorm.io/driver/sqlite"
+)
+
+func TestProviderUsageReservesUnderConcurrency(t *testing.T) {
+	// A file database, so concurrent reservations use separate connections and
+	// race to insert the day's first count
+	path := filepath.Join(t.TempDir(), "usage.db")
+	db, err := database.Open(sqlite.Open("file:" + path + "?_busy_timeout=5000"))
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(8)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := database.AutoMigrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	usage := NewProviderUsage(db)
+
+	const day, limit = "2026-10-19", 5
+	var mu sync.Mutex
+	granted := 0
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := usage.Reserve("spoonacular", day, limit)
+			if err != nil {
+				t.Errorf("Reserve: %v", err)
+			}
+			if ok {
+				mu.Lock()
+				granted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	used, err := usage.Used("spoonacular", day)
+	if err != nil || granted != limit || used != limit {
+		t.Errorf("granted %d and counted %d (%v) of a quota of %d", granted, used, err, limit)
+	}
+
+	// Counts are per provider and per day
+	if ok, err := usage.Reserve("spoonacular", "2026-10-20", limit); !ok || err != nil {
+		t.Errorf("Reserve the next day = %v, %v", ok, err)
+	}
+	if ok, err := usage.Reserve("edamam", day, limit); !ok || err != nil {
+		t.Errorf("Reserve for another provider = %v, %v", ok, err)
+	}
+	if used, _ := usage.Used("themealdb", day); used != 0 {
+		t.Errorf("an unused provider counted %d", used)
+	}
+}
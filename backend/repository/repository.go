@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+
+	"food-app/ingredients"
+	"food-app/models"
+	"food-app/services"
+)
+
+// Lookups of a single record return ErrNotFound when nothing matches.
+
+// UserRepository reads and writes user accounts and their linked identities
+type UserRepository interface {
+	User(id uint) (models.User, error)
+	UserByEmail(email string) (models.User, error)
+	UserExists(email, username string) (bool, error)
+	UsernameTaken(username string) (bool, error)
+	CreateUser(user *models.User) error
+	UpdateUser(user *models.User, fields map[string]interface{}) error
+
+	Identities(userID uint) ([]models.UserIdentity, error)
+	UserIdentity(id, userID uint) (models.UserIdentity, error)
+	IdentityBySubject(provider, subject string) (models.UserIdentity, error)
+	CountIdentities(userID uint) (int64, error)
+	CreateIdentity(identity *models.UserIdentity) error
+	TouchIdentity(identity *models.UserIdentity, email string, emailVerified bool) error
+	DeleteIdentity(identity *models.UserIdentity) error
+}
+
+// MealRepository reads and writes meals and what users think of them
+type MealRepository interface {
+	FindMeals(filter MealFilter) ([]models.Meal, error)
+	Meal(id uint) (models.Meal, error)
+	MealBySourceURL(sourceURL string) (models.Meal, error)
+	MealIngredients(mealIDs []uint) ([]models.MealIngredient, error)
+	CreateImportedMeal(meal *models.Meal, lines []ingredients.Line) error
+
+	ReactToMeal(userID, mealID uint, liked bool) error
+	LikedMeals(userID uint) ([]models.Meal, error)
+	DislikedMealIDs(userID uint) ([]uint, error)
+	CreateReview(review *models.MealReview) error
+	MealReviews(mealID uint) ([]models.MealReview, error)
+}
+
+// IngredientRepository reads and writes the ingredient catalog and the aliases
+// names resolve through
+type IngredientRepository interface {
+	Ingredients(filter IngredientFilter) ([]models.Ingredient, error)
+	Ingredient(id uint) (models.Ingredient, error)
+	IngredientNamed(name string) (models.Ingredient, error)
+	IngredientMatcher() (*ingredients.Matcher, error)
+	CreateIngredient(ingredient *models.Ingredient) error
+	UpdateIngredient(ingredient *models.Ingredient, fields map[string]interface{}) error
+	IngredientInUse(id uint) (bool, error)
+	DeleteIngredient(ingredient *models.Ingredient) error
+
+	IngredientAlias(id, ingredientID uint) (models.IngredientAlias, error)
+	CreateIngredientAlias(alias *models.IngredientAlias) error
+	DeleteIngredientAlias(alias *models.IngredientAlias) error
+}
+
+// CatalogRepository bulk exports and imports meals and ingredients
+type CatalogRepository interface {
+	ExportCatalog() (services.Catalog, error)
+	ImportCatalog(catalog services.Catalog, dryRun bool) (CatalogImportResult, error)
+}
+
+// PlanRepository reads and writes meal plans and their entries
+type PlanRepository interface {
+	Plan(id uint) (models.MealPlan, error)
+	PlanWithMeals(id uint) (models.MealPlan, error)
+	PlanWithShoppingList(id uint) (models.MealPlan, error)
+	UserPlans(userID uint) ([]models.MealPlan, error)
+	CreatePlan(mealPlan *models.MealPlan) error
+	UpdatePlan(mealPlan *models.MealPlan, fields map[string]interface{}) error
+	ActivatePlan(mealPlan models.MealPlan) error
+	SharePlan(mealPlanID uint, householdID *uint) error
+	DeletePlan(mealPlan *models.MealPlan) error
+
+	Entry(id uint) (models.MealPlanEntry, error)
+	EntryInSlot(mealPlanID uint, day, mealType string) (models.MealPlanEntry, error)
+	CreateEntry(entry *models.MealPlanEntry) error
+	SetEntryLocked(entry *models.MealPlanEntry, locked bool) error
+	SetEntryAbsentMembers(entry *models.MealPlanEntry, usernames []string) error
+	ClearEntries(mealPlanID uint, keepIDs []uint) error
+	ClearSlot(mealPlanID uint, day, mealType string) error
+	SavePlannedMeals(mealPlanID uint, planned []services.PlannedMeal, existing map[services.PlanSlot]uint) error
+}
+
+// ShoppingListRepository reads and writes shopping lists and ingredient prices
+type ShoppingListRepository interface {
+	ShoppingListForPlan(mealPlanID uint) (models.ShoppingList, error)
+	ShoppingList(id uint) (models.ShoppingList, error)
+	ShoppingLists(userID uint, householdID *uint) ([]models.ShoppingList, error)
+	AccessibleShoppingItem(itemID, userID uint, householdID *uint) (models.ShoppingListItem, error)
+	SyncShoppingList(mealPlanID uint) error
+	AddManualItem(mealPlanID uint, item *models.ShoppingListItem) error
+	UpdateShoppingItem(item *models.ShoppingListItem, fields map[string]interface{}) error
+	DeleteShoppingItem(item *models.ShoppingListItem) error
+
+	CreatePrice(price *models.IngredientPrice) error
+	IngredientPrices(ingredientID uint, store string) ([]models.IngredientPrice, error)
+	LatestPrices(ingredientIDs []uint, store string) (map[uint]models.IngredientPrice, error)
+}
+
+// StoreLayoutRepository reads and writes the store layouts shopping lists are walked in
+type StoreLayoutRepository interface {
+	StoreLayouts(userID uint) ([]models.StoreLayout, error)
+	StoreLayout(id, userID uint) (models.StoreLayout, error)
+	SaveStoreLayout(layout *models.StoreLayout, aisles []models.StoreAisle) error
+	DeleteStoreLayout(layout *models.StoreLayout) error
+}
+
+// HouseholdRepository reads and writes households, their members and invitations
+type HouseholdRepository interface {
+	Membership(userID uint) (models.HouseholdMember, error)
+	Household(id uint) (models.Household, error)
+	HouseholdWithMembers(id uint) (models.Household, error)
+	CreateHousehold(household *models.Household) error
+	DeleteHousehold(householdID uint) error
+	Member(householdID, userID uint) (models.HouseholdMember, error)
+	SetMemberRole(member *models.HouseholdMember, role string) error
+	DeleteMember(member *models.HouseholdMember) error
+
+	CreateInvitation(invitation *models.HouseholdInvitation) error
+	PendingInvitations(householdID uint) ([]models.HouseholdInvitation, error)
+	InvitationsFor(email string) ([]models.HouseholdInvitation, error)
+	PendingInvitation(token, email string) (models.HouseholdInvitation, error)
+	AcceptInvitation(invitation *models.HouseholdInvitation, userID uint) error
+	SetInvitationStatus(invitation *models.HouseholdInvitation, status string) error
+}
+
+// CalendarRepository reads and writes calendar feed settings
+type CalendarRepository interface {
+	CalendarFeed(userID uint) (models.CalendarFeed, error)
+	CalendarFeedByToken(token string) (models.CalendarFeed, error)
+	CreateCalendarFeed(feed *models.CalendarFeed) error
+	UpdateCalendarFeed(feed *models.CalendarFeed, fields map[string]interface{}) error
+}
+
+// Repository is everything the handlers read and write. Store implements it;
+// WithContext binds its queries to a request's context, and Transaction hands
+// fn a Repository whose every method runs in one transaction.
+type Repository interface {
+	UserRepository
+	MealRepository
+	IngredientRepository
+	CatalogRepository
+	PlanRepository
+	ShoppingListRepository
+	StoreLayoutRepository
+	HouseholdRepository
+	CalendarRepository
+
+	WithContext(ctx context.Context) Repository
+	Transaction(fn func(tx Repository) error) error
+}
+
+var _ Repository = (*Store)(nil)
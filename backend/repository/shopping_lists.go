@@ -0,0 +1,303 @@
+package repository
+
+import (
+	"food-app/models"
+	"food-app/services"
+)
+
+// itemKey identifies a shopping list line: quantities are summed per ingredient and unit
+type itemKey struct {
+	ingredientID uint
+	unit         string
+}
+
+// SyncShoppingList reconciles a plan's shopping list with its entries. Quantities
+// are recomputed and existing items updated in place, so notes survive plan changes
+// and purchase state survives them unless the plan now needs more than was bought,
+// in which case the item goes back on the list. Items no longer called for are
+// removed, unless they were already bought; those stay on the list flagged
+// NoLongerNeeded. Leftover entries are covered by their cook, which is scaled to
+// feed them.
+func (s *Store) SyncShoppingList(mealPlanID uint) error {
+	var mealPlan models.MealPlan
+	if err := s.db.Preload("Meals.Meal.IngredientLines").Where("id = ?", mealPlanID).First(&mealPlan).Error; err != nil {
+		return err
+	}
+
+	needed := neededQuantities(mealPlan)
+
+	shoppingList, err := s.shoppingListFor(mealPlan)
+	if err != nil {
+		return err
+	}
+
+	// Manual items are the user's own and never reconciled
+	var items []models.ShoppingListItem
+	if err := s.db.Where("shopping_list_id = ? AND manual = ?", shoppingList.ID, false).Order("id").Find(&items).Error; err != nil {
+		return err
+	}
+
+	matched := map[itemKey]bool{}
+	for _, item := range items {
+		key := itemKey{item.IngredientID, item.Unit}
+		quantity, stillNeeded := needed[key]
+
+		switch {
+		case stillNeeded && !matched[key]:
+			matched[key] = true
+			if item.Quantity == quantity && !item.NoLongerNeeded {
+				continue
+			}
+			updates := map[string]interface{}{
+				"quantity":         quantity,
+				"no_longer_needed": false,
+			}
+			if item.IsPurchased && quantity > item.Quantity {
+				updates["is_purchased"] = false
+			}
+			if err := s.db.Model(&item).Updates(updates).Error; err != nil {
+				return err
+			}
+		case item.IsPurchased:
+			if item.NoLongerNeeded {
+				continue
+			}
+			if err := s.db.Model(&item).Update("no_longer_needed", true).Error; err != nil {
+				return err
+			}
+		default:
+			if err := s.db.Delete(&item).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	for key, quantity := range needed {
+		if matched[key] {
+			continue
+		}
+		item := models.ShoppingListItem{
+			ShoppingListID: shoppingList.ID,
+			IngredientID:   key.ingredientID,
+			Quantity:       quantity,
+			Unit:           key.unit,
+		}
+		if err := s.db.Create(&item).Error; err != nil {
+			return err
+		}
+	}
+
+	return s.ApplyShoppingListCosts(shoppingList.ID)
+}
+
+// AddManualItem adds a free-text item to the plan's shopping list, creating the list if needed
+func (s *Store) AddManualItem(mealPlanID uint, item *models.ShoppingListItem) error {
+	var mealPlan models.MealPlan
+	if err := s.db.Where("id = ?", mealPlanID).First(&mealPlan).Error; err != nil {
+		return err
+	}
+
+	shoppingList, err := s.shoppingListFor(mealPlan)
+	if err != nil {
+		return err
+	}
+
+	item.ShoppingListID = shoppingList.ID
+	item.IngredientID = 0
+	item.Manual = true
+	return s.db.Create(item).Error
+}
+
+// ShoppingListForPlan loads a plan's shopping list with its items and their ingredients
+func (s *Store) ShoppingListForPlan(mealPlanID uint) (models.ShoppingList, error) {
+	var shoppingList models.ShoppingList
+	err := first(s.db.Preload("Items").Preload("Items.Ingredient").Where("meal_plan_id = ?", mealPlanID), &shoppingList)
+	return shoppingList, err
+}
+
+// ShoppingList loads a shopping list without its items
+func (s *Store) ShoppingList(id uint) (models.ShoppingList, error) {
+	var shoppingList models.ShoppingList
+	err := first(s.db, &shoppingList, id)
+	return shoppingList, err
+}
+
+// ShoppingLists lists the lists a user owns or that are shared with their
+// household (nil when they have none), newest first
+func (s *Store) ShoppingLists(userID uint, householdID *uint) ([]models.ShoppingList, error) {
+	query := s.db.Preload("Items").Preload("Items.Ingredient")
+	if householdID != nil {
+		query = query.Where("user_id = ? OR household_id = ?", userID, *householdID)
+	} else {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var shoppingLists []models.ShoppingList
+	err := query.Order("created_at DESC").Find(&shoppingLists).Error
+	return shoppingLists, err
+}
+
+// AccessibleShoppingItem loads an item from a list the user owns or that is
+// shared with their household (nil when they have none)
+func (s *Store) AccessibleShoppingItem(itemID, userID uint, householdID *uint) (models.ShoppingListItem, error) {
+	query := s.db.Joins("JOIN shopping_lists ON shopping_list_items.shopping_list_id = shopping_lists.id")
+	if householdID != nil {
+		query = query.Where("shopping_list_items.id = ? AND (shopping_lists.user_id = ? OR shopping_lists.household_id = ?)",
+			itemID, userID, *householdID)
+	} else {
+		query = query.Where("shopping_list_items.id = ? AND shopping_lists.user_id = ?", itemID, userID)
+	}
+
+	var item models.ShoppingListItem
+	err := first(query, &item)
+	return item, err
+}
+
+// DeleteShoppingItem removes an item from its list
+func (s *Store) DeleteShoppingItem(item *models.ShoppingListItem) error {
+	return s.db.Delete(item).Error
+}
+
+// shoppingListFor returns the plan's shopping list, creating an empty one if it has none
+func (s *Store) shoppingListFor(mealPlan models.MealPlan) (models.ShoppingList, error) {
+	var shoppingList models.ShoppingList
+	err := first(s.db.Where("meal_plan_id = ?", mealPlan.ID).Order("id"), &shoppingList)
+	if err != ErrNotFound {
+		return shoppingList, err
+	}
+
+	shoppingList = models.ShoppingList{
+		UserID:      mealPlan.UserID,
+		HouseholdID: mealPlan.HouseholdID,
+		MealPlanID:  mealPlan.ID,
+		Name:        "Week of " + mealPlan.WeekStart.Format("Jan 2, 2006"),
+	}
+	err = s.db.Create(&shoppingList).Error
+	return shoppingList, err
+}
+
+// neededQuantities sums the ingredient lines of every cooked entry, scaled to its headcount
+func neededQuantities(mealPlan models.MealPlan) map[itemKey]float64 {
+	needed := map[itemKey]float64{}
+
+	cookScales := models.CookScales(mealPlan.Meals, mealPlan.HouseholdSize)
+	for _, entry := range mealPlan.Meals {
+		scale, cooked := cookScales[entry.ID]
+		if !cooked {
+			continue
+		}
+
+		for _, mealIngredient := range entry.Meal.IngredientLines {
+			needed[itemKey{mealIngredient.IngredientID, mealIngredient.Unit}] += mealIngredient.Quantity * scale
+		}
+	}
+	return needed
+}
+
+// ApplyShoppingListCosts prices every item of a list and stores the total of the items still needed
+func (s *Store) ApplyShoppingListCosts(shoppingListID uint) error {
+	var items []models.ShoppingListItem
+	if err := s.db.Where("shopping_list_id = ?", shoppingListID).Find(&items).Error; err != nil {
+		return err
+	}
+
+	ingredientIDs := make([]uint, 0, len(items))
+	for _, item := range items {
+		ingredientIDs = append(ingredientIDs, item.IngredientID)
+	}
+	prices, err := s.LatestPrices(ingredientIDs, "")
+	if err != nil {
+		return err
+	}
+
+	total := 0.0
+	for _, item := range items {
+		var estimated *float64
+		if price, known := prices[item.IngredientID]; known {
+			if cost, ok := PriceFor(item.Quantity, item.Unit, price); ok {
+				estimated = &cost
+				// Leftover purchases are kept for reference but not part of the plan's cost
+				if !item.NoLongerNeeded {
+					total += cost
+				}
+			}
+		}
+		if err := s.db.Model(&item).UpdateColumn("estimated_cost", estimated).Error; err != nil {
+			return err
+		}
+	}
+
+	return s.db.Model(&models.ShoppingList{}).Where("id = ?", shoppingListID).
+		UpdateColumn("estimated_total", total).Error
+}
+
+// UpdateShoppingItem updates the given columns of a shopping list item
+func (s *Store) UpdateShoppingItem(item *models.ShoppingListItem, fields map[string]interface{}) error {
+	return s.db.Model(item).Updates(fields).Error
+}
+
+// CreatePrice records an observed ingredient price
+func (s *Store) CreatePrice(price *models.IngredientPrice) error {
+	return s.db.Create(price).Error
+}
+
+// IngredientPrices lists an ingredient's prices, newest first, optionally at one store
+func (s *Store) IngredientPrices(ingredientID uint, store string) ([]models.IngredientPrice, error) {
+	query := s.db.Where("ingredient_id = ?", ingredientID)
+	if store != "" {
+		query = query.Where("store = ?", store)
+	}
+
+	var prices []models.IngredientPrice
+	err := query.Order("observed_at DESC").Find(&prices).Error
+	return prices, err
+}
+
+// LatestPrices returns the most recent price per ingredient, preferring the given
+// store. Prices in currencies other than models.PriceCurrency are ignored.
+func (s *Store) LatestPrices(ingredientIDs []uint, store string) (map[uint]models.IngredientPrice, error) {
+	prices := map[uint]models.IngredientPrice{}
+	if len(ingredientIDs) == 0 {
+		return prices, nil
+	}
+
+	var all []models.IngredientPrice
+	if err := s.db.Where("ingredient_id IN (?) AND currency = ?", ingredientIDs, models.PriceCurrency).Order("observed_at DESC, id DESC").Find(&all).Error; err != nil {
+		return nil, err
+	}
+
+	for _, price := range all {
+		current, seen := prices[price.IngredientID]
+		switch {
+		case !seen:
+			prices[price.IngredientID] = price
+		case store != "" && current.Store != store && price.Store == store:
+			prices[price.IngredientID] = price
+		}
+	}
+	return prices, nil
+}
+
+// PriceFor converts a quantity into the price's unit and returns its cost
+func PriceFor(quantity float64, unit string, price models.IngredientPrice) (float64, bool) {
+	converted, ok := services.ConvertQuantity(quantity, unit, price.Unit)
+	if !ok {
+		return 0, false
+	}
+	return converted * price.UnitPrice(), true
+}
+
+// deleteShoppingLists removes a plan's shopping lists and their items
+func (s *Store) deleteShoppingLists(mealPlanID uint) error {
+	var listIDs []uint
+	if err := s.db.Model(&models.ShoppingList{}).Where("meal_plan_id = ?", mealPlanID).Pluck("id", &listIDs).Error; err != nil {
+		return err
+	}
+	if len(listIDs) == 0 {
+		return nil
+	}
+	if err := s.db.Where("shopping_list_id IN (?)", listIDs).Delete(&models.ShoppingListItem{}).Error; err != nil {
+		return err
+	}
+	return s.db.Where("id IN (?)", listIDs).Delete(&models.ShoppingList{}).Error
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"testing"
+
+	"food-app/models"
+)
+
+// riceItem syncs the plan's list and returns its one rice line
+func riceItem(t *testing.T, s *Store, mealPlanID uint) models.ShoppingListItem {
+	t.Helper()
+
+	if err := s.SyncShoppingList(mealPlanID); err != nil {
+		t.Fatalf("SyncShoppingList: %v", err)
+	}
+	list, err := s.ShoppingListForPlan(mealPlanID)
+	if err != nil {
+		t.Fatalf("ShoppingListForPlan: %v", err)
+	}
+	var found []models.ShoppingListItem
+	for _, item := range list.Items {
+		if item.Ingredient.Name == "Rice" {
+			found = append(found, item)
+		}
+	}
+	if len(found) != 1 {
+		t.Fatalf("list has %d rice lines, want 1", len(found))
+	}
+	return found[0]
+}
+
+func TestSyncKeepsPurchasesUnlessMoreIsNeeded(t *testing.T) {
+	s := newTestStore(t)
+	meal := seedMeal(t, s, "Rice Bowl", "Rice")
+	plan := models.MealPlan{UserID: 1, Name: "Week"}
+	if err := s.CreatePlan(&plan); err != nil {
+		t.Fatalf("CreatePlan: %v", err)
+	}
+	addEntry := func(day string) models.MealPlanEntry {
+		entry := models.MealPlanEntry{MealPlanID: plan.ID, MealID: meal.ID, Day: day, MealType: "dinner", Headcount: 2}
+		if err := s.CreateEntry(&entry); err != nil {
+			t.Fatalf("CreateEntry: %v", err)
+		}
+		return entry
+	}
+	addEntry("monday")
+	tuesday := addEntry("tuesday")
+
+	rice := riceItem(t, s, plan.ID)
+	if err := s.UpdateShoppingItem(&rice, map[string]interface{}{"is_purchased": true, "notes": "basmati"}); err != nil {
+		t.Fatalf("UpdateShoppingItem: %v", err)
+	}
+
+	// Needing less keeps what was bought
+	if err := s.db.Delete(&tuesday).Error; err != nil {
+		t.Fatalf("delete entry: %v", err)
+	}
+	decreased := riceItem(t, s, plan.ID)
+	if decreased.ID != rice.ID || !decreased.IsPurchased || decreased.Notes != "basmati" || decreased.Quantity >= rice.Quantity {
+		t.Errorf("after a decrease rice is %+v, was %+v", decreased, rice)
+	}
+
+	// Needing more than was bought puts it back on the list
+	addEntry("tuesday")
+	addEntry("wednesday")
+	increased := riceItem(t, s, plan.ID)
+	if increased.ID != rice.ID || increased.IsPurchased || increased.Notes != "basmati" || increased.Quantity <= rice.Quantity {
+		t.Errorf("after an increase rice is %+v, was %+v", increased, rice)
+	}
+}
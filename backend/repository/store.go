@@ -0,0 +1,61 @@
+// Package repository holds the application's database access. Handlers depend
+// on the interfaces in repository.go; Store implements all of them on a gorm
+// handle. Every method returns its error, and Transaction runs a group of them
+// atomically so a failure midway leaves no half-replaced plan or list behind.
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned by lookups that match no record
+var ErrNotFound = errors.New("record not found")
+
+// Store reads and writes through a database handle, which inside Transaction
+// is the transaction itself
+type Store struct {
+	db *gorm.DB
+}
+
+// New wraps a database handle
+func New(db *gorm.DB) *Store {
+	return &Store{db: db}
+}
+
+// DB returns the underlying handle, for reads that should see the store's writes
+func (s *Store) DB() *gorm.DB {
+	return s.db
+}
+
+// WithContext returns a store whose queries run under ctx, so they are
+// cancelled along with the request that issued them
+func (s *Store) WithContext(ctx context.Context) Repository {
+	return &Store{db: s.db.WithContext(ctx)}
+}
+
+// Transaction runs fn with a repository bound to a new transaction. The
+// transaction commits when fn returns nil and rolls back when it returns an
+// error or panics.
+func (s *Store) Transaction(fn func(tx Repository) error) error {
+	return s.transaction(func(tx *Store) error {
+		return fn(tx)
+	})
+}
+
+func (s *Store) transaction(fn func(tx *Store) error) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&Store{db: tx})
+	})
+}
+
+// first loads the first record query matches into out, reporting no match as ErrNotFound
+func first(query *gorm.DB, out interface{}, where ...interface{}) error {
+	err := query.First(out, where...).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"food-app/models"
+
+	"gorm.io/gorm"
+)
+
+// SaveStoreLayout creates or updates a layout and replaces its aisles
+func (s *Store) SaveStoreLayout(layout *models.StoreLayout, aisles []models.StoreAisle) error {
+	layout.Aisles = nil
+	if err := s.db.Save(layout).Error; err != nil {
+		return err
+	}
+	if err := s.db.Where("store_layout_id = ?", layout.ID).Delete(&models.StoreAisle{}).Error; err != nil {
+		return err
+	}
+
+	layout.Aisles = make([]models.StoreAisle, 0, len(aisles))
+	for position, aisle := range aisles {
+		aisle.ID = 0
+		aisle.StoreLayoutID = layout.ID
+		aisle.Position = position
+		if err := s.db.Create(&aisle).Error; err != nil {
+			return err
+		}
+		layout.Aisles = append(layout.Aisles, aisle)
+	}
+	return nil
+}
+
+// DeleteStoreLayout removes a layout and its aisles
+func (s *Store) DeleteStoreLayout(layout *models.StoreLayout) error {
+	if err := s.db.Where("store_layout_id = ?", layout.ID).Delete(&models.StoreAisle{}).Error; err != nil {
+		return err
+	}
+	return s.db.Delete(layout).Error
+}
+
+// StoreLayouts lists a user's store layouts with their aisles in walking order
+func (s *Store) StoreLayouts(userID uint) ([]models.StoreLayout, error) {
+	var layouts []models.StoreLayout
+	err := s.db.Preload("Aisles", orderedAisles).Where("user_id = ?", userID).Order("id").Find(&layouts).Error
+	return layouts, err
+}
+
+// StoreLayout loads one of a user's layouts with its aisles in walking order;
+// an id of 0 loads their first layout
+func (s *Store) StoreLayout(id, userID uint) (models.StoreLayout, error) {
+	query := s.db.Preload("Aisles", orderedAisles).Where("user_id = ?", userID)
+	if id != 0 {
+		query = query.Where("id = ?", id)
+	}
+
+	var layout models.StoreLayout
+	err := first(query.Order("id"), &layout)
+	return layout, err
+}
+
+func orderedAisles(db *gorm.DB) *gorm.DB {
+	return db.Order("position")
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"food-app/database"
+	"food-app/models"
+
+	"gorm.io/driver/sqlite"
+)
+
+// newTestStore opens a store on a fresh in-memory SQLite database with the full schema
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+
+	db, err := database.Open(sqlite.Open(":memory:"))
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("database handle: %v", err)
+	}
+	// Every connection to ":memory:" is its own database, so keep to one
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := database.AutoMigrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return New(db)
+}
+
+// seedMeal creates a meal with one line per named ingredient
+func seedMeal(t *testing.T, s *Store, name string, ingredientNames ...string) models.Meal {
+	t.Helper()
+
+	meal := models.Meal{Name: name, Servings: 2, MealType: "dinner", DietaryTags: models.StringArray{"vegetarian"}}
+	if err := s.db.Create(&meal).Error; err != nil {
+		t.Fatalf("create meal: %v", err)
+	}
+	for i, ingredientName := range ingredientNames {
+		var ingredient models.Ingredient
+		if err := s.db.Where(models.Ingredient{Name: ingredientName}).FirstOrCreate(&ingredient).Error; err != nil {
+			t.Fatalf("create ingredient: %v", err)
+		}
+		line := models.MealIngredient{MealID: meal.ID, IngredientID: ingredient.ID, Quantity: float64(i + 1), Unit: "cup"}
+		if err := s.db.Create(&line).Error; err != nil {
+			t.Fatalf("create meal ingredient: %v", err)
+		}
+	}
+	return meal
+}
+
+func TestLookupsReportErrNotFound(t *testing.T) {
+	s := newTestStore(t)
+
+	lookups := map[string]error{}
+	_, lookups["Meal"] = s.Meal(42)
+	_, lookups["User"] = s.User(42)
+	_, lookups["UserByEmail"] = s.UserByEmail("nobody@example.com")
+	_, lookups["Plan"] = s.Plan(42)
+	_, lookups["Ingredient"] = s.Ingredient(42)
+	_, lookups["IngredientNamed"] = s.IngredientNamed("nothing")
+	_, lookups["Household"] = s.Household(42)
+	_, lookups["CalendarFeedByToken"] = s.CalendarFeedByToken("nope")
+
+	for name, err := range lookups {
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: got %v, want ErrNotFound", name, err)
+		}
+	}
+}
+
+func TestDeletedMealsAreHidden(t *testing.T) {
+	s := newTestStore(t)
+	kept := seedMeal(t, s, "Kept")
+	deleted := seedMeal(t, s, "Deleted")
+
+	if err := s.db.Delete(&deleted).Error; err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	if _, err := s.Meal(deleted.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Meal(deleted): got %v, want ErrNotFound", err)
+	}
+	var row models.Meal
+	if err := s.db.Unscoped().First(&row, deleted.ID).Error; err != nil || !row.DeletedAt.Valid {
+		t.Errorf("deleted meal should remain with deleted_at set, got %+v, %v", row.DeletedAt, err)
+	}
+
+	meals, err := s.FindMeals(MealFilter{})
+	if err != nil {
+		t.Fatalf("FindMeals: %v", err)
+	}
+	if len(meals) != 1 || meals[0].ID != kept.ID {
+		t.Errorf("FindMeals returned %d meals, want only %q", len(meals), kept.Name)
+	}
+}
+
+func TestMealPreloadsIngredientLines(t *testing.T) {
+	s := newTestStore(t)
+	seeded := seedMeal(t, s, "Stir Fry", "Rice", "Broccoli")
+
+	meal, err := s.Meal(seeded.ID)
+	if err != nil {
+		t.Fatalf("Meal: %v", err)
+	}
+	if len(meal.IngredientLines) != 2 {
+		t.Fatalf("got %d ingredient lines, want 2", len(meal.IngredientLines))
+	}
+	for i, want := range []string{"Rice", "Broccoli"} {
+		line := meal.IngredientLines[i]
+		if line.Ingredient.Name != want || line.Quantity != float64(i+1) || line.Unit != "cup" {
+			t.Errorf("line %d = %s %v %s, want %s %v cup", i, line.Ingredient.Name, line.Quantity, line.Unit, want, i+1)
+		}
+	}
+	// AfterFind keeps the flat ingredient list for older clients
+	if len(meal.Ingredients) != 2 || meal.Ingredients[0].Name != "Rice" {
+		t.Errorf("Ingredients = %+v, want the lines' ingredients", meal.Ingredients)
+	}
+}
+
+func TestCreateHooksSetCreatedAt(t *testing.T) {
+	s := newTestStore(t)
+
+	user := models.User{Email: "cook@example.com", Username: "cook"}
+	if err := s.CreateUser(&user); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	meal := seedMeal(t, s, "Soup")
+
+	if user.CreatedAt.IsZero() || meal.CreatedAt.IsZero() {
+		t.Errorf("CreatedAt not set: user %v, meal %v", user.CreatedAt, meal.CreatedAt)
+	}
+}
+
+func TestStringArrayRoundTrip(t *testing.T) {
+	s := newTestStore(t)
+
+	for _, tags := range []models.StringArray{
+		{},
+		{"vegan"},
+		{"gluten-free", "has, comma", `has "quotes"`, "has space"},
+	} {
+		meal := models.Meal{Name: "Tagged", DietaryTags: tags, Allergens: models.StringArray{"nuts"}}
+		if err := s.db.Create(&meal).Error; err != nil {
+			t.Fatalf("create: %v", err)
+		}
+
+		loaded, err := s.Meal(meal.ID)
+		if err != nil {
+			t.Fatalf("Meal: %v", err)
+		}
+		if len(loaded.DietaryTags) != len(tags) {
+			t.Fatalf("DietaryTags = %q, want %q", loaded.DietaryTags, tags)
+		}
+		for i := range tags {
+			if loaded.DietaryTags[i] != tags[i] {
+				t.Errorf("DietaryTags = %q, want %q", loaded.DietaryTags, tags)
+			}
+		}
+		if len(loaded.Allergens) != 1 || loaded.Allergens[0] != "nuts" {
+			t.Errorf("Allergens = %q, want [nuts]", loaded.Allergens)
+		}
+	}
+}
+
+func TestStringArrayScansJSON(t *testing.T) {
+	var tags models.StringArray
+	if err := tags.Scan(`["a","b"]`); err != nil || len(tags) != 2 || tags[1] != "b" {
+		t.Errorf("Scan(JSON) = %q, %v", tags, err)
+	}
+	if err := tags.Scan(nil); err != nil || tags == nil || len(tags) != 0 {
+		t.Errorf("Scan(nil) = %#v, %v, want empty", tags, err)
+	}
+}
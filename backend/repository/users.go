@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"time"
+
+	"food-app/models"
+)
+
+// User loads a user by ID
+func (s *Store) User(id uint) (models.User, error) {
+	var user models.User
+	err := first(s.db, &user, id)
+	return user, err
+}
+
+// UserByEmail loads the user with an email address, ignoring case
+func (s *Store) UserByEmail(email string) (models.User, error) {
+	var user models.User
+	err := first(s.db.Where("LOWER(email) = LOWER(?)", email), &user)
+	return user, err
+}
+
+// UserExists reports whether a user has the email or the username
+func (s *Store) UserExists(email, username string) (bool, error) {
+	var count int64
+	err := s.db.Model(&models.User{}).Where("email = ? OR username = ?", email, username).Count(&count).Error
+	return count > 0, err
+}
+
+// UsernameTaken reports whether a user has the username
+func (s *Store) UsernameTaken(username string) (bool, error) {
+	var count int64
+	err := s.db.Model(&models.User{}).Where("username = ?", username).Count(&count).Error
+	return count > 0, err
+}
+
+// CreateUser inserts a user
+func (s *Store) CreateUser(user *models.User) error {
+	return s.db.Create(user).Error
+}
+
+// UpdateUser updates the given columns of a user
+func (s *Store) UpdateUser(user *models.User, fields map[string]interface{}) error {
+	return s.db.Model(user).Updates(fields).Error
+}
+
+// Identities lists the external identities linked to a user, oldest first
+func (s *Store) Identities(userID uint) ([]models.UserIdentity, error) {
+	var identities []models.UserIdentity
+	err := s.db.Where("user_id = ?", userID).Order("created_at").Find(&identities).Error
+	return identities, err
+}
+
+// UserIdentity loads one of a user's identities
+func (s *Store) UserIdentity(id, userID uint) (models.UserIdentity, error) {
+	var identity models.UserIdentity
+	err := first(s.db.Where("id = ? AND user_id = ?", id, userID), &identity)
+	return identity, err
+}
+
+// IdentityBySubject loads the identity a provider knows by subject
+func (s *Store) IdentityBySubject(provider, subject string) (models.UserIdentity, error) {
+	var identity models.UserIdentity
+	err := first(s.db.Where("provider = ? AND subject = ?", provider, subject), &identity)
+	return identity, err
+}
+
+// CountIdentities counts the identities linked to a user
+func (s *Store) CountIdentities(userID uint) (int64, error) {
+	var count int64
+	err := s.db.Model(&models.UserIdentity{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// CreateIdentity inserts an identity
+func (s *Store) CreateIdentity(identity *models.UserIdentity) error {
+	return s.db.Create(identity).Error
+}
+
+// TouchIdentity records a sign-in with the profile details the provider returned
+func (s *Store) TouchIdentity(identity *models.UserIdentity, email string, emailVerified bool) error {
+	return s.db.Model(identity).Updates(map[string]interface{}{
+		"email":          email,
+		"email_verified": emailVerified,
+		"last_login_at":  time.Now(),
+	}).Error
+}
+
+// DeleteIdentity removes an identity
+func (s *Store) DeleteIdentity(identity *models.UserIdentity) error {
+	return s.db.Delete(identity).Error
+}
@@ -0,0 +1,415 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"food-app/models"
+)
+
+// Catalog file formats
+const (
+	CatalogJSON = "json"
+	CatalogCSV  = "csv"
+)
+
+// CatalogVersion is the version written to and accepted in JSON catalogs
+const CatalogVersion = 1
+
+// Catalog is the portable form of the meal and ingredient catalog. Meals and
+// ingredients are identified by name, ignoring case, so a catalog can be
+// imported into any database.
+type Catalog struct {
+	Version     int                 `json:"version"`
+	Ingredients []CatalogIngredient `json:"ingredients"`
+	Meals       []CatalogMeal       `json:"meals"`
+}
+
+// CatalogIngredient is an ingredient catalog entry
+type CatalogIngredient struct {
+	Name            string   `json:"name"`
+	Category        string   `json:"category"`
+	Unit            string   `json:"unit"`
+	CaloriesPer100g float64  `json:"calories_per_100g"`
+	Aliases         []string `json:"aliases,omitempty"` // other names that resolve to this ingredient
+}
+
+// CatalogMeal is a meal with its ingredient quantities
+type CatalogMeal struct {
+	Name         string                  `json:"name"`
+	Description  string                  `json:"description"`
+	ImageURL     string                  `json:"image_url"`
+	PrepTime     int                     `json:"prep_time"`
+	CookTime     int                     `json:"cook_time"`
+	Servings     int                     `json:"servings"`
+	Difficulty   string                  `json:"difficulty"`
+	Cuisine      string                  `json:"cuisine"`
+	MealType     string                  `json:"meal_type"`
+	Instructions string                  `json:"instructions"`
+	Nutrition    models.NutritionInfo    `json:"nutrition"`
+	DietaryTags  []string                `json:"dietary_tags"`
+	Allergens    []string                `json:"allergens"`
+	SourceURL    string                  `json:"source_url"`
+	Ingredients  []CatalogMealIngredient `json:"ingredients"`
+}
+
+// CatalogMealIngredient is one ingredient of a meal; Name refers to a catalog
+// ingredient, which is created on import if neither the file nor the database has it
+type CatalogMealIngredient struct {
+	Name     string  `json:"name"`
+	Quantity float64 `json:"quantity"`
+	Unit     string  `json:"unit"`
+	Note     string  `json:"note,omitempty"`
+}
+
+// CatalogProblem is a validation error at a place in a catalog file
+type CatalogProblem struct {
+	Path    string `json:"path"` // e.g. "meals[2].ingredients[0].unit" or "row 14"
+	Message string `json:"message"`
+}
+
+// CatalogError lists everything wrong with a catalog
+type CatalogError struct {
+	Problems []CatalogProblem
+}
+
+func (e *CatalogError) Error() string {
+	if len(e.Problems) == 1 {
+		return e.Problems[0].Path + ": " + e.Problems[0].Message
+	}
+	return fmt.Sprintf("%d problems in catalog, first %s: %s", len(e.Problems), e.Problems[0].Path, e.Problems[0].Message)
+}
+
+var (
+	catalogDifficulties = map[string]bool{"": true, "easy": true, "medium": true, "hard": true}
+	catalogMealTypes    = map[string]bool{"": true, "breakfast": true, "lunch": true, "dinner": true, "snack": true}
+)
+
+// Validate checks names, uniqueness, enumerations, units and that no number is
+// negative. It returns a *CatalogError listing every problem, or nil.
+func (c Catalog) Validate() error {
+	var problems []CatalogProblem
+	add := func(path, format string, args ...interface{}) {
+		problems = append(problems, CatalogProblem{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if c.Version != 0 && c.Version != CatalogVersion {
+		add("version", "unsupported catalog version %d", c.Version)
+	}
+
+	ingredientNames := map[string]bool{}
+	for i, ingredient := range c.Ingredients {
+		path := fmt.Sprintf("ingredients[%d]", i)
+		key := strings.ToLower(strings.TrimSpace(ingredient.Name))
+		switch {
+		case key == "":
+			add(path+".name", "is required")
+		case ingredientNames[key]:
+			add(path+".name", "duplicate ingredient %q", ingredient.Name)
+		}
+		ingredientNames[key] = true
+		for j, alias := range ingredient.Aliases {
+			aliasKey := strings.ToLower(strings.TrimSpace(alias))
+			switch {
+			case aliasKey == "":
+				add(fmt.Sprintf("%s.aliases[%d]", path, j), "must not be empty")
+			case ingredientNames[aliasKey]:
+				add(fmt.Sprintf("%s.aliases[%d]", path, j), "%q already names an ingredient", alias)
+			}
+			ingredientNames[aliasKey] = true
+		}
+		if ingredient.Unit != "" && UnitDimension(ingredient.Unit) == "" {
+			add(path+".unit", "unknown unit %q", ingredient.Unit)
+		}
+		if ingredient.CaloriesPer100g < 0 {
+			add(path+".calories_per_100g", "must not be negative")
+		}
+	}
+
+	mealNames := map[string]bool{}
+	for i, meal := range c.Meals {
+		path := fmt.Sprintf("meals[%d]", i)
+		key := strings.ToLower(strings.TrimSpace(meal.Name))
+		switch {
+		case key == "":
+			add(path+".name", "is required")
+		case mealNames[key]:
+			add(path+".name", "duplicate meal %q", meal.Name)
+		}
+		mealNames[key] = true
+
+		if meal.PrepTime < 0 || meal.CookTime < 0 || meal.Servings < 0 {
+			add(path, "prep_time, cook_time and servings must not be negative")
+		}
+		if !catalogDifficulties[meal.Difficulty] {
+			add(path+".difficulty", "must be easy, medium or hard")
+		}
+		if !catalogMealTypes[meal.MealType] {
+			add(path+".meal_type", "must be breakfast, lunch, dinner or snack")
+		}
+		n := meal.Nutrition
+		if n.Calories < 0 || n.Protein < 0 || n.Carbohydrates < 0 || n.Fat < 0 || n.Fiber < 0 || n.Sugar < 0 || n.Sodium < 0 {
+			add(path+".nutrition", "values must not be negative")
+		}
+
+		used := map[string]bool{}
+		for j, ingredient := range meal.Ingredients {
+			ingredientPath := fmt.Sprintf("%s.ingredients[%d]", path, j)
+			ingredientKey := strings.ToLower(strings.TrimSpace(ingredient.Name))
+			switch {
+			case ingredientKey == "":
+				add(ingredientPath+".name", "is required")
+			case used[ingredientKey]:
+				add(ingredientPath+".name", "%q is listed twice", ingredient.Name)
+			}
+			used[ingredientKey] = true
+			if ingredient.Quantity < 0 {
+				add(ingredientPath+".quantity", "must not be negative")
+			}
+			if ingredient.Unit != "" && UnitDimension(ingredient.Unit) == "" {
+				add(ingredientPath+".unit", "unknown unit %q", ingredient.Unit)
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return &CatalogError{Problems: problems}
+	}
+	return nil
+}
+
+// ReadCatalog decodes a catalog in one of the catalog formats. Malformed input
+// is reported as a *CatalogError.
+func ReadCatalog(r io.Reader, format string) (Catalog, error) {
+	switch format {
+	case CatalogJSON:
+		var catalog Catalog
+		decoder := json.NewDecoder(r)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&catalog); err != nil {
+			return catalog, &CatalogError{Problems: []CatalogProblem{{Path: "json", Message: err.Error()}}}
+		}
+		return catalog, nil
+	case CatalogCSV:
+		return readCatalogCSV(r)
+	}
+	return Catalog{}, fmt.Errorf("unknown catalog format %q", format)
+}
+
+// WriteCatalog encodes a catalog in one of the catalog formats
+func WriteCatalog(w io.Writer, format string, catalog Catalog) error {
+	catalog.Version = CatalogVersion
+	switch format {
+	case CatalogJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(catalog)
+	case CatalogCSV:
+		return writeCatalogCSV(w, catalog)
+	}
+	return fmt.Errorf("unknown catalog format %q", format)
+}
+
+// catalogColumns is the CSV header. Each row is one meal ingredient and repeats
+// the meal's columns; rows with an empty meal define catalog ingredients.
+// Tags, allergens and ingredient aliases are separated by semicolons.
+var catalogColumns = []string{
+	"meal", "description", "image_url", "prep_time", "cook_time", "servings",
+	"difficulty", "cuisine", "meal_type", "instructions",
+	"calories", "protein", "carbohydrates", "fat", "fiber", "sugar", "sodium",
+	"dietary_tags", "allergens", "source_url",
+	"ingredient", "quantity", "unit", "note", "ingredient_category", "ingredient_unit", "calories_per_100g",
+	"ingredient_aliases",
+}
+
+func writeCatalogCSV(w io.Writer, catalog Catalog) error {
+	out := csv.NewWriter(w)
+	out.Write(catalogColumns)
+
+	number := func(value float64) string { return strconv.FormatFloat(value, 'f', -1, 64) }
+	row := func(values map[string]string) []string {
+		record := make([]string, len(catalogColumns))
+		for i, column := range catalogColumns {
+			record[i] = values[column]
+		}
+		return record
+	}
+
+	for _, ingredient := range catalog.Ingredients {
+		out.Write(row(map[string]string{
+			"ingredient":          ingredient.Name,
+			"ingredient_category": ingredient.Category,
+			"ingredient_unit":     ingredient.Unit,
+			"calories_per_100g":   number(ingredient.CaloriesPer100g),
+			"ingredient_aliases":  strings.Join(ingredient.Aliases, ";"),
+		}))
+	}
+
+	for _, meal := range catalog.Meals {
+		n := meal.Nutrition
+		values := map[string]string{
+			"meal": meal.Name, "description": meal.Description, "image_url": meal.ImageURL,
+			"prep_time": strconv.Itoa(meal.PrepTime), "cook_time": strconv.Itoa(meal.CookTime),
+			"servings": strconv.Itoa(meal.Servings), "difficulty": meal.Difficulty,
+			"cuisine": meal.Cuisine, "meal_type": meal.MealType, "instructions": meal.Instructions,
+			"calories": number(n.Calories), "protein": number(n.Protein),
+			"carbohydrates": number(n.Carbohydrates), "fat": number(n.Fat),
+			"fiber": number(n.Fiber), "sugar": number(n.Sugar), "sodium": number(n.Sodium),
+			"dietary_tags": strings.Join(meal.DietaryTags, ";"), "allergens": strings.Join(meal.Allergens, ";"),
+			"source_url": meal.SourceURL,
+		}
+		if len(meal.Ingredients) == 0 {
+			out.Write(row(values))
+		}
+		for _, ingredient := range meal.Ingredients {
+			values["ingredient"] = ingredient.Name
+			values["quantity"] = number(ingredient.Quantity)
+			values["unit"] = ingredient.Unit
+			values["note"] = ingredient.Note
+			out.Write(row(values))
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+func readCatalogCSV(r io.Reader) (Catalog, error) {
+	catalog := Catalog{Version: CatalogVersion}
+	var problems []CatalogProblem
+
+	in := csv.NewReader(r)
+	in.FieldsPerRecord = -1
+	header, err := in.Read()
+	if err != nil {
+		return catalog, &CatalogError{Problems: []CatalogProblem{{Path: "header", Message: "missing CSV header"}}}
+	}
+	index := map[string]int{}
+	for i, column := range header {
+		index[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	for _, required := range []string{"meal", "ingredient"} {
+		if _, ok := index[required]; !ok {
+			return catalog, &CatalogError{Problems: []CatalogProblem{{Path: "header", Message: "missing column " + required}}}
+		}
+	}
+
+	meals := map[string]int{}
+	ingredients := map[string]bool{}
+	for row := 2; ; row++ {
+		record, err := in.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		path := fmt.Sprintf("row %d", row)
+		if err != nil {
+			problems = append(problems, CatalogProblem{Path: path, Message: err.Error()})
+			break
+		}
+
+		field := func(column string) string {
+			if i, ok := index[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		integer := func(column string) int {
+			value, err := strconv.Atoi(field(column))
+			if err != nil && field(column) != "" {
+				problems = append(problems, CatalogProblem{Path: path + " " + column, Message: "not a whole number"})
+			}
+			return value
+		}
+		decimal := func(column string) float64 {
+			value, err := strconv.ParseFloat(field(column), 64)
+			if err != nil && field(column) != "" {
+				problems = append(problems, CatalogProblem{Path: path + " " + column, Message: "not a number"})
+			}
+			return value
+		}
+
+		mealName, ingredientName := field("meal"), field("ingredient")
+		if mealName == "" && ingredientName == "" {
+			continue
+		}
+
+		// Ingredient definitions: rows without a meal, or meal rows that fill in the ingredient columns
+		ingredientKey := strings.ToLower(ingredientName)
+		definesIngredient := mealName == "" || field("ingredient_category") != "" || field("ingredient_unit") != "" ||
+			field("calories_per_100g") != "" || field("ingredient_aliases") != ""
+		if ingredientName != "" && definesIngredient && !ingredients[ingredientKey] {
+			ingredients[ingredientKey] = true
+			catalog.Ingredients = append(catalog.Ingredients, CatalogIngredient{
+				Name:            ingredientName,
+				Category:        field("ingredient_category"),
+				Unit:            field("ingredient_unit"),
+				CaloriesPer100g: decimal("calories_per_100g"),
+				Aliases:         splitCatalogList(field("ingredient_aliases")),
+			})
+		}
+		if mealName == "" {
+			continue
+		}
+
+		// The first row of a meal carries its details
+		mealKey := strings.ToLower(mealName)
+		i, seen := meals[mealKey]
+		if !seen {
+			i = len(catalog.Meals)
+			meals[mealKey] = i
+			catalog.Meals = append(catalog.Meals, CatalogMeal{
+				Name:         mealName,
+				Description:  field("description"),
+				ImageURL:     field("image_url"),
+				PrepTime:     integer("prep_time"),
+				CookTime:     integer("cook_time"),
+				Servings:     integer("servings"),
+				Difficulty:   field("difficulty"),
+				Cuisine:      field("cuisine"),
+				MealType:     field("meal_type"),
+				Instructions: field("instructions"),
+				Nutrition: models.NutritionInfo{
+					Calories:      decimal("calories"),
+					Protein:       decimal("protein"),
+					Carbohydrates: decimal("carbohydrates"),
+					Fat:           decimal("fat"),
+					Fiber:         decimal("fiber"),
+					Sugar:         decimal("sugar"),
+					Sodium:        decimal("sodium"),
+				},
+				DietaryTags: splitCatalogList(field("dietary_tags")),
+				Allergens:   splitCatalogList(field("allergens")),
+				SourceURL:   field("source_url"),
+			})
+		}
+		if ingredientName != "" {
+			catalog.Meals[i].Ingredients = append(catalog.Meals[i].Ingredients, CatalogMealIngredient{
+				Name:     ingredientName,
+				Quantity: decimal("quantity"),
+				Unit:     field("unit"),
+				Note:     field("note"),
+			})
+		}
+	}
+
+	if len(problems) > 0 {
+		return catalog, &CatalogError{Problems: problems}
+	}
+	return catalog, nil
+}
+
+// splitCatalogList splits a semicolon-separated CSV cell
+func splitCatalogList(cell string) []string {
+	list := []string{}
+	for _, item := range strings.Split(cell, ";") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
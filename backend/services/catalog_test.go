@@ -0,0 +1,100 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"food-app/models"
+)
+
+// sampleCatalog uses every field, with text that needs quoting in CSV
+func sampleCatalog() Catalog {
+	return Catalog{
+		Version: CatalogVersion,
+		Ingredients: []CatalogIngredient{
+			{Name: "Rice", Category: "grain", Unit: "cup", CaloriesPer100g: 130, Aliases: []string{"White Rice", "Jasmine Rice"}},
+			{Name: "Chicken, thighs", Category: "protein", Unit: "lb", CaloriesPer100g: 209.5, Aliases: []string{"Thighs"}},
+		},
+		Meals: []CatalogMeal{
+			{
+				Name:         "Chicken & Rice",
+				Description:  `The "weeknight" classic, with a comma`,
+				ImageURL:     "https://example.com/chicken.jpg",
+				PrepTime:     10,
+				CookTime:     35,
+				Servings:     4,
+				Difficulty:   "easy",
+				Cuisine:      "american",
+				MealType:     "dinner",
+				Instructions: "Brown the chicken.\nAdd the rice; simmer.",
+				Nutrition:    models.NutritionInfo{Calories: 540, Protein: 38.5, Carbohydrates: 52, Fat: 18, Fiber: 2, Sugar: 1, Sodium: 640},
+				DietaryTags:  []string{"gluten-free", "dairy-free"},
+				Allergens:    []string{"none"},
+				SourceURL:    "https://example.com/recipes/1",
+				Ingredients: []CatalogMealIngredient{
+					{Name: "Chicken, thighs", Quantity: 1.5, Unit: "lb", Note: "bone-in, skin on"},
+					{Name: "Rice", Quantity: 2, Unit: "cup"},
+				},
+			},
+			{
+				Name:        "Plain Rice",
+				Servings:    2,
+				MealType:    "lunch",
+				DietaryTags: []string{"vegan"},
+				Allergens:   []string{"none"},
+				Ingredients: []CatalogMealIngredient{{Name: "Rice", Quantity: 1, Unit: "cup", Note: "rinsed"}},
+			},
+		},
+	}
+}
+
+func TestCatalogRoundTrips(t *testing.T) {
+	for _, format := range []string{CatalogJSON, CatalogCSV} {
+		t.Run(format, func(t *testing.T) {
+			want := sampleCatalog()
+
+			var encoded bytes.Buffer
+			if err := WriteCatalog(&encoded, format, want); err != nil {
+				t.Fatalf("WriteCatalog: %v", err)
+			}
+			got, err := ReadCatalog(bytes.NewReader(encoded.Bytes()), format)
+			if err != nil {
+				t.Fatalf("ReadCatalog: %v\n%s", err, encoded.String())
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("round trip through %s changed the catalog\ngot  %+v\nwant %+v", format, got, want)
+			}
+			if err := got.Validate(); err != nil {
+				t.Errorf("round-tripped catalog is invalid: %v", err)
+			}
+
+			var again bytes.Buffer
+			if err := WriteCatalog(&again, format, got); err != nil {
+				t.Fatalf("WriteCatalog: %v", err)
+			}
+			if again.String() != encoded.String() {
+				t.Errorf("second export differs:\n%s\nfirst:\n%s", again.String(), encoded.String())
+			}
+		})
+	}
+}
+
+func TestReadCatalogReportsEveryProblem(t *testing.T) {
+	csv := strings.Join([]string{
+		"meal,servings,ingredient,quantity,unit",
+		"Soup,four,Leek,1,cup",
+		"Stew,2,Beef,lots,lb",
+	}, "\n")
+
+	_, err := ReadCatalog(strings.NewReader(csv), CatalogCSV)
+	var invalid *CatalogError
+	if !errors.As(err, &invalid) || len(invalid.Problems) != 2 {
+		t.Fatalf("ReadCatalog = %v, want two problems", err)
+	}
+	if invalid.Problems[0].Path != "row 2 servings" || invalid.Problems[1].Path != "row 3 quantity" {
+		t.Errorf("problems = %+v", invalid.Problems)
+	}
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event is a change notification delivered to stream subscribers.
+// Versions increase by one per topic, so a client can resume after reconnecting.
+// Versions restart with the process, so the hub's epoch tells them apart.
+type Event struct {
+	Epoch     string      `json:"epoch"`
+	Version   uint64      `json:"version"`
+	Type      string      `json:"type"`
+	Data      interface{} `json:"data"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// ID identifies the event for resuming, as "epoch-version"
+func (e Event) ID() string {
+	return EventID(e.Epoch, e.Version)
+}
+
+// EventID formats a resume position as "epoch-version"
+func EventID(epoch string, version uint64) string {
+	return fmt.Sprintf("%s-%d", epoch, version)
+}
+
+// ErrInvalidEventID is returned by ParseEventID for ids not made by EventID
+var ErrInvalidEventID = errors.New("invalid event id")
+
+// ParseEventID splits an id made by EventID
+func ParseEventID(id string) (epoch string, version uint64, err error) {
+	sep := strings.LastIndex(id, "-")
+	if sep <= 0 {
+		return "", 0, ErrInvalidEventID
+	}
+	version, err = strconv.ParseUint(id[sep+1:], 10, 64)
+	if err != nil {
+		return "", 0, ErrInvalidEventID
+	}
+	return id[:sep], version, nil
+}
+
+// EventHub is an in-process publish/subscribe hub keyed by topic.
+// It keeps a bounded history per topic for resume-from-version, and forgets
+// topics nobody has subscribed to or published on for a while.
+type EventHub struct {
+	mu           sync.Mutex
+	epoch        string // distinguishes this hub's versions from a previous process's
+	historySize  int    // events retained per topic
+	historyBytes int    // encoded event data retained per topic
+	idleTTL      time.Duration
+	now          func() time.Time
+	lastSweep    time.Time
+	// evictedVersion is the highest version of any evicted topic. A topic made
+	// again starts there, so versions a client saw before are never reused.
+	evictedVersion uint64
+	topics         map[string]*topicState
+}
+
+type topicState struct {
+	version      uint64
+	history      []Event
+	sizes        []int // encoded size of each history event's data
+	bytes        int
+	lastActivity time.Time
+	subscribers  map[chan Event]struct{}
+}
+
+// FromLatest subscribes without replaying any history
+const FromLatest = ^uint64(0)
+
+// subscriberBuffer is how many events a slow subscriber may lag before it is dropped
+const subscriberBuffer = 32
+
+// Default limits of a hub, see NewEventHub
+const (
+	defaultHistoryBytes = 1 << 20
+	defaultTopicIdleTTL = 30 * time.Minute
+)
+
+// NewEventHub creates a hub retaining up to historySize events, and at most
+// 1 MiB of their encoded data, per topic. Topics without subscribers are
+// evicted after 30 minutes without events.
+func NewEventHub(historySize int) *EventHub {
+	return &EventHub{
+		epoch:        strconv.FormatInt(time.Now().UnixNano(), 36),
+		historySize:  historySize,
+		historyBytes: defaultHistoryBytes,
+		idleTTL:      defaultTopicIdleTTL,
+		now:          time.Now,
+		topics:       map[string]*topicState{},
+	}
+}
+
+// topic returns a topic's state, creating it if needed, and marks it active
+func (h *EventHub) topic(name string) *topicState {
+	now := h.now()
+	h.sweep(now)
+
+	state, ok := h.topics[name]
+	if !ok {
+		state = &topicState{version: h.evictedVersion, subscribers: map[chan Event]struct{}{}}
+		h.topics[name] = state
+	}
+	state.lastActivity = now
+	return state
+}
+
+// sweep evicts idle topics without subscribers. It runs at most once per TTL,
+// so publishing stays cheap however many topics there are.
+func (h *EventHub) sweep(now time.Time) {
+	if now.Sub(h.lastSweep) < h.idleTTL {
+		return
+	}
+	h.lastSweep = now
+
+	for name, state := range h.topics {
+		if len(state.subscribers) == 0 && now.Sub(state.lastActivity) >= h.idleTTL {
+			if state.version > h.evictedVersion {
+				h.evictedVersion = state.version
+			}
+			delete(h.topics, name)
+		}
+	}
+}
+
+// retain appends an event to the topic's history, dropping the oldest events
+// beyond the hub's count and size limits. The latest event is always kept.
+func (h *EventHub) retain(state *topicState, event Event) {
+	size := 0
+	if encoded, err := json.Marshal(event.Data); err == nil {
+		size = len(encoded)
+	}
+
+	state.history = append(state.history, event)
+	state.sizes = append(state.sizes, size)
+	state.bytes += size
+
+	drop := 0
+	for len(state.history)-drop > 1 &&
+		(len(state.history)-drop > h.historySize || state.bytes > h.historyBytes) {
+		state.bytes -= state.sizes[drop]
+		drop++
+	}
+	if drop > 0 {
+		state.history = append([]Event(nil), state.history[drop:]...)
+		state.sizes = append([]int(nil), state.sizes[drop:]...)
+	}
+}
+
+// Publish records an event on a topic and fans it out to subscribers.
+// Subscribers that cannot keep up are disconnected and must resume.
+func (h *EventHub) Publish(topic, eventType string, data interface{}) Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	state := h.topic(topic)
+	state.version++
+	event := Event{
+		Epoch:     h.epoch,
+		Version:   state.version,
+		Type:      eventType,
+		Data:      data,
+		CreatedAt: time.Now(),
+	}
+
+	h.retain(state, event)
+
+	for ch := range state.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(state.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	return event
+}
+
+// Epoch returns the hub's epoch, which every event it publishes carries
+func (h *EventHub) Epoch() string {
+	return h.epoch
+}
+
+// Version returns the latest version published on a topic
+func (h *EventHub) Version(topic string) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.topic(topic).version
+}
+
+// LastEventID returns the id of the latest event on a topic, to resume from
+func (h *EventHub) LastEventID(topic string) string {
+	return EventID(h.epoch, h.Version(topic))
+}
+
+// Subscribe registers for events on a topic. Events after sinceVersion that are
+// still retained are returned as backlog; resumed is false when the client must
+// reload its state: its epoch is another hub's, e.g. from before a server restart,
+// or it is too far behind. The returned cancel func must be called to unsubscribe.
+func (h *EventHub) Subscribe(topic, epoch string, sinceVersion uint64) (events <-chan Event, backlog []Event, resumed bool, cancel func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	state := h.topic(topic)
+	resumed = true
+
+	switch {
+	case sinceVersion == FromLatest:
+	case epoch != h.epoch, sinceVersion > state.version:
+		resumed = false
+	case sinceVersion < state.version:
+		oldest := state.version - uint64(len(state.history)) + 1
+		if len(state.history) == 0 || sinceVersion+1 < oldest {
+			resumed = false
+		} else {
+			for _, event := range state.history {
+				if event.Version > sinceVersion {
+					backlog = append(backlog, event)
+				}
+			}
+		}
+	}
+
+	ch := make(chan Event, subscriberBuffer)
+	state.subscribers[ch] = struct{}{}
+
+	cancel = func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := state.subscribers[ch]; ok {
+			delete(state.subscribers, ch)
+			close(ch)
+		}
+		// The topic may only be evicted once it has been idle since the last subscriber left
+		state.lastActivity = h.now()
+	}
+
+	return ch, backlog, resumed, cancel
+}
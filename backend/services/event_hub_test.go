@@ -0,0 +1,129 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventIDRoundTrip(t *testing.T) {
+	epoch, version, err := ParseEventID(EventID("kq3x9", 42))
+	if err != nil || epoch != "kq3x9" || version != 42 {
+		t.Errorf("ParseEventID = %q, %d, %v", epoch, version, err)
+	}
+	for _, id := range []string{"", "42", "-42", "kq3x9-", "kq3x9-x"} {
+		if _, _, err := ParseEventID(id); err != ErrInvalidEventID {
+			t.Errorf("ParseEventID(%q) = %v, want ErrInvalidEventID", id, err)
+		}
+	}
+}
+
+func TestSubscribeResumesOnlyWithinTheEpoch(t *testing.T) {
+	hub := NewEventHub(2)
+	first := hub.Publish("plan:1", "a", nil)
+	for _, eventType := range []string{"b", "c"} {
+		hub.Publish("plan:1", eventType, nil)
+	}
+
+	tests := []struct {
+		name    string
+		epoch   string
+		since   uint64
+		resumed bool
+		backlog int
+	}{
+		{"latest", "", FromLatest, true, 0},
+		{"up to date", hub.Epoch(), 3, true, 0},
+		{"retained", hub.Epoch(), 2, true, 1},
+		{"oldest retained", hub.Epoch(), 1, true, 2},
+		{"no longer retained", hub.Epoch(), 0, false, 0},
+		{"ahead", hub.Epoch(), 4, false, 0},
+		{"previous process", "older", 2, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, backlog, resumed, cancel := hub.Subscribe("plan:1", tt.epoch, tt.since)
+			defer cancel()
+			if resumed != tt.resumed || len(backlog) != tt.backlog {
+				t.Errorf("resumed = %v with %d events, want %v with %d", resumed, len(backlog), tt.resumed, tt.backlog)
+			}
+		})
+	}
+
+	if first.ID() != EventID(hub.Epoch(), 1) || hub.LastEventID("plan:1") != EventID(hub.Epoch(), 3) {
+		t.Errorf("ids %q and %q do not carry the hub's epoch %q", first.ID(), hub.LastEventID("plan:1"), hub.Epoch())
+	}
+}
+
+func TestIdleTopicsAreEvictedWithoutReusingVersions(t *testing.T) {
+	clock := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	hub := NewEventHub(10)
+	hub.now = func() time.Time { return clock }
+
+	hub.Publish("plan:1", "a", nil)
+	hub.Publish("plan:1", "b", nil)
+	_, _, _, cancel := hub.Subscribe("plan:2", "", FromLatest)
+	hub.Publish("plan:2", "a", nil)
+
+	clock = clock.Add(defaultTopicIdleTTL)
+	hub.Publish("plan:3", "a", nil)
+	if _, ok := hub.topics["plan:1"]; ok {
+		t.Error("idle topic plan:1 was kept")
+	}
+	if _, ok := hub.topics["plan:2"]; !ok {
+		t.Error("plan:2 was evicted while subscribed")
+	}
+
+	// A client that saw plan:1 at version 2 resumes a recreated topic safely
+	if hub.Version("plan:1") != 2 {
+		t.Errorf("recreated topic at version %d, want 2", hub.Version("plan:1"))
+	}
+	hub.Publish("plan:1", "c", nil)
+	if _, backlog, resumed, cancel := hub.Subscribe("plan:1", hub.Epoch(), 2); !resumed || len(backlog) != 1 || backlog[0].Type != "c" {
+		t.Errorf("resume from 2: resumed %v with %+v", resumed, backlog)
+	} else {
+		cancel()
+	}
+	if _, _, resumed, cancel := hub.Subscribe("plan:1", hub.Epoch(), 1); resumed {
+		t.Error("resumed from a version whose events were evicted")
+	} else {
+		cancel()
+	}
+
+	// Once unsubscribed and idle, plan:2 goes too
+	cancel()
+	clock = clock.Add(defaultTopicIdleTTL)
+	hub.Publish("plan:3", "b", nil)
+	if _, ok := hub.topics["plan:2"]; ok {
+		t.Error("plan:2 was kept after its subscriber left")
+	}
+}
+
+func TestHistoryIsCappedBySize(t *testing.T) {
+	hub := NewEventHub(100)
+	hub.historyBytes = 250
+
+	big := strings.Repeat("x", 100) // 102 bytes encoded
+	for i := 0; i < 5; i++ {
+		hub.Publish("plan:1", "big", big)
+	}
+	if state := hub.topics["plan:1"]; len(state.history) != 2 || state.bytes != 204 {
+		t.Errorf("retained %d events of %d bytes, want 2 of 204", len(state.history), state.bytes)
+	}
+	if _, backlog, resumed, cancel := hub.Subscribe("plan:1", hub.Epoch(), 3); !resumed || len(backlog) != 2 {
+		t.Errorf("resume from 3: resumed %v with %d events", resumed, len(backlog))
+	} else {
+		cancel()
+	}
+	if _, _, resumed, cancel := hub.Subscribe("plan:1", hub.Epoch(), 2); resumed {
+		t.Error("resumed past the size cap")
+	} else {
+		cancel()
+	}
+
+	// An event larger than the cap is still kept on its own
+	hub.Publish("plan:1", "huge", strings.Repeat("x", 300))
+	if state := hub.topics["plan:1"]; len(state.history) != 1 || state.history[0].Type != "huge" {
+		t.Errorf("history = %+v, want only the huge event", state.history)
+	}
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"io"
+	"strings"
+	"time"
+)
+
+// icalTimeFormat is the UTC date-time form used for DTSTAMP and similar properties
+const icalTimeFormat = "20060102T150405Z"
+
+// icalWriter writes iCalendar (RFC 5545) content lines, folding them at 75 octets.
+// The first write error is kept and returned by Err.
+type icalWriter struct {
+	w   io.Writer
+	err error
+}
+
+// property writes a NAME:value line; the value must already be escaped where needed
+func (iw *icalWriter) property(name, value string) {
+	iw.line(name + ":" + value)
+}
+
+// text writes a TEXT property, escaping its value
+func (iw *icalWriter) text(name, value string) {
+	iw.property(name, icalEscape(value))
+}
+
+// timestamp writes a UTC date-time property
+func (iw *icalWriter) timestamp(name string, t time.Time) {
+	iw.property(name, t.UTC().Format(icalTimeFormat))
+}
+
+func (iw *icalWriter) line(line string) {
+	if iw.err != nil {
+		return
+	}
+
+	// Fold long lines with CRLF followed by a space, never splitting a UTF-8 sequence
+	var folded strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > 75 {
+			folded.WriteString("\r\n ")
+			width = 1
+		}
+		folded.WriteRune(r)
+		width += size
+	}
+	folded.WriteString("\r\n")
+
+	_, iw.err = io.WriteString(iw.w, folded.String())
+}
+
+// Err returns the first error encountered while writing
+func (iw *icalWriter) Err() error {
+	return iw.err
+}
+
+var icalEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// icalEscape escapes a TEXT value
+func icalEscape(value string) string {
+	return icalEscaper.Replace(value)
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// CalendarEvent is one meal in an iCalendar feed
+type CalendarEvent struct {
+	UID         string
+	Summary     string
+	Description string
+	Start       time.Time
+	End         time.Time
+	Updated     time.Time
+	// ReminderBefore sets an alarm this long before Start; 0 means no alarm
+	ReminderBefore time.Duration
+	ReminderText   string
+}
+
+// calendarRefresh is how often subscribed calendar apps are asked to refetch the feed
+const calendarRefresh = "PT1H"
+
+// WriteMealCalendar writes events as an iCalendar feed with one VEVENT each
+func WriteMealCalendar(w io.Writer, name string, events []CalendarEvent) error {
+	iw := &icalWriter{w: w}
+	now := time.Now()
+
+	iw.property("BEGIN", "VCALENDAR")
+	iw.property("VERSION", "2.0")
+	iw.property("PRODID", "-//food-app//Meal Plan//EN")
+	iw.property("CALSCALE", "GREGORIAN")
+	iw.property("METHOD", "PUBLISH")
+	iw.text("X-WR-CALNAME", name)
+	iw.property("REFRESH-INTERVAL;VALUE=DURATION", calendarRefresh)
+	iw.property("X-PUBLISHED-TTL", calendarRefresh)
+
+	for _, event := range events {
+		iw.property("BEGIN", "VEVENT")
+		iw.property("UID", event.UID)
+		iw.timestamp("DTSTAMP", now)
+		if !event.Updated.IsZero() {
+			iw.timestamp("LAST-MODIFIED", event.Updated)
+		}
+		iw.timestamp("DTSTART", event.Start)
+		iw.timestamp("DTEND", event.End)
+		iw.text("SUMMARY", event.Summary)
+		if event.Description != "" {
+			iw.text("DESCRIPTION", event.Description)
+		}
+		iw.property("TRANSP", "TRANSPARENT")
+		if event.ReminderBefore > 0 {
+			iw.property("BEGIN", "VALARM")
+			iw.property("ACTION", "DISPLAY")
+			iw.property("TRIGGER", fmt.Sprintf("-PT%dM", int(event.ReminderBefore.Minutes())))
+			iw.text("DESCRIPTION", event.ReminderText)
+			iw.property("END", "VALARM")
+		}
+		iw.property("END", "VEVENT")
+	}
+
+	iw.property("END", "VCALENDAR")
+	return iw.Err()
+}
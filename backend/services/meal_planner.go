@@ -0,0 +1,487 @@
+package services
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"food-app/models"
+)
+
+// PlanDays and PlanMealTypes define the weekly grid the planner fills
+var (
+	PlanDays      = []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
+	PlanMealTypes = []string{"breakfast", "lunch", "dinner"}
+)
+
+// DefaultMaxRepeatsPerWeek is used when PlannerOptions.MaxRepeatsPerWeek is zero
+const DefaultMaxRepeatsPerWeek = 2
+
+// maxLeftoverDays limits how long batch-cooked food is scheduled after the cook
+const maxLeftoverDays = 2
+
+// Names of constraints that may be relaxed when no meal satisfies them
+const (
+	ConstraintMaxRepeats      = "max_repeats_per_week"
+	ConstraintConsecutiveDays = "no_consecutive_days"
+	ConstraintTimeBudget      = "day_time_budget"
+	ConstraintMaxDifficulty   = "max_difficulty"
+)
+
+// PlanSlot identifies one meal in the weekly grid
+type PlanSlot struct {
+	Day      string `json:"day"`
+	MealType string `json:"meal_type"`
+}
+
+// PlannerOptions configures the planner. Zero values give the default behaviour:
+// at most two of the same meal per week, never on consecutive days, and a
+// preference for varied cuisines and rotating proteins.
+type PlannerOptions struct {
+	MaxRepeatsPerWeek    int              `json:"max_repeats_per_week"`
+	AllowConsecutiveDays bool             `json:"allow_consecutive_days"`
+	AllowCuisineRepeats  bool             `json:"allow_cuisine_repeats"`
+	AllowProteinRepeats  bool             `json:"allow_protein_repeats"`
+	BatchCooking         bool             `json:"batch_cooking"` // cook dinners once and eat the leftovers for lunch
+	Seed                 *int64           `json:"seed"`          // fixes the random choices for reproducible plans
+	WeeklyBudget         float64          `json:"weekly_budget"` // prefer cheaper meals to keep the week under this total
+	TimeBudgets          map[string]int   `json:"time_budgets"`  // active cooking minutes allowed per day, e.g. {"monday": 30}
+	MaxDifficulty        string           `json:"max_difficulty"`
+	HouseholdSize        int              `json:"-"`
+	MealCosts            map[uint]float64 `json:"-"` // per-serving cost by meal ID; unknown meals cost nothing
+}
+
+// PlannedMeal is one filled slot of a generated plan
+type PlannedMeal struct {
+	PlanSlot
+	Meal       models.Meal `json:"meal"`
+	LeftoverOf *PlanSlot   `json:"leftover_of,omitempty"` // cook this slot eats leftovers from
+	Relaxed    []string    `json:"relaxed,omitempty"`     // constraints dropped to fill this slot
+}
+
+// Alternative is a candidate for a slot with the constraints it would break
+type Alternative struct {
+	Meal       models.Meal `json:"meal"`
+	Violations []string    `json:"violations"`
+	Penalty    int         `json:"penalty"` // soft score; lower is better
+}
+
+// PlanResult is a generated week plus the seed that reproduces it
+type PlanResult struct {
+	Seed          int64          `json:"seed"`
+	Meals         []PlannedMeal  `json:"meals"`
+	EstimatedCost float64        `json:"estimated_cost"`
+	OverBudget    bool           `json:"over_budget"`  // the budget could not be met with the candidates
+	CookingTime   map[string]int `json:"cooking_time"` // active cooking minutes per day; leftovers take none
+}
+
+// MealPlanner fills a week of slots from candidate meals while respecting
+// variety constraints. Hard constraints (repeat limit, consecutive days, daily
+// cooking time, difficulty cap) are relaxed only when no candidate satisfies
+// them; cuisine and protein variety are soft preferences used to rank candidates.
+type MealPlanner struct {
+	options  PlannerOptions
+	seed     int64
+	rng      *rand.Rand
+	cheapest float64 // lowest slot cost among the candidates, for budget projections
+}
+
+// NewMealPlanner creates a planner; without a seed in options one is chosen from the clock
+func NewMealPlanner(options PlannerOptions) *MealPlanner {
+	seed := time.Now().UnixNano()
+	if options.Seed != nil {
+		seed = *options.Seed
+	}
+	if options.MaxRepeatsPerWeek <= 0 {
+		options.MaxRepeatsPerWeek = DefaultMaxRepeatsPerWeek
+	}
+	if options.HouseholdSize <= 0 {
+		options.HouseholdSize = 1
+	}
+
+	return &MealPlanner{
+		options: options,
+		seed:    seed,
+		rng:     rand.New(rand.NewSource(seed)),
+	}
+}
+
+// WeekSlots returns every slot of the week in chronological order
+func WeekSlots() []PlanSlot {
+	slots := make([]PlanSlot, 0, len(PlanDays)*len(PlanMealTypes))
+	for _, day := range PlanDays {
+		for _, mealType := range PlanMealTypes {
+			slots = append(slots, PlanSlot{Day: day, MealType: mealType})
+		}
+	}
+	return slots
+}
+
+// planState tracks what has been scheduled so far
+type planState struct {
+	uses        map[uint]int
+	daysByMeal  map[uint]map[int]bool
+	cuisineUses map[string]int
+	dayCuisines map[int]map[string]bool
+	dayProteins map[int]map[string]bool
+	lastProtein string
+	spent       float64
+	slotsLeft   int
+	dayMinutes  map[int]int
+}
+
+func newPlanState() *planState {
+	return &planState{
+		uses:        map[uint]int{},
+		daysByMeal:  map[uint]map[int]bool{},
+		cuisineUses: map[string]int{},
+		dayCuisines: map[int]map[string]bool{},
+		dayProteins: map[int]map[string]bool{},
+		dayMinutes:  map[int]int{},
+	}
+}
+
+func (s *planState) record(meal models.Meal, dayIndex int) {
+	s.uses[meal.ID]++
+	if s.daysByMeal[meal.ID] == nil {
+		s.daysByMeal[meal.ID] = map[int]bool{}
+	}
+	s.daysByMeal[meal.ID][dayIndex] = true
+
+	cuisine := strings.ToLower(meal.Cuisine)
+	s.cuisineUses[cuisine]++
+	if s.dayCuisines[dayIndex] == nil {
+		s.dayCuisines[dayIndex] = map[string]bool{}
+	}
+	s.dayCuisines[dayIndex][cuisine] = true
+
+	protein := MainProtein(meal)
+	if s.dayProteins[dayIndex] == nil {
+		s.dayProteins[dayIndex] = map[string]bool{}
+	}
+	if protein != "" {
+		s.dayProteins[dayIndex][protein] = true
+	}
+}
+
+// Plan fills the week from candidates. Meals whose MealType matches a slot are
+// preferred; if none match, any candidate may be used.
+func (p *MealPlanner) Plan(candidates []models.Meal) PlanResult {
+	return p.PlanAround(candidates, nil)
+}
+
+// PlanAround fills every slot not already taken by fixed, e.g. locked entries.
+// Fixed meals count towards repeats, variety, time and budget like planned ones
+// and are returned unchanged in the result.
+func (p *MealPlanner) PlanAround(candidates []models.Meal, fixed []PlannedMeal) PlanResult {
+	result := PlanResult{Seed: p.seed, Meals: []PlannedMeal{}}
+	if len(candidates) == 0 && len(fixed) == 0 {
+		return result
+	}
+
+	sorted, byType := p.prepare(candidates)
+
+	fixedBySlot := map[PlanSlot]PlannedMeal{}
+	for _, planned := range fixed {
+		fixedBySlot[planned.PlanSlot] = planned
+	}
+
+	state := newPlanState()
+	state.slotsLeft = len(PlanDays)*len(PlanMealTypes) - len(fixedBySlot)
+	for dayIndex, day := range PlanDays {
+		for _, mealType := range PlanMealTypes {
+			if planned, ok := fixedBySlot[PlanSlot{Day: day, MealType: mealType}]; ok {
+				p.recordPlanned(state, planned, dayIndex)
+			}
+		}
+	}
+
+	leftovers := map[PlanSlot]PlanSlot{}
+
+	for dayIndex, day := range PlanDays {
+		for _, mealType := range PlanMealTypes {
+			slot := PlanSlot{Day: day, MealType: mealType}
+
+			if planned, ok := fixedBySlot[slot]; ok {
+				state.lastProtein = MainProtein(planned.Meal)
+				result.Meals = append(result.Meals, planned)
+				continue
+			}
+			if len(sorted) == 0 {
+				continue
+			}
+
+			state.slotsLeft--
+
+			planned := PlannedMeal{PlanSlot: slot}
+			if source, ok := leftovers[slot]; ok {
+				planned.Meal = findPlanned(result.Meals, source).Meal
+				planned.LeftoverOf = &source
+			} else {
+				planned.Meal, planned.Relaxed = p.choose(p.pool(slot, sorted, byType), state, dayIndex)
+				if p.options.BatchCooking && mealType == "dinner" {
+					p.scheduleLeftovers(slot, planned.Meal, dayIndex, leftovers, fixedBySlot)
+				}
+			}
+
+			p.recordPlanned(state, planned, dayIndex)
+			state.lastProtein = MainProtein(planned.Meal)
+			result.Meals = append(result.Meals, planned)
+		}
+	}
+
+	result.CookingTime = make(map[string]int, len(PlanDays))
+	for dayIndex, day := range PlanDays {
+		result.CookingTime[day] = state.dayMinutes[dayIndex]
+	}
+	result.EstimatedCost = state.spent
+	result.OverBudget = p.options.WeeklyBudget > 0 && state.spent > p.options.WeeklyBudget
+	return result
+}
+
+// Alternatives ranks the candidates for one slot of an existing plan, best
+// first: fewest broken constraints, then the lowest soft penalty. The slot's
+// current meal is left out; limit <= 0 returns every candidate.
+func (p *MealPlanner) Alternatives(candidates []models.Meal, plan []PlannedMeal, slot PlanSlot, limit int) []Alternative {
+	sorted, byType := p.prepare(candidates)
+	dayIndex, _ := models.DayIndex(slot.Day)
+	position, ok := models.SlotIndex(slot.Day, slot.MealType)
+	if !ok {
+		return []Alternative{}
+	}
+
+	// Everything but the slot itself (and leftovers eaten from it) shapes the ranking
+	state := newPlanState()
+	var current PlannedMeal
+	previous := -1
+	for _, planned := range plan {
+		if planned.PlanSlot == slot {
+			current = planned
+			continue
+		}
+		if planned.LeftoverOf != nil && *planned.LeftoverOf == slot {
+			continue
+		}
+		plannedDay, ok := models.DayIndex(planned.Day)
+		if !ok {
+			continue
+		}
+		p.recordPlanned(state, planned, plannedDay)
+		if plannedPosition, _ := models.SlotIndex(planned.Day, planned.MealType); plannedPosition < position && plannedPosition > previous {
+			previous = plannedPosition
+			state.lastProtein = MainProtein(planned.Meal)
+		}
+	}
+
+	alternatives := []Alternative{}
+	for _, meal := range p.pool(slot, sorted, byType) {
+		if current.Meal.ID != 0 && meal.ID == current.Meal.ID {
+			continue
+		}
+		alternatives = append(alternatives, Alternative{
+			Meal:       meal,
+			Violations: p.violations(meal, state, dayIndex),
+			Penalty:    p.penalty(meal, state, dayIndex),
+		})
+	}
+
+	sort.SliceStable(alternatives, func(i, j int) bool {
+		a, b := alternatives[i], alternatives[j]
+		if len(a.Violations) != len(b.Violations) {
+			return len(a.Violations) < len(b.Violations)
+		}
+		return a.Penalty < b.Penalty
+	})
+
+	if limit > 0 && len(alternatives) > limit {
+		alternatives = alternatives[:limit]
+	}
+	return alternatives
+}
+
+// prepare sorts candidates by ID, so the seed alone determines the outcome, groups
+// them by meal type and notes the cheapest for budget projections
+func (p *MealPlanner) prepare(candidates []models.Meal) ([]models.Meal, map[string][]models.Meal) {
+	sorted := make([]models.Meal, len(candidates))
+	copy(sorted, candidates)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	byType := map[string][]models.Meal{}
+	p.cheapest = 0
+	for i, meal := range sorted {
+		byType[meal.MealType] = append(byType[meal.MealType], meal)
+		if cost := p.slotCost(meal); i == 0 || cost < p.cheapest {
+			p.cheapest = cost
+		}
+	}
+	return sorted, byType
+}
+
+// pool returns the candidates for a slot's meal type, or all of them if none match
+func (p *MealPlanner) pool(slot PlanSlot, sorted []models.Meal, byType map[string][]models.Meal) []models.Meal {
+	if pool := byType[slot.MealType]; len(pool) > 0 {
+		return pool
+	}
+	return sorted
+}
+
+// recordPlanned adds a filled slot to the state; leftovers take no cooking time
+func (p *MealPlanner) recordPlanned(state *planState, planned PlannedMeal, dayIndex int) {
+	state.record(planned.Meal, dayIndex)
+	state.spent += p.slotCost(planned.Meal)
+	if planned.LeftoverOf == nil {
+		state.dayMinutes[dayIndex] += planned.Meal.ActiveTime()
+	}
+}
+
+// scheduleLeftovers reserves the following days' lunches while the dinner has servings to spare
+func (p *MealPlanner) scheduleLeftovers(source PlanSlot, meal models.Meal, dayIndex int, leftovers map[PlanSlot]PlanSlot, fixed map[PlanSlot]PlannedMeal) {
+	headcount := p.options.HouseholdSize
+	spare := meal.Servings - headcount
+	for next := dayIndex + 1; next < len(PlanDays) && next <= dayIndex+maxLeftoverDays && spare >= headcount; next++ {
+		slot := PlanSlot{Day: PlanDays[next], MealType: "lunch"}
+		if _, taken := leftovers[slot]; taken {
+			break
+		}
+		if _, taken := fixed[slot]; taken {
+			break
+		}
+		leftovers[slot] = source
+		spare -= headcount
+	}
+}
+
+// choose picks a meal for a slot: first the candidates violating the fewest hard
+// constraints, then the best soft score, breaking near-ties with the RNG
+func (p *MealPlanner) choose(pool []models.Meal, state *planState, dayIndex int) (models.Meal, []string) {
+	type scored struct {
+		meal       models.Meal
+		violations []string
+		penalty    int
+	}
+
+	var best []scored
+	bestViolations := -1
+	bestPenalty := 0
+
+	for _, meal := range pool {
+		violations := p.violations(meal, state, dayIndex)
+		penalty := p.penalty(meal, state, dayIndex)
+
+		switch {
+		case bestViolations == -1 || len(violations) < bestViolations:
+			best = []scored{{meal, violations, penalty}}
+			bestViolations = len(violations)
+			bestPenalty = penalty
+		case len(violations) == bestViolations:
+			best = append(best, scored{meal, violations, penalty})
+			if penalty < bestPenalty {
+				bestPenalty = penalty
+			}
+		}
+	}
+
+	// Near-ties keep some variety between runs with different seeds
+	var shortlist []scored
+	for _, candidate := range best {
+		if candidate.penalty <= bestPenalty+1 {
+			shortlist = append(shortlist, candidate)
+		}
+	}
+
+	picked := shortlist[p.rng.Intn(len(shortlist))]
+	return picked.meal, picked.violations
+}
+
+// violations lists the hard constraints a meal would break in this slot
+func (p *MealPlanner) violations(meal models.Meal, state *planState, dayIndex int) []string {
+	broken := []string{}
+	if state.uses[meal.ID] >= p.options.MaxRepeatsPerWeek {
+		broken = append(broken, ConstraintMaxRepeats)
+	}
+	if !p.options.AllowConsecutiveDays {
+		days := state.daysByMeal[meal.ID]
+		if days[dayIndex] || days[dayIndex-1] || days[dayIndex+1] {
+			broken = append(broken, ConstraintConsecutiveDays)
+		}
+	}
+	if budget := p.options.TimeBudgets[PlanDays[dayIndex]]; budget > 0 {
+		if state.dayMinutes[dayIndex]+meal.ActiveTime() > budget {
+			broken = append(broken, ConstraintTimeBudget)
+		}
+	}
+	if maxRank := models.DifficultyRank(p.options.MaxDifficulty); maxRank > 0 {
+		if models.DifficultyRank(meal.Difficulty) > maxRank {
+			broken = append(broken, ConstraintMaxDifficulty)
+		}
+	}
+	return broken
+}
+
+// penalty scores soft preferences; lower is better
+func (p *MealPlanner) penalty(meal models.Meal, state *planState, dayIndex int) int {
+	penalty := state.uses[meal.ID] * 3
+
+	if !p.options.AllowCuisineRepeats {
+		cuisine := strings.ToLower(meal.Cuisine)
+		penalty += state.cuisineUses[cuisine]
+		if state.dayCuisines[dayIndex][cuisine] {
+			penalty += 2
+		}
+	}
+
+	if !p.options.AllowProteinRepeats {
+		if protein := MainProtein(meal); protein != "" {
+			if protein == state.lastProtein {
+				penalty += 3
+			}
+			if state.dayProteins[dayIndex][protein] {
+				penalty += 2
+			}
+		}
+	}
+
+	// When the day's time budget has to be broken, break it by as little as possible
+	if budget := p.options.TimeBudgets[PlanDays[dayIndex]]; budget > 0 {
+		if over := state.dayMinutes[dayIndex] + meal.ActiveTime() - budget; over > 0 {
+			penalty += over / 10
+		}
+	}
+
+	if budget := p.options.WeeklyBudget; budget > 0 {
+		// Over budget if even the cheapest meals for the remaining slots can't make up for it
+		cost := p.slotCost(meal)
+		projected := state.spent + cost + p.cheapest*float64(state.slotsLeft)
+		if projected > budget {
+			allowance := budget / float64(len(PlanDays)*len(PlanMealTypes))
+			penalty += 10 + int(cost/allowance)
+		}
+	}
+
+	return penalty
+}
+
+// slotCost is what serving a meal to the household costs in one slot
+func (p *MealPlanner) slotCost(meal models.Meal) float64 {
+	return p.options.MealCosts[meal.ID] * float64(p.options.HouseholdSize)
+}
+
+// MainProtein returns the first protein-category ingredient of a meal, if its ingredient lines are loaded
+func MainProtein(meal models.Meal) string {
+	for _, line := range meal.IngredientLines {
+		if line.Ingredient.Category == "protein" {
+			return strings.ToLower(line.Ingredient.Name)
+		}
+	}
+	return ""
+}
+
+func findPlanned(meals []PlannedMeal, slot PlanSlot) PlannedMeal {
+	for _, planned := range meals {
+		if planned.PlanSlot == slot {
+			return planned
+		}
+	}
+	return PlannedMeal{}
+}
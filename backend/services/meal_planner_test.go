@@ -0,0 +1,168 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+
+	"food-app/models"
+)
+
+var (
+	testCuisines = []string{"italian", "mexican", "thai", "indian"}
+	testProteins = []string{"chicken", "beef", "tofu", "salmon"}
+)
+
+// testMeals makes perType meals of each plan meal type. Proteins and cuisines
+// rotate through the meals, offset per meal type so no two meals share both.
+func testMeals(perType int) []models.Meal {
+	var meals []models.Meal
+	for t, mealType := range PlanMealTypes {
+		for i := 0; i < perType; i++ {
+			protein := testProteins[i%len(testProteins)]
+			meals = append(meals, models.Meal{
+				ID:       uint(len(meals) + 1),
+				Name:     fmt.Sprintf("%s %d", mealType, i),
+				MealType: mealType,
+				Cuisine:  testCuisines[(i+t)%len(testCuisines)],
+				IngredientLines: []models.MealIngredient{{
+					Ingredient: models.Ingredient{Name: protein, Category: "protein"},
+				}},
+			})
+		}
+	}
+	return meals
+}
+
+// planWith plans a week from meals with a fixed seed
+func planWith(t *testing.T, options PlannerOptions, meals []models.Meal) PlanResult {
+	t.Helper()
+
+	seed := int64(11)
+	options.Seed = &seed
+	result := NewMealPlanner(options).Plan(meals)
+	if len(result.Meals) != len(WeekSlots()) {
+		t.Fatalf("planned %d slots, want %d", len(result.Meals), len(WeekSlots()))
+	}
+	return result
+}
+
+// mealDays lists the day indexes each meal is planned on
+func mealDays(result PlanResult) map[uint][]int {
+	days := map[uint][]int{}
+	for _, planned := range result.Meals {
+		day, _ := models.DayIndex(planned.Day)
+		days[planned.Meal.ID] = append(days[planned.Meal.ID], day)
+	}
+	return days
+}
+
+func TestPlannerHardConstraints(t *testing.T) {
+	tests := []struct {
+		name       string
+		options    PlannerOptions
+		perType    int
+		maxRepeats int
+	}{
+		{"default repeats", PlannerOptions{}, 4, DefaultMaxRepeatsPerWeek},
+		{"no repeats", PlannerOptions{MaxRepeatsPerWeek: 1}, 7, 1},
+		{"three repeats", PlannerOptions{MaxRepeatsPerWeek: 3}, 3, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := planWith(t, tt.options, testMeals(tt.perType))
+
+			for _, planned := range result.Meals {
+				if len(planned.Relaxed) > 0 {
+					t.Errorf("%s %s relaxed %v", planned.Day, planned.MealType, planned.Relaxed)
+				}
+				if planned.Meal.MealType != planned.MealType {
+					t.Errorf("%s %s got a %s", planned.Day, planned.MealType, planned.Meal.MealType)
+				}
+			}
+			for mealID, days := range mealDays(result) {
+				if len(days) > tt.maxRepeats {
+					t.Errorf("meal %d planned %d times, max %d", mealID, len(days), tt.maxRepeats)
+				}
+				for i := 1; i < len(days); i++ {
+					if days[i]-days[i-1] <= 1 {
+						t.Errorf("meal %d planned on days %v", mealID, days)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestPlannerRotatesCuisinesAndProteins(t *testing.T) {
+	// Rotation is a soft preference, so this holds for the fixed seed, not every seed
+	result := planWith(t, PlannerOptions{MaxRepeatsPerWeek: 3}, testMeals(4))
+
+	for i := 1; i < len(result.Meals); i++ {
+		previous, planned := MainProtein(result.Meals[i-1].Meal), MainProtein(result.Meals[i].Meal)
+		if planned == previous {
+			t.Errorf("%s %s repeats %s from the slot before", result.Meals[i].Day, result.Meals[i].MealType, planned)
+		}
+	}
+
+	for _, day := range PlanDays {
+		cuisines := map[string]bool{}
+		for _, planned := range result.Meals {
+			if planned.Day != day {
+				continue
+			}
+			if cuisines[planned.Meal.Cuisine] {
+				t.Errorf("%s has %s twice", day, planned.Meal.Cuisine)
+			}
+			cuisines[planned.Meal.Cuisine] = true
+		}
+	}
+}
+
+func TestPlannerRelaxesWhenThePoolIsTooSmall(t *testing.T) {
+	result := planWith(t, PlannerOptions{}, testMeals(1))
+
+	for _, planned := range result.Meals {
+		relaxed := map[string]bool{}
+		for _, constraint := range planned.Relaxed {
+			relaxed[constraint] = true
+		}
+		// Monday is the only day either constraint holds
+		if planned.Day == "monday" {
+			if len(relaxed) > 0 {
+				t.Errorf("monday %s relaxed %v", planned.MealType, planned.Relaxed)
+			}
+			continue
+		}
+		if !relaxed[ConstraintConsecutiveDays] {
+			t.Errorf("%s %s did not relax %s", planned.Day, planned.MealType, ConstraintConsecutiveDays)
+		}
+		if planned.Day != "tuesday" && !relaxed[ConstraintMaxRepeats] {
+			t.Errorf("%s %s did not relax %s", planned.Day, planned.MealType, ConstraintMaxRepeats)
+		}
+	}
+}
+
+func TestPlannerSeedReproducesThePlan(t *testing.T) {
+	meals := testMeals(4)
+	first, second := planWith(t, PlannerOptions{}, meals), planWith(t, PlannerOptions{}, meals)
+	for i := range first.Meals {
+		if first.Meals[i].Meal.ID != second.Meals[i].Meal.ID {
+			t.Errorf("%s %s differs between runs with one seed", first.Meals[i].Day, first.Meals[i].MealType)
+		}
+	}
+}
+
+func TestAlternativesRejectsUnknownSlots(t *testing.T) {
+	meals := testMeals(2)
+	result := planWith(t, PlannerOptions{}, meals)
+	planner := NewMealPlanner(PlannerOptions{})
+
+	for _, slot := range []PlanSlot{{Day: "someday", MealType: "dinner"}, {Day: "monday", MealType: "brunch"}} {
+		if alternatives := planner.Alternatives(meals, result.Meals, slot, 0); len(alternatives) != 0 {
+			t.Errorf("%+v has %d alternatives", slot, len(alternatives))
+		}
+	}
+	if alternatives := planner.Alternatives(meals, result.Meals, PlanSlot{Day: "monday", MealType: "dinner"}, 0); len(alternatives) != 1 {
+		t.Errorf("monday dinner has %d alternatives, want the other dinner", len(alternatives))
+	}
+}
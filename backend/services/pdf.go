@@ -0,0 +1,226 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Page geometry of PDFDocument, in points (US Letter with 0.75in margins)
+const (
+	pdfPageWidth  = 612.0
+	pdfPageHeight = 792.0
+	pdfMargin     = 54.0
+)
+
+// PDFDocument is a minimal text-only PDF writer using the standard Helvetica fonts,
+// enough for printable lists without a PDF dependency. Text outside Latin-1 is
+// replaced with "?", and text wider than the page wraps onto further lines.
+type PDFDocument struct {
+	pages []*bytes.Buffer
+	y     float64
+}
+
+// NewPDFDocument creates a document with one empty page
+func NewPDFDocument() *PDFDocument {
+	doc := &PDFDocument{}
+	doc.newPage()
+	return doc
+}
+
+func (d *PDFDocument) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = pdfPageHeight - pdfMargin
+}
+
+func (d *PDFDocument) page() *bytes.Buffer {
+	return d.pages[len(d.pages)-1]
+}
+
+// ensureSpace starts a new page unless height points remain above the bottom margin
+func (d *PDFDocument) ensureSpace(height float64) {
+	if d.y-height < pdfMargin {
+		d.newPage()
+	}
+}
+
+// Heading writes bold lines
+func (d *PDFDocument) Heading(text string, size float64) {
+	for _, line := range wrapPDFText(text, "F2", size, pdfPageWidth-2*pdfMargin) {
+		d.ensureSpace(size * 1.6)
+		d.y -= size * 1.4
+		d.text(pdfMargin, d.y, "F2", size, line)
+		d.y -= size * 0.2
+	}
+}
+
+// Text writes regular lines, indented by indent points
+func (d *PDFDocument) Text(text string, size, indent float64) {
+	for _, line := range wrapPDFText(text, "F1", size, pdfPageWidth-2*pdfMargin-indent) {
+		d.ensureSpace(size * 1.4)
+		d.y -= size * 1.4
+		d.text(pdfMargin+indent, d.y, "F1", size, line)
+	}
+}
+
+// Checkbox writes lines with a box in front of the first, ticked when checked.
+// Wrapped lines are indented under the text.
+func (d *PDFDocument) Checkbox(text string, size float64, checked bool) {
+	box := size * 0.8
+	indent := box + 6
+	for i, line := range wrapPDFText(text, "F1", size, pdfPageWidth-2*pdfMargin-indent) {
+		if i > 0 {
+			d.ensureSpace(size * 1.2)
+			d.y -= size * 1.2
+			d.text(pdfMargin+indent, d.y, "F1", size, line)
+			continue
+		}
+
+		d.ensureSpace(size * 1.5)
+		d.y -= size * 1.5
+
+		page := d.page()
+		fmt.Fprintf(page, "0.5 w %.2f %.2f %.2f %.2f re S\n", pdfMargin, d.y-1, box, box)
+		if checked {
+			fmt.Fprintf(page, "%.2f %.2f m %.2f %.2f l %.2f %.2f l S\n",
+				pdfMargin+box*0.2, d.y-1+box*0.5, pdfMargin+box*0.4, d.y-1+box*0.2, pdfMargin+box*0.85, d.y-1+box*0.85)
+		}
+		d.text(pdfMargin+indent, d.y, "F1", size, line)
+	}
+}
+
+// Space adds vertical space
+func (d *PDFDocument) Space(height float64) {
+	d.y -= height
+}
+
+func (d *PDFDocument) text(x, y float64, font string, size float64, text string) {
+	fmt.Fprintf(d.page(), "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, pdfString(text))
+}
+
+// WriteTo writes the complete PDF file
+func (d *PDFDocument) WriteTo(w io.Writer) (int64, error) {
+	var out bytes.Buffer
+	var offsets []int
+
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// Objects 1-4 are the catalog, page tree and fonts; each page adds a page and a content stream
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+i*2)
+	}
+
+	out.WriteString("%PDF-1.4\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, content := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 6+i*2))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.WriteTo(w)
+}
+
+// Glyph widths of Helvetica (F1) and Helvetica-Bold (F2) for ' ' through '~', in
+// thousandths of the font size, from the standard AFM metrics
+var pdfGlyphWidths = map[string][]int{
+	"F1": {
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	},
+	"F2": {
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	},
+}
+
+// pdfTextWidth measures text in points. Characters outside ASCII count as a digit.
+func pdfTextWidth(text, font string, size float64) float64 {
+	widths := pdfGlyphWidths[font]
+	total := 0
+	for _, r := range text {
+		if r >= ' ' && int(r-' ') < len(widths) {
+			total += widths[r-' ']
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// wrapPDFText breaks text into lines no wider than width, between words where it
+// can and inside a word too long for a line of its own
+func wrapPDFText(text, font string, size, width float64) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if pdfTextWidth(candidate, font, size) <= width {
+			line = candidate
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+
+		// Split a word that is wider than a whole line
+		line = ""
+		for _, r := range word {
+			if line != "" && pdfTextWidth(line+string(r), font, size) > width {
+				lines = append(lines, line)
+				line = ""
+			}
+			line += string(r)
+		}
+	}
+	if line != "" || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// pdfString escapes text for a PDF literal string, mapping it to Latin-1
+func pdfString(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32:
+			b.WriteByte(' ')
+		case r < 256:
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
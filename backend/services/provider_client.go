@@ -0,0 +1,412 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrCircuitOpen is returned while a provider is cooling down after repeated failures
+	ErrCircuitOpen = errors.New("provider temporarily unavailable")
+	// ErrQuotaExhausted is returned once a provider's daily request quota is used up
+	ErrQuotaExhausted = errors.New("provider daily quota exhausted")
+)
+
+// maxProviderResponse caps how much of a provider response is read
+const maxProviderResponse = 10 << 20
+
+// ProviderError is an unsuccessful HTTP response from a recipe provider
+type ProviderError struct {
+	Provider   string
+	StatusCode int
+	Message    string        // start of the response body
+	RetryAfter time.Duration // from the Retry-After header, 0 when absent
+}
+
+func (e *ProviderError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("%s returned %d: %s", e.Provider, e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("%s returned %d", e.Provider, e.StatusCode)
+}
+
+// Unwrap makes 404 responses match ErrRecipeNotFound
+func (e *ProviderError) Unwrap() error {
+	if e.StatusCode == http.StatusNotFound {
+		return ErrRecipeNotFound
+	}
+	return nil
+}
+
+// Temporary reports whether retrying may succeed: rate limiting and server errors
+func (e *ProviderError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// UsageStore counts provider requests per day so quotas survive restarts and
+// are shared between server instances
+type UsageStore interface {
+	// Reserve records one request for provider on day (YYYY-MM-DD, UTC) and
+	// reports false, recording nothing, when limit requests were already made
+	Reserve(provider, day string, limit int) (bool, error)
+	// Used returns the number of requests recorded for provider on day
+	Used(provider, day string) (int, error)
+}
+
+// MemoryUsageStore is a UsageStore for a single process
+type MemoryUsageStore struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// NewMemoryUsageStore creates an empty in-memory usage store
+func NewMemoryUsageStore() *MemoryUsageStore {
+	return &MemoryUsageStore{counts: map[string]int{}}
+}
+
+func (s *MemoryUsageStore) Reserve(provider, day string, limit int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := provider + "/" + day
+	if s.counts[key] >= limit {
+		return false, nil
+	}
+	s.counts[key]++
+	return true, nil
+}
+
+func (s *MemoryUsageStore) Used(provider, day string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.counts[provider+"/"+day], nil
+}
+
+// ProviderClientOptions tune the retry, circuit breaker, cache and quota
+// behaviour of provider clients; zero values take the defaults noted
+type ProviderClientOptions struct {
+	HTTPClient       *http.Client  // default: 30 second timeout
+	MaxRetries       int           // retries after the first attempt; default 3, negative for none
+	BaseDelay        time.Duration // first backoff delay, doubled per retry; default 500ms
+	MaxDelay         time.Duration // backoff cap; default 10s
+	FailureThreshold int           // consecutive failures that open the circuit; default 5
+	OpenDuration     time.Duration // how long an open circuit rejects requests; default 30s
+	CacheTTL         time.Duration // how long successful responses are reused; default 10m, negative to disable
+	CacheSize        int           // cached responses kept per provider; default 256
+	Usage            UsageStore    // default: a MemoryUsageStore
+}
+
+func (o ProviderClientOptions) withDefaults() ProviderClientOptions {
+	if o.HTTPClient == nil {
+		o.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	if o.MaxRetries == 0 {
+		o.MaxRetries = 3
+	}
+	if o.BaseDelay == 0 {
+		o.BaseDelay = 500 * time.Millisecond
+	}
+	if o.MaxDelay == 0 {
+		o.MaxDelay = 10 * time.Second
+	}
+	if o.FailureThreshold == 0 {
+		o.FailureThreshold = 5
+	}
+	if o.OpenDuration == 0 {
+		o.OpenDuration = 30 * time.Second
+	}
+	if o.CacheTTL == 0 {
+		o.CacheTTL = 10 * time.Minute
+	}
+	if o.CacheSize == 0 {
+		o.CacheSize = 256
+	}
+	if o.Usage == nil {
+		o.Usage = NewMemoryUsageStore()
+	}
+	return o
+}
+
+// ProviderClient makes GET requests to one recipe provider. It retries rate
+// limiting, server and network errors with exponential backoff, stops calling a
+// failing provider for a while (circuit breaker), caches successful responses
+// and refuses requests beyond the provider's daily quota.
+type ProviderClient struct {
+	provider   string
+	dailyQuota int // 0 for unlimited
+	options    ProviderClientOptions
+	now        func() time.Time
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool // a half-open trial request is in flight
+	cache     map[string]cachedResponse
+}
+
+type cachedResponse struct {
+	body    []byte
+	expires time.Time
+}
+
+// ProviderUsage is a provider's request count for today and its circuit state
+type ProviderUsage struct {
+	Provider    string `json:"provider"`
+	RequestsDay string `json:"day"`
+	Requests    int    `json:"requests"`
+	DailyQuota  int    `json:"daily_quota"` // 0 for unlimited
+	CircuitOpen bool   `json:"circuit_open"`
+}
+
+// NewProviderClient creates a client for provider; dailyQuota 0 means unlimited
+func NewProviderClient(provider string, dailyQuota int, options ProviderClientOptions) *ProviderClient {
+	return &ProviderClient{
+		provider:   provider,
+		dailyQuota: dailyQuota,
+		options:    options.withDefaults(),
+		now:        time.Now,
+		cache:      map[string]cachedResponse{},
+	}
+}
+
+// GetJSON fetches requestURL and decodes the JSON body into out
+func (c *ProviderClient) GetJSON(ctx context.Context, requestURL string, out interface{}) error {
+	body, err := c.Get(ctx, requestURL)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %v", c.provider, err)
+	}
+	return nil
+}
+
+// Get fetches requestURL, returning the body of a 200 response. Other statuses
+// are returned as *ProviderError.
+func (c *ProviderClient) Get(ctx context.Context, requestURL string) ([]byte, error) {
+	if body, ok := c.cached(requestURL); ok {
+		return body, nil
+	}
+
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		if err := c.allow(); err != nil {
+			return nil, err
+		}
+		if err := c.reserve(); err != nil {
+			c.settleProbe()
+			return nil, err
+		}
+
+		body, err := c.do(ctx, requestURL)
+		if err != nil && ctx.Err() != nil {
+			// The caller gave up, which says nothing about the provider's health
+			c.settleProbe()
+		} else {
+			c.record(err)
+		}
+		if err == nil {
+			c.store(requestURL, body)
+			return body, nil
+		}
+		lastErr = err
+
+		var providerErr *ProviderError
+		retryable := ctx.Err() == nil && (!errors.As(err, &providerErr) || providerErr.Temporary())
+		if !retryable || attempt >= c.options.MaxRetries {
+			return nil, lastErr
+		}
+
+		delay := c.backoff(attempt)
+		if providerErr != nil && providerErr.RetryAfter > delay {
+			delay = providerErr.RetryAfter
+		}
+		if delay > c.options.MaxDelay {
+			return nil, lastErr // the provider asked us to wait longer than we will
+		}
+		select {
+		case <-ctx.Done():
+			return nil, lastErr
+		case <-time.After(delay):
+		}
+	}
+}
+
+// do performs one request
+func (c *ProviderClient) do(ctx context.Context, requestURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "food-app")
+
+	resp, err := c.options.HTTPClient.Do(req)
+	if err != nil {
+		// url.Error repeats the URL, which carries the API key
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("%s request failed: %w", c.provider, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, &ProviderError{
+			Provider:   c.provider,
+			StatusCode: resp.StatusCode,
+			Message:    cleanText(string(snippet)),
+			RetryAfter: retryAfter(resp.Header.Get("Retry-After"), c.now()),
+		}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxProviderResponse))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s response: %w", c.provider, err)
+	}
+	return body, nil
+}
+
+// backoff is BaseDelay doubled per attempt, capped at MaxDelay, with up to 50% jitter
+func (c *ProviderClient) backoff(attempt int) time.Duration {
+	delay := c.options.BaseDelay << attempt
+	if delay <= 0 || delay > c.options.MaxDelay {
+		delay = c.options.MaxDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// retryAfter reads a Retry-After header given in seconds or as an HTTP date
+func retryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(header); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// allow rejects requests while the circuit is open. Once OpenDuration has
+// passed, one trial request is let through; its outcome closes or reopens it.
+func (c *ProviderClient) allow() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failures < c.options.FailureThreshold {
+		return nil
+	}
+	if c.now().Before(c.openUntil) || c.probing {
+		return ErrCircuitOpen
+	}
+	c.probing = true
+	return nil
+}
+
+// record updates the circuit after an attempt that completed. Only failures
+// that say the provider is unhealthy count; a 404 or 401 is the caller's problem.
+func (c *ProviderClient) record(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.probing = false
+
+	var providerErr *ProviderError
+	if err == nil || (errors.As(err, &providerErr) && !providerErr.Temporary()) {
+		c.failures = 0
+		return
+	}
+	c.failures++
+	if c.failures >= c.options.FailureThreshold {
+		c.openUntil = c.now().Add(c.options.OpenDuration)
+	}
+}
+
+// settleProbe releases a half-open trial that never reached the provider or
+// was cancelled by its caller, leaving the failure count as it was
+func (c *ProviderClient) settleProbe() {
+	c.mu.Lock()
+	c.probing = false
+	c.mu.Unlock()
+}
+
+// reserve counts the request against today's quota
+func (c *ProviderClient) reserve() error {
+	if c.dailyQuota <= 0 {
+		return nil
+	}
+	ok, err := c.options.Usage.Reserve(c.provider, c.today(), c.dailyQuota)
+	if err != nil {
+		return fmt.Errorf("failed to check %s quota: %w", c.provider, err)
+	}
+	if !ok {
+		return ErrQuotaExhausted
+	}
+	return nil
+}
+
+func (c *ProviderClient) today() string {
+	return c.now().UTC().Format("2006-01-02")
+}
+
+func (c *ProviderClient) cached(requestURL string) ([]byte, bool) {
+	if c.options.CacheTTL < 0 {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.cache[requestURL]
+	if !ok || c.now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.body, true
+}
+
+// store caches a response, first dropping expired entries and, when still
+// full, the entry closest to expiry
+func (c *ProviderClient) store(requestURL string, body []byte) {
+	if c.options.CacheTTL < 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if len(c.cache) >= c.options.CacheSize {
+		oldest := ""
+		for key, entry := range c.cache {
+			if now.After(entry.expires) {
+				delete(c.cache, key)
+			} else if oldest == "" || entry.expires.Before(c.cache[oldest].expires) {
+				oldest = key
+			}
+		}
+		if len(c.cache) >= c.options.CacheSize && oldest != "" {
+			delete(c.cache, oldest)
+		}
+	}
+	c.cache[requestURL] = cachedResponse{body: body, expires: now.Add(c.options.CacheTTL)}
+}
+
+// Usage reports today's request count and whether the circuit is open
+func (c *ProviderClient) Usage() (ProviderUsage, error) {
+	usage := ProviderUsage{Provider: c.provider, RequestsDay: c.today(), DailyQuota: c.dailyQuota}
+	requests, err := c.options.Usage.Used(c.provider, usage.RequestsDay)
+	usage.Requests = requests
+
+	c.mu.Lock()
+	usage.CircuitOpen = c.failures >= c.options.FailureThreshold && c.now().Before(c.openUntil)
+	c.mu.Unlock()
+	return usage, err
+}
@@ -0,0 +1,409 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// scriptedAPI answers each request with the next status in its script,
+// repeating the last one, and counts the requests
+type scriptedAPI struct {
+	*httptest.Server
+	requests atomic.Int32
+
+	mu         sync.Mutex
+	statuses   []int
+	retryAfter string
+}
+
+func newScriptedAPI(t *testing.T, statuses ...int) *scriptedAPI {
+	api := &scriptedAPI{statuses: statuses}
+	api.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(api.requests.Add(1))
+		api.mu.Lock()
+		status := api.statuses[min(n, len(api.statuses))-1]
+		retryAfter := api.retryAfter
+		api.mu.Unlock()
+
+		if status != http.StatusOK {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			http.Error(w, "nope", status)
+			return
+		}
+		w.Write([]byte(`{"path":"` + r.URL.Path + `"}`))
+	}))
+	t.Cleanup(api.Close)
+	return api
+}
+
+// answer replaces the script, e.g. once the provider recovers
+func (api *scriptedAPI) answer(statuses ...int) {
+	api.mu.Lock()
+	api.statuses = statuses
+	api.requests.Store(0)
+	api.mu.Unlock()
+}
+
+// testClock is a settable clock for a ProviderClient
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+// newTestClient makes a client with quick retries on a test clock
+func newTestClient(quota int, options ProviderClientOptions) (*ProviderClient, *testClock) {
+	if options.BaseDelay == 0 {
+		options.BaseDelay = time.Millisecond
+	}
+	if options.MaxDelay == 0 {
+		options.MaxDelay = 20 * time.Millisecond
+	}
+	client := NewProviderClient("test", quota, options)
+	clock := &testClock{now: time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)}
+	client.now = clock.Now
+	return client, clock
+}
+
+func TestProviderClientRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		requests int32
+		status   int // of the error returned, 0 for success
+	}{
+		{"server errors then success", []int{503, 500, 200}, 3, 0},
+		{"rate limited then success", []int{429, 200}, 2, 0},
+		{"retries run out", []int{502}, 4, 502},
+		{"not found", []int{404, 200}, 1, 404},
+		{"bad request", []int{400, 200}, 1, 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newScriptedAPI(t, tt.statuses...)
+			client, _ := newTestClient(0, ProviderClientOptions{FailureThreshold: 100})
+
+			body, err := client.Get(context.Background(), api.URL+"/recipes")
+			if got := api.requests.Load(); got != tt.requests {
+				t.Errorf("made %d requests, want %d", got, tt.requests)
+			}
+			if tt.status == 0 {
+				if err != nil || string(body) != `{"path":"/recipes"}` {
+					t.Errorf("Get = %q, %v", body, err)
+				}
+				return
+			}
+			var providerErr *ProviderError
+			if !errors.As(err, &providerErr) || providerErr.StatusCode != tt.status {
+				t.Fatalf("err = %v, want status %d", err, tt.status)
+			}
+			if errors.Is(err, ErrRecipeNotFound) != (tt.status == http.StatusNotFound) {
+				t.Errorf("errors.Is(%v, ErrRecipeNotFound) is wrong", err)
+			}
+		})
+	}
+}
+
+func TestProviderClientHonorsRetryAfter(t *testing.T) {
+	api := newScriptedAPI(t, 429, 200)
+	api.retryAfter = "1"
+
+	// Longer than the client will wait: give up at once
+	client, _ := newTestClient(0, ProviderClientOptions{MaxDelay: 100 * time.Millisecond})
+	if _, err := client.Get(context.Background(), api.URL+"/a"); err == nil || api.requests.Load() != 1 {
+		t.Fatalf("Get = %v after %d requests, want the 429 after 1", err, api.requests.Load())
+	}
+
+	// Within MaxDelay: wait the full second even though backoff is a millisecond
+	api.answer(429, 200)
+	client, _ = newTestClient(0, ProviderClientOptions{MaxDelay: 2 * time.Second})
+	start := time.Now()
+	if _, err := client.Get(context.Background(), api.URL+"/a"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if waited := time.Since(start); waited < time.Second {
+		t.Errorf("retried after %v, want at least the 1s asked for", waited)
+	}
+
+	if got := retryAfter("Mon, 19 Oct 2026 12:00:30 GMT", time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)); got != 30*time.Second {
+		t.Errorf("retryAfter(date) = %v, want 30s", got)
+	}
+}
+
+func TestProviderClientCircuitBreaker(t *testing.T) {
+	api := newScriptedAPI(t, 500)
+	client, clock := newTestClient(0, ProviderClientOptions{MaxRetries: -1, FailureThreshold: 2, OpenDuration: time.Minute})
+	get := func() error {
+		_, err := client.Get(context.Background(), api.URL+"/recipes")
+		return err
+	}
+
+	get()
+	get()
+	if err := get(); !errors.Is(err, ErrCircuitOpen) || api.requests.Load() != 2 {
+		t.Fatalf("third Get = %v after %d requests, want ErrCircuitOpen after 2", err, api.requests.Load())
+	}
+	if usage, _ := client.Usage(); !usage.CircuitOpen {
+		t.Error("Usage does not report the open circuit")
+	}
+
+	// Half open: one probe goes through, and its failure reopens the circuit
+	clock.Advance(time.Minute + time.Second)
+	if err := get(); errors.Is(err, ErrCircuitOpen) || api.requests.Load() != 3 {
+		t.Fatalf("probe = %v after %d requests", err, api.requests.Load())
+	}
+	if err := get(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Get after a failed probe = %v, want ErrCircuitOpen", err)
+	}
+
+	// A successful probe closes it
+	clock.Advance(time.Minute + time.Second)
+	api.answer(200)
+	for i := 0; i < 3; i++ {
+		if err := get(); err != nil {
+			t.Fatalf("Get %d after recovery: %v", i, err)
+		}
+	}
+	if usage, _ := client.Usage(); usage.CircuitOpen {
+		t.Error("the circuit is still open after a successful probe")
+	}
+}
+
+func TestProviderClientAllowsOneProbeAtATime(t *testing.T) {
+	release := make(chan struct{})
+	arrived := make(chan struct{}, 1)
+	healthy := atomic.Bool{}
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		arrived <- struct{}{}
+		<-release
+		w.Write([]byte(`{}`))
+	}))
+	defer api.Close()
+
+	client, clock := newTestClient(0, ProviderClientOptions{MaxRetries: -1, FailureThreshold: 1, OpenDuration: time.Minute, CacheTTL: -1})
+	client.Get(context.Background(), api.URL)
+	clock.Advance(2 * time.Minute)
+	healthy.Store(true)
+
+	probe := make(chan error)
+	go func() {
+		_, err := client.Get(context.Background(), api.URL)
+		probe <- err
+	}()
+	<-arrived
+	if _, err := client.Get(context.Background(), api.URL); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Get during the probe = %v, want ErrCircuitOpen", err)
+	}
+	close(release)
+	if err := <-probe; err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if _, err := client.Get(context.Background(), api.URL); err != nil {
+		t.Errorf("Get after the probe: %v", err)
+	}
+}
+
+func TestProviderClientCancellationLeavesFailuresAlone(t *testing.T) {
+	slow := atomic.Bool{}
+	var requests atomic.Int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if slow.Load() {
+			<-r.Context().Done()
+			return
+		}
+		http.Error(w, "down", http.StatusInternalServerError)
+	}))
+	defer api.Close()
+
+	client, clock := newTestClient(0, ProviderClientOptions{MaxRetries: -1, FailureThreshold: 3, OpenDuration: time.Minute})
+	getCancelled := func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		requests.Store(0)
+		slow.Store(true)
+		go func() {
+			for requests.Load() == 0 {
+				time.Sleep(time.Millisecond)
+			}
+			cancel()
+		}()
+		if _, err := client.Get(ctx, api.URL); err == nil {
+			t.Fatal("a cancelled request succeeded")
+		}
+		slow.Store(false)
+		requests.Store(0)
+	}
+
+	client.Get(context.Background(), api.URL)
+	client.Get(context.Background(), api.URL)
+	getCancelled()
+	if usage, _ := client.Usage(); usage.CircuitOpen {
+		t.Fatal("a cancelled request counted as a failure")
+	}
+	// Had the cancellation reset the count, this third failure would not open the circuit
+	client.Get(context.Background(), api.URL)
+	if usage, _ := client.Usage(); !usage.CircuitOpen {
+		t.Fatal("the failures before the cancellation were forgotten")
+	}
+
+	// A cancelled probe frees the way for the next one
+	clock.Advance(2 * time.Minute)
+	getCancelled()
+	client.Get(context.Background(), api.URL)
+	if requests.Load() != 1 {
+		t.Errorf("the probe after a cancelled one made %d requests, want 1", requests.Load())
+	}
+}
+
+func TestProviderClientCache(t *testing.T) {
+	api := newScriptedAPI(t, 200)
+	client, clock := newTestClient(0, ProviderClientOptions{CacheTTL: time.Minute, CacheSize: 2})
+	get := func(path string) {
+		t.Helper()
+		if _, err := client.Get(context.Background(), api.URL+path); err != nil {
+			t.Fatalf("Get %s: %v", path, err)
+		}
+	}
+	expectRequests := func(want int32) {
+		t.Helper()
+		if got := api.requests.Load(); got != want {
+			t.Fatalf("%d requests, want %d", got, want)
+		}
+	}
+
+	get("/a")
+	get("/a")
+	expectRequests(1)
+
+	// Expired entries are fetched again
+	clock.Advance(61 * time.Second)
+	get("/a")
+	expectRequests(2)
+
+	// A full cache evicts the entry closest to expiry, /a
+	clock.Advance(10 * time.Second)
+	get("/b")
+	clock.Advance(10 * time.Second)
+	get("/c")
+	expectRequests(4)
+	get("/b")
+	get("/c")
+	expectRequests(4)
+	get("/a")
+	expectRequests(5)
+
+	// A negative TTL disables the cache
+	uncached, _ := newTestClient(0, ProviderClientOptions{CacheTTL: -1})
+	uncached.Get(context.Background(), api.URL+"/a")
+	uncached.Get(context.Background(), api.URL+"/a")
+	expectRequests(7)
+}
+
+func TestProviderClientDailyQuota(t *testing.T) {
+	api := newScriptedAPI(t, 500, 200)
+	client, clock := newTestClient(3, ProviderClientOptions{CacheTTL: -1})
+
+	// The retry counts against the quota too
+	if _, err := client.Get(context.Background(), api.URL+"/a"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	client.Get(context.Background(), api.URL+"/b")
+	if _, err := client.Get(context.Background(), api.URL+"/c"); !errors.Is(err, ErrQuotaExhausted) {
+		t.Fatalf("Get beyond the quota = %v, want ErrQuotaExhausted", err)
+	}
+	if api.requests.Load() != 3 {
+		t.Errorf("made %d requests, want the quota of 3", api.requests.Load())
+	}
+	if usage, err := client.Usage(); err != nil || usage.Requests != 3 || usage.DailyQuota != 3 || usage.RequestsDay != "2026-10-19" {
+		t.Errorf("Usage = %+v, %v", usage, err)
+	}
+
+	// The quota resets at midnight UTC
+	clock.Advance(12 * time.Hour)
+	if _, err := client.Get(context.Background(), api.URL+"/c"); err != nil {
+		t.Errorf("Get the next day: %v", err)
+	}
+}
+
+// denyingUsage refuses the first reservations, then allows everything
+type denyingUsage struct {
+	denials int
+}
+
+func (u *denyingUsage) Reserve(provider, day string, limit int) (bool, error) {
+	if u.denials > 0 {
+		u.denials--
+		return false, nil
+	}
+	return true, nil
+}
+
+func (u *denyingUsage) Used(provider, day string) (int, error) {
+	return 0, nil
+}
+
+func TestProviderClientQuotaDoesNotHoldTheProbe(t *testing.T) {
+	api := newScriptedAPI(t, 500, 200)
+	usage := &denyingUsage{}
+	client, clock := newTestClient(10, ProviderClientOptions{MaxRetries: -1, FailureThreshold: 1, OpenDuration: time.Minute, Usage: usage})
+
+	client.Get(context.Background(), api.URL)
+	clock.Advance(2 * time.Minute)
+
+	// The probe is refused by the quota before it reaches the provider...
+	usage.denials = 1
+	if _, err := client.Get(context.Background(), api.URL); !errors.Is(err, ErrQuotaExhausted) {
+		t.Fatalf("Get = %v, want ErrQuotaExhausted", err)
+	}
+	// ...so the next request may probe instead
+	if _, err := client.Get(context.Background(), api.URL); err != nil {
+		t.Errorf("Get after the refused probe: %v", err)
+	}
+}
+
+func TestMemoryUsageStoreReservesUnderConcurrency(t *testing.T) {
+	store := NewMemoryUsageStore()
+
+	var granted atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, _ := store.Reserve("test", "2026-10-19", 10); ok {
+				granted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if used, _ := store.Used("test", "2026-10-19"); granted.Load() != 10 || used != 10 {
+		t.Errorf("granted %d and counted %d of a quota of 10", granted.Load(), used)
+	}
+	if used, _ := store.Used("test", "2026-10-20"); used != 0 {
+		t.Errorf("the next day starts at %d", used)
+	}
+}
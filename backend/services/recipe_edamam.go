@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/url"
+	"strings"
+
+	"food-app/models"
+)
+
+// edamamProvider implements RecipeProvider for the Edamam Recipe Search API v2
+type edamamProvider struct {
+	name    string
+	appID   string
+	appKey  string
+	baseURL string
+	client  *ProviderClient
+}
+
+// edamamNutrient is one entry of Edamam's totalNutrients, for the whole recipe
+type edamamNutrient struct {
+	Quantity float64 `json:"quantity"`
+	Unit     string  `json:"unit"`
+}
+
+// edamamRecipe is the part of Edamam's recipe record we use. Edamam does not
+// publish instructions; the steps live on the source page.
+type edamamRecipe struct {
+	URI             string                    `json:"uri"`
+	Label           string                    `json:"label"`
+	Image           string                    `json:"image"`
+	URL             string                    `json:"url"`
+	Yield           float64                   `json:"yield"`
+	TotalTime       float64                   `json:"totalTime"`
+	CuisineType     []string                  `json:"cuisineType"`
+	MealType        []string                  `json:"mealType"`
+	DishType        []string                  `json:"dishType"`
+	DietLabels      []string                  `json:"dietLabels"`
+	HealthLabels    []string                  `json:"healthLabels"`
+	IngredientLines []string                  `json:"ingredientLines"`
+	TotalNutrients  map[string]edamamNutrient `json:"totalNutrients"`
+}
+
+// edamamDietTags are the health labels kept as dietary tags; Edamam lists dozens
+var edamamDietTags = map[string]bool{
+	"vegan": true, "vegetarian": true, "pescatarian": true, "paleo": true, "keto-friendly": true,
+	"gluten-free": true, "dairy-free": true, "egg-free": true, "peanut-free": true, "tree-nut-free": true,
+}
+
+func newEdamamProvider(config RecipeProviderConfig, client *ProviderClient) (*edamamProvider, error) {
+	if config.AppID == "" || config.APIKey == "" {
+		return nil, errors.New("app ID and key are required")
+	}
+	baseURL := config.BaseURL
+	if baseURL == "" {
+		baseURL = "https://api.edamam.com"
+	}
+	return &edamamProvider{
+		name:    config.Name,
+		appID:   config.AppID,
+		appKey:  config.APIKey,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  client,
+	}, nil
+}
+
+func (p *edamamProvider) Name() string {
+	return p.name
+}
+
+func (p *edamamProvider) params() url.Values {
+	params := url.Values{}
+	params.Set("type", "public")
+	params.Set("app_id", p.appID)
+	params.Set("app_key", p.appKey)
+	return params
+}
+
+func (p *edamamProvider) Search(ctx context.Context, query string, limit int) ([]ProviderRecipe, error) {
+	params := p.params()
+	params.Set("q", query)
+
+	var result struct {
+		Hits []struct {
+			Recipe edamamRecipe `json:"recipe"`
+		} `json:"hits"`
+	}
+	if err := p.client.GetJSON(ctx, p.baseURL+"/api/recipes/v2?"+params.Encode(), &result); err != nil {
+		return nil, err
+	}
+
+	// Edamam pages in twenties and takes no page size
+	recipes := []ProviderRecipe{}
+	for _, hit := range result.Hits {
+		if len(recipes) == limit {
+			break
+		}
+		recipe, err := p.wrap(hit.Recipe)
+		if err != nil {
+			return nil, err
+		}
+		recipes = append(recipes, recipe)
+	}
+	return recipes, nil
+}
+
+func (p *edamamProvider) Recipe(ctx context.Context, id string) (ProviderRecipe, error) {
+	var result struct {
+		Recipe edamamRecipe `json:"recipe"`
+	}
+	requestURL := p.baseURL + "/api/recipes/v2/" + url.PathEscape(id) + "?" + p.params().Encode()
+	if err := p.client.GetJSON(ctx, requestURL, &result); err != nil {
+		return ProviderRecipe{}, err
+	}
+	return p.wrap(result.Recipe)
+}
+
+// wrap keeps the recipe's ID, the part of its URI after "#recipe_"
+func (p *edamamProvider) wrap(record edamamRecipe) (ProviderRecipe, error) {
+	id := record.URI
+	if i := strings.LastIndex(id, "#recipe_"); i >= 0 {
+		id = id[i+len("#recipe_"):]
+	}
+	return providerRecipe(p.name, id, record.Label, record.Image, record.URL, record)
+}
+
+func (p *edamamProvider) Normalize(recipe ProviderRecipe) (ImportedRecipe, error) {
+	var record edamamRecipe
+	if err := json.Unmarshal(recipe.Data, &record); err != nil {
+		return ImportedRecipe{}, err
+	}
+
+	servings := int(math.Round(record.Yield))
+	if servings <= 0 {
+		servings = 4
+	}
+	// Edamam totals nutrients over the whole recipe; meals store them per serving
+	perServing := func(code string) float64 {
+		return RoundQuantity(record.TotalNutrients[code].Quantity / float64(servings))
+	}
+
+	tags := models.StringArray{}
+	for _, tag := range providerTags(append(record.DietLabels, record.HealthLabels...)) {
+		if edamamDietTags[tag] || strings.HasPrefix(tag, "low-") || strings.HasPrefix(tag, "high-") {
+			tags = append(tags, tag)
+		}
+	}
+
+	cuisine := "various"
+	if len(record.CuisineType) > 0 {
+		cuisine = strings.ToLower(record.CuisineType[0])
+	}
+
+	lines := make([]string, 0, len(record.IngredientLines))
+	for _, line := range record.IngredientLines {
+		if line = cleanText(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	description := ""
+	if record.URL != "" {
+		description = "Method at " + record.URL
+	}
+
+	meal := models.Meal{
+		Name:         cleanText(record.Label),
+		Description:  description,
+		ImageURL:     record.Image,
+		PrepTime:     int(record.TotalTime),
+		Servings:     servings,
+		Difficulty:   models.DifficultyMedium,
+		Cuisine:      cuisine,
+		MealType:     recipeMealType(append(record.MealType, record.DishType...)),
+		Instructions: "[]",
+		NutritionInfo: models.NutritionInfo{
+			Calories:      perServing("ENERC_KCAL"),
+			Protein:       perServing("PROCNT"),
+			Carbohydrates: perServing("CHOCDF"),
+			Fat:           perServing("FAT"),
+			Fiber:         perServing("FIBTG"),
+			Sugar:         perServing("SUGAR"),
+			Sodium:        perServing("NA"),
+		},
+		DietaryTags: tags,
+		Allergens:   models.StringArray{},
+		SourceURL:   providerSourceURL(recipe),
+	}
+	return ImportedRecipe{Meal: meal, IngredientLines: lines, Steps: []string{}}, nil
+}
@@ -0,0 +1,553 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"food-app/models"
+
+	"golang.org/x/net/html"
+)
+
+// maxRecipePageSize caps how much of a recipe page is read
+const maxRecipePageSize = 5 << 20
+
+// ErrNoRecipe is returned when a page carries no schema.org Recipe
+var ErrNoRecipe = errors.New("no schema.org Recipe found on the page")
+
+// ImportedRecipe is a recipe read from a web page, ready to be saved as a Meal.
+// IngredientLines are the page's ingredient lines as written; see package ingredients
+// for parsing them.
+type ImportedRecipe struct {
+	Meal            models.Meal `json:"meal"`
+	IngredientLines []string    `json:"ingredient_lines"`
+	Steps           []string    `json:"steps"`
+}
+
+// RecipeImporter reads schema.org/Recipe data (JSON-LD or microdata) from recipe pages
+type RecipeImporter struct {
+	Client *http.Client
+}
+
+// NewRecipeImporter creates an importer whose client refuses to connect to
+// loopback, private and link-local addresses, so user-supplied URLs cannot
+// reach internal services
+func NewRecipeImporter() *RecipeImporter {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+				ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() {
+				return fmt.Errorf("refusing to fetch recipes from %s", host)
+			}
+			return nil
+		},
+	}
+
+	return &RecipeImporter{
+		Client: &http.Client{
+			Timeout:   20 * time.Second,
+			Transport: &http.Transport{DialContext: dialer.DialContext, Proxy: http.ProxyFromEnvironment},
+		},
+	}
+}
+
+// ImportURL fetches a recipe page and parses it
+func (r *RecipeImporter) ImportURL(ctx context.Context, pageURL string) (ImportedRecipe, error) {
+	parsed, err := url.Parse(pageURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return ImportedRecipe{}, fmt.Errorf("invalid recipe URL %q", pageURL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, parsed.String(), nil)
+	if err != nil {
+		return ImportedRecipe{}, err
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	req.Header.Set("User-Agent", "food-app recipe importer")
+
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return ImportedRecipe{}, fmt.Errorf("failed to fetch recipe page: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return ImportedRecipe{}, fmt.Errorf("recipe page returned %s", resp.Status)
+	}
+
+	page, err := io.ReadAll(io.LimitReader(resp.Body, maxRecipePageSize))
+	if err != nil {
+		return ImportedRecipe{}, fmt.Errorf("failed to read recipe page: %v", err)
+	}
+
+	return ParseRecipeHTML(page, parsed.String())
+}
+
+// ParseRecipeHTML extracts the first schema.org Recipe from a page, preferring
+// JSON-LD over microdata. sourceURL resolves relative image links and is kept on the Meal.
+func ParseRecipeHTML(page []byte, sourceURL string) (ImportedRecipe, error) {
+	doc, err := html.Parse(bytes.NewReader(page))
+	if err != nil {
+		return ImportedRecipe{}, fmt.Errorf("failed to parse recipe page: %v", err)
+	}
+
+	recipe, found := findJSONLDRecipe(doc)
+	if !found {
+		recipe, found = findMicrodataRecipe(doc)
+	}
+	if !found {
+		return ImportedRecipe{}, ErrNoRecipe
+	}
+
+	imported := recipeFromSchema(recipe, sourceURL)
+	if imported.Meal.Name == "" {
+		return ImportedRecipe{}, errors.New("the recipe has no name")
+	}
+	return imported, nil
+}
+
+// findJSONLDRecipe looks through every ld+json script, including @graph lists, for a Recipe
+func findJSONLDRecipe(doc *html.Node) (map[string]interface{}, bool) {
+	var recipe map[string]interface{}
+	walkHTML(doc, func(node *html.Node) bool {
+		if recipe != nil {
+			return false
+		}
+		if node.Type != html.ElementNode || node.Data != "script" ||
+			!strings.Contains(strings.ToLower(htmlAttr(node, "type")), "ld+json") {
+			return true
+		}
+
+		var data interface{}
+		if err := json.Unmarshal([]byte(htmlText(node)), &data); err != nil {
+			return true
+		}
+		recipe, _ = findRecipeObject(data)
+		return true
+	})
+	return recipe, recipe != nil
+}
+
+func findRecipeObject(data interface{}) (map[string]interface{}, bool) {
+	switch value := data.(type) {
+	case []interface{}:
+		for _, item := range value {
+			if recipe, ok := findRecipeObject(item); ok {
+				return recipe, true
+			}
+		}
+	case map[string]interface{}:
+		if hasSchemaType(value["@type"], "Recipe") {
+			return value, true
+		}
+		if graph, ok := value["@graph"]; ok {
+			return findRecipeObject(graph)
+		}
+	}
+	return nil, false
+}
+
+func hasSchemaType(value interface{}, want string) bool {
+	for _, t := range schemaStrings(value) {
+		if t == want || strings.HasSuffix(t, "/"+want) {
+			return true
+		}
+	}
+	return false
+}
+
+// findMicrodataRecipe reads itemprop values inside an itemtype=schema.org/Recipe element
+// into the same shape as JSON-LD
+func findMicrodataRecipe(doc *html.Node) (map[string]interface{}, bool) {
+	var scope *html.Node
+	walkHTML(doc, func(node *html.Node) bool {
+		if scope == nil && node.Type == html.ElementNode && hasSchemaType(htmlAttr(node, "itemtype"), "Recipe") {
+			scope = node
+		}
+		return scope == nil
+	})
+	if scope == nil {
+		return nil, false
+	}
+
+	recipe := map[string]interface{}{}
+	walkHTML(scope, func(node *html.Node) bool {
+		if node.Type != html.ElementNode {
+			return true
+		}
+		prop := htmlAttr(node, "itemprop")
+		if prop == "" || node == scope {
+			return true
+		}
+
+		var value interface{} = microdataValue(node)
+		nestedItem := hasAttr(node, "itemscope")
+		if nestedItem {
+			// Nested items such as NutritionInformation or HowToStep keep their own properties
+			nested := map[string]interface{}{}
+			walkHTML(node, func(child *html.Node) bool {
+				if child != node && child.Type == html.ElementNode {
+					if childProp := htmlAttr(child, "itemprop"); childProp != "" {
+						nested[childProp] = microdataValue(child)
+					}
+				}
+				return true
+			})
+			if len(nested) > 0 {
+				value = nested
+			}
+		}
+
+		switch existing := recipe[prop].(type) {
+		case nil:
+			recipe[prop] = value
+		case []interface{}:
+			recipe[prop] = append(existing, value)
+		default:
+			recipe[prop] = []interface{}{existing, value}
+		}
+		return !nestedItem
+	})
+	return recipe, true
+}
+
+func microdataValue(node *html.Node) string {
+	for _, attr := range []string{"content", "datetime"} {
+		if value := htmlAttr(node, attr); value != "" {
+			return value
+		}
+	}
+	switch node.Data {
+	case "img", "audio", "video", "source":
+		return htmlAttr(node, "src")
+	case "a", "link":
+		return htmlAttr(node, "href")
+	case "meta":
+		return htmlAttr(node, "content")
+	}
+	return strings.TrimSpace(collapseSpace(htmlText(node)))
+}
+
+// recipeFromSchema maps schema.org Recipe properties onto a Meal
+func recipeFromSchema(recipe map[string]interface{}, sourceURL string) ImportedRecipe {
+	prepTime := isoDurationMinutes(schemaString(recipe["prepTime"]))
+	cookTime := isoDurationMinutes(schemaString(recipe["cookTime"]))
+	if prepTime == 0 && cookTime == 0 {
+		prepTime = isoDurationMinutes(schemaString(recipe["totalTime"]))
+	}
+
+	steps := recipeSteps(recipe["recipeInstructions"])
+	instructions, _ := json.Marshal(steps)
+
+	meal := models.Meal{
+		Name:          cleanText(schemaString(recipe["name"])),
+		Description:   cleanText(schemaString(recipe["description"])),
+		ImageURL:      resolveURL(sourceURL, recipeImage(recipe["image"])),
+		PrepTime:      prepTime,
+		CookTime:      cookTime,
+		Servings:      recipeYield(recipe["recipeYield"]),
+		Difficulty:    models.DifficultyMedium,
+		Cuisine:       strings.ToLower(firstString(recipe["recipeCuisine"])),
+		MealType:      recipeMealType(schemaStrings(recipe["recipeCategory"])),
+		Instructions:  string(instructions),
+		NutritionInfo: recipeNutrition(recipe["nutrition"]),
+		DietaryTags:   recipeDiets(recipe["suitableForDiet"]),
+		Allergens:     models.StringArray{},
+		SourceURL:     sourceURL,
+	}
+	if meal.Cuisine == "" {
+		meal.Cuisine = "various"
+	}
+
+	lines := schemaStrings(recipe["recipeIngredient"])
+	if len(lines) == 0 {
+		lines = schemaStrings(recipe["ingredients"]) // pre-2015 property name
+	}
+	ingredientLines := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if line = cleanText(line); line != "" {
+			ingredientLines = append(ingredientLines, line)
+		}
+	}
+
+	return ImportedRecipe{Meal: meal, IngredientLines: ingredientLines, Steps: steps}
+}
+
+// recipeSteps flattens text, HowToStep and HowToSection instructions into steps
+func recipeSteps(value interface{}) []string {
+	steps := []string{}
+	switch v := value.(type) {
+	case string:
+		for _, line := range strings.Split(cleanTextKeepLines(v), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				steps = append(steps, line)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			steps = append(steps, recipeSteps(item)...)
+		}
+	case map[string]interface{}:
+		if items, ok := v["itemListElement"]; ok {
+			return recipeSteps(items)
+		}
+		text := schemaString(v["text"])
+		if text == "" {
+			text = schemaString(v["name"])
+		}
+		if text = cleanText(text); text != "" {
+			steps = append(steps, text)
+		}
+	}
+	return steps
+}
+
+func recipeImage(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []interface{}:
+		if len(v) > 0 {
+			return recipeImage(v[0])
+		}
+	case map[string]interface{}:
+		return schemaString(v["url"])
+	}
+	return ""
+}
+
+var leadingNumber = regexp.MustCompile(`\d+(\.\d+)?`)
+
+// recipeYield reads servings from values such as 4, "4", "4 servings" or ["4", "4 servings"]
+func recipeYield(value interface{}) int {
+	for _, yield := range schemaStrings(value) {
+		if number := leadingNumber.FindString(yield); number != "" {
+			if servings, err := strconv.ParseFloat(number, 64); err == nil && servings >= 1 {
+				return int(servings)
+			}
+		}
+	}
+	return 4
+}
+
+func recipeMealType(categories []string) string {
+	for _, category := range categories {
+		category = strings.ToLower(category)
+		switch {
+		case strings.Contains(category, "breakfast") || strings.Contains(category, "brunch"):
+			return "breakfast"
+		case strings.Contains(category, "lunch"):
+			return "lunch"
+		case strings.Contains(category, "snack") || strings.Contains(category, "dessert") ||
+			strings.Contains(category, "appetizer"):
+			return "snack"
+		}
+	}
+	return "dinner"
+}
+
+func recipeNutrition(value interface{}) models.NutritionInfo {
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		return models.NutritionInfo{}
+	}
+
+	amount := func(key string) float64 {
+		number := leadingNumber.FindString(schemaString(fields[key]))
+		parsed, _ := strconv.ParseFloat(number, 64)
+		return parsed
+	}
+
+	sodium := amount("sodiumContent")
+	if strings.HasSuffix(strings.TrimSpace(schemaString(fields["sodiumContent"])), " g") {
+		sodium *= 1000 // NutritionInfo keeps sodium in milligrams
+	}
+
+	return models.NutritionInfo{
+		Calories:      amount("calories"),
+		Protein:       amount("proteinContent"),
+		Carbohydrates: amount("carbohydrateContent"),
+		Fat:           amount("fatContent"),
+		Fiber:         amount("fiberContent"),
+		Sugar:         amount("sugarContent"),
+		Sodium:        sodium,
+	}
+}
+
+// recipeDiets maps schema.org RestrictedDiet values such as https://schema.org/VeganDiet to tags like "vegan"
+func recipeDiets(value interface{}) models.StringArray {
+	tags := models.StringArray{}
+	for _, diet := range schemaStrings(value) {
+		diet = diet[strings.LastIndex(diet, "/")+1:]
+		diet = strings.TrimSuffix(diet, "Diet")
+		if diet == "" {
+			continue
+		}
+		var tag strings.Builder
+		for i, r := range diet {
+			if i > 0 && r >= 'A' && r <= 'Z' {
+				tag.WriteByte('-')
+			}
+			tag.WriteRune(r)
+		}
+		tags = append(tags, strings.ToLower(tag.String()))
+	}
+	return tags
+}
+
+var isoDuration = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// isoDurationMinutes converts ISO 8601 durations like PT1H30M to minutes
+func isoDurationMinutes(duration string) int {
+	match := isoDuration.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(duration)))
+	if match == nil {
+		return 0
+	}
+	days, _ := strconv.Atoi(match[1])
+	hours, _ := strconv.Atoi(match[2])
+	minutes, _ := strconv.Atoi(match[3])
+	return days*24*60 + hours*60 + minutes
+}
+
+// schemaString returns a property's text, taking the first of several values
+func schemaString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}:
+		if len(v) > 0 {
+			return schemaString(v[0])
+		}
+	case map[string]interface{}:
+		if text := schemaString(v["@value"]); text != "" {
+			return text
+		}
+		return schemaString(v["name"])
+	}
+	return ""
+}
+
+// schemaStrings returns every text value of a property
+func schemaStrings(value interface{}) []string {
+	var values []string
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			values = append(values, schemaStrings(item)...)
+		}
+	default:
+		if text := schemaString(v); text != "" {
+			values = append(values, text)
+		}
+	}
+	return values
+}
+
+func firstString(value interface{}) string {
+	if values := schemaStrings(value); len(values) > 0 {
+		return strings.TrimSpace(values[0])
+	}
+	return ""
+}
+
+func resolveURL(base, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil || base == "" {
+		return ref
+	}
+	resolved, err := baseURL.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return resolved.String()
+}
+
+// cleanText strips markup and entities that sites leave inside JSON-LD strings
+func cleanText(text string) string {
+	return strings.TrimSpace(collapseSpace(stripTags(text)))
+}
+
+func cleanTextKeepLines(text string) string {
+	text = strings.NewReplacer("<br>", "\n", "<br/>", "\n", "<br />", "\n", "</p>", "\n", "</li>", "\n").Replace(text)
+	lines := strings.Split(stripTags(text), "\n")
+	for i, line := range lines {
+		lines[i] = collapseSpace(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+func stripTags(text string) string {
+	return html.UnescapeString(htmlTag.ReplaceAllString(text, " "))
+}
+
+func collapseSpace(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// walkHTML visits nodes depth-first; when visit returns false the node's children are skipped
+func walkHTML(node *html.Node, visit func(*html.Node) bool) {
+	if !visit(node) {
+		return
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		walkHTML(child, visit)
+	}
+}
+
+func htmlAttr(node *html.Node, name string) string {
+	for _, attr := range node.Attr {
+		if attr.Key == name {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(node *html.Node, name string) bool {
+	for _, attr := range node.Attr {
+		if attr.Key == name {
+			return true
+		}
+	}
+	return false
+}
+
+func htmlText(node *html.Node) string {
+	var text strings.Builder
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			text.WriteString(n.Data)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			collect(child)
+		}
+	}
+	collect(node)
+	return text.String()
+}
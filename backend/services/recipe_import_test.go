@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"food-app/models"
+)
+
+func readRecipePage(t *testing.T, name string) []byte {
+	t.Helper()
+	page, err := os.ReadFile(filepath.Join("testdata", "recipes", name))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	return page
+}
+
+func TestParseRecipeHTML(t *testing.T) {
+	const source = "https://example.com/recipes/dinner"
+
+	tests := []struct {
+		page      string
+		meal      models.Meal
+		lines     []string
+		steps     []string
+		nutrition models.NutritionInfo
+		diets     models.StringArray
+	}{
+		{
+			page: "jsonld.html",
+			meal: models.Meal{
+				Name:        "Weeknight Chicken & Rice Curry",
+				Description: "A quick curry for busy evenings.",
+				ImageURL:    "https://example.com/images/curry.jpg",
+				PrepTime:    15,
+				CookTime:    65,
+				Servings:    4,
+				Cuisine:     "indian",
+				MealType:    "dinner",
+			},
+			lines:     []string{"2 1/2 cups rice, rinsed", "1 lb chicken thighs", "2 tbsp curry paste"},
+			steps:     []string{"Cook the rice.", "Brown the chicken.", "Stir in the curry paste and simmer."},
+			nutrition: models.NutritionInfo{Calories: 540, Protein: 32, Sodium: 1200},
+			diets:     models.StringArray{"gluten-free"},
+		},
+		{
+			page: "microdata.html",
+			meal: models.Meal{
+				Name:        "Buttermilk Pancakes",
+				Description: "Fluffy pancakes for the weekend.",
+				ImageURL:    "https://example.com/recipes/pancakes.jpg",
+				PrepTime:    10,
+				CookTime:    20,
+				Servings:    6,
+				Cuisine:     "various",
+				MealType:    "breakfast",
+			},
+			lines:     []string{"1 ½ cups flour", "2 eggs, beaten", "Salt to taste"},
+			steps:     []string{"Whisk everything together."},
+			nutrition: models.NutritionInfo{Calories: 310},
+			diets:     models.StringArray{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.page, func(t *testing.T) {
+			recipe, err := ParseRecipeHTML(readRecipePage(t, tt.page), source)
+			if err != nil {
+				t.Fatalf("ParseRecipeHTML: %v", err)
+			}
+			meal := recipe.Meal
+			got := models.Meal{
+				Name: meal.Name, Description: meal.Description, ImageURL: meal.ImageURL,
+				PrepTime: meal.PrepTime, CookTime: meal.CookTime, Servings: meal.Servings,
+				Cuisine: meal.Cuisine, MealType: meal.MealType,
+			}
+			if !reflect.DeepEqual(got, tt.meal) {
+				t.Errorf("meal = %+v\nwant %+v", got, tt.meal)
+			}
+			if meal.SourceURL != source || meal.Difficulty != models.DifficultyMedium {
+				t.Errorf("source %q, difficulty %q", meal.SourceURL, meal.Difficulty)
+			}
+			if meal.NutritionInfo != tt.nutrition {
+				t.Errorf("nutrition = %+v, want %+v", meal.NutritionInfo, tt.nutrition)
+			}
+			if !reflect.DeepEqual(meal.DietaryTags, tt.diets) {
+				t.Errorf("diets = %q, want %q", meal.DietaryTags, tt.diets)
+			}
+			if !reflect.DeepEqual(recipe.IngredientLines, tt.lines) {
+				t.Errorf("ingredient lines = %q, want %q", recipe.IngredientLines, tt.lines)
+			}
+			if !reflect.DeepEqual(recipe.Steps, tt.steps) {
+				t.Errorf("steps = %q, want %q", recipe.Steps, tt.steps)
+			}
+		})
+	}
+}
+
+func TestParseRecipeHTMLWithoutARecipe(t *testing.T) {
+	if _, err := ParseRecipeHTML(readRecipePage(t, "no_recipe.html"), "https://example.com/about"); !errors.Is(err, ErrNoRecipe) {
+		t.Errorf("err = %v, want ErrNoRecipe", err)
+	}
+}
+
+func TestImportURL(t *testing.T) {
+	page := readRecipePage(t, "microdata.html")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pancakes" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(page)
+	}))
+	defer server.Close()
+
+	importer := &RecipeImporter{Client: server.Client()}
+	recipe, err := importer.ImportURL(context.Background(), server.URL+"/pancakes")
+	if err != nil || recipe.Meal.Name != "Buttermilk Pancakes" || recipe.Meal.ImageURL != server.URL+"/pancakes.jpg" {
+		t.Errorf("ImportURL = %q with image %q, %v", recipe.Meal.Name, recipe.Meal.ImageURL, err)
+	}
+	if _, err := importer.ImportURL(context.Background(), server.URL+"/missing"); err == nil {
+		t.Error("a 404 page imported")
+	}
+	if _, err := importer.ImportURL(context.Background(), "file:///etc/passwd"); err == nil {
+		t.Error("a file URL imported")
+	}
+
+	// The default client will not reach loopback addresses such as the test server
+	if _, err := NewRecipeImporter().ImportURL(context.Background(), server.URL+"/pancakes"); err == nil {
+		t.Error("the default importer fetched from a loopback address")
+	}
+}
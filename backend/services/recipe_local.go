@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"food-app/models"
+)
+
+// localRecipeProvider implements RecipeProvider over a catalog file (see Catalog),
+// read once when the provider is created
+type localRecipeProvider struct {
+	name  string
+	meals []CatalogMeal
+}
+
+func newLocalRecipeProvider(config RecipeProviderConfig) (*localRecipeProvider, error) {
+	if config.Path == "" {
+		return nil, errors.New("catalog file path is required")
+	}
+	file, err := os.Open(config.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	format := CatalogJSON
+	if strings.EqualFold(filepath.Ext(config.Path), ".csv") {
+		format = CatalogCSV
+	}
+	catalog, err := ReadCatalog(file, format)
+	if err == nil {
+		err = catalog.Validate()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &localRecipeProvider{name: config.Name, meals: catalog.Meals}, nil
+}
+
+func (p *localRecipeProvider) Name() string {
+	return p.name
+}
+
+// Search matches the query against names, descriptions, cuisines and tags, ignoring case
+func (p *localRecipeProvider) Search(_ context.Context, query string, limit int) ([]ProviderRecipe, error) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	recipes := []ProviderRecipe{}
+	for _, meal := range p.meals {
+		if len(recipes) == limit {
+			break
+		}
+		text := strings.ToLower(strings.Join(append([]string{meal.Name, meal.Description, meal.Cuisine}, meal.DietaryTags...), " "))
+		if !strings.Contains(text, query) {
+			continue
+		}
+		recipe, err := p.wrap(meal)
+		if err != nil {
+			return nil, err
+		}
+		recipes = append(recipes, recipe)
+	}
+	return recipes, nil
+}
+
+func (p *localRecipeProvider) Recipe(_ context.Context, id string) (ProviderRecipe, error) {
+	for _, meal := range p.meals {
+		if localRecipeID(meal.Name) == id {
+			return p.wrap(meal)
+		}
+	}
+	return ProviderRecipe{}, ErrRecipeNotFound
+}
+
+func (p *localRecipeProvider) wrap(meal CatalogMeal) (ProviderRecipe, error) {
+	return providerRecipe(p.name, localRecipeID(meal.Name), meal.Name, meal.ImageURL, meal.SourceURL, meal)
+}
+
+func (p *localRecipeProvider) Normalize(recipe ProviderRecipe) (ImportedRecipe, error) {
+	var entry CatalogMeal
+	if err := json.Unmarshal(recipe.Data, &entry); err != nil {
+		return ImportedRecipe{}, err
+	}
+
+	// Instructions are stored as a JSON list of steps or as plain text
+	var steps []string
+	if json.Unmarshal([]byte(entry.Instructions), &steps) != nil {
+		steps = recipeSteps(entry.Instructions)
+	}
+	instructions, _ := json.Marshal(steps)
+
+	lines := make([]string, 0, len(entry.Ingredients))
+	for _, ingredient := range entry.Ingredients {
+		line := ingredient.Name
+		if ingredient.Quantity > 0 {
+			line = strings.TrimSpace(strconv.FormatFloat(ingredient.Quantity, 'f', -1, 64) + " " + ingredient.Unit + " " + ingredient.Name)
+		}
+		if ingredient.Note != "" {
+			line += ", " + ingredient.Note
+		}
+		lines = append(lines, strings.Join(strings.Fields(line), " "))
+	}
+
+	servings := entry.Servings
+	if servings <= 0 {
+		servings = 4
+	}
+
+	meal := models.Meal{
+		Name:          entry.Name,
+		Description:   entry.Description,
+		ImageURL:      entry.ImageURL,
+		PrepTime:      entry.PrepTime,
+		CookTime:      entry.CookTime,
+		Servings:      servings,
+		Difficulty:    entry.Difficulty,
+		Cuisine:       entry.Cuisine,
+		MealType:      entry.MealType,
+		Instructions:  string(instructions),
+		NutritionInfo: entry.Nutrition,
+		DietaryTags:   models.StringArray(append([]string{}, entry.DietaryTags...)),
+		Allergens:     models.StringArray(append([]string{}, entry.Allergens...)),
+		SourceURL:     providerSourceURL(recipe),
+	}
+	return ImportedRecipe{Meal: meal, IngredientLines: lines, Steps: steps}, nil
+}
+
+// localRecipeID turns a meal name into a URL-safe ID, e.g. "Bean Tacos" is "bean-tacos"
+func localRecipeID(name string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), "-")
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+)
+
+// Supported recipe provider kinds
+const (
+	RecipeProviderSpoonacular = "spoonacular"
+	RecipeProviderEdamam      = "edamam"
+	RecipeProviderTheMealDB   = "themealdb"
+	RecipeProviderLocal       = "local"
+)
+
+var (
+	// ErrUnknownRecipeProvider is returned when a provider name is not configured
+	ErrUnknownRecipeProvider = errors.New("unknown recipe provider")
+	// ErrRecipeNotFound is returned when a provider has no recipe with the requested ID
+	ErrRecipeNotFound = errors.New("recipe not found")
+)
+
+// RecipeProviderConfig describes one recipe source
+type RecipeProviderConfig struct {
+	Name    string // name used in URLs; defaults to Kind
+	Kind    string // spoonacular, edamam, themealdb or local
+	APIKey  string // Spoonacular apiKey, Edamam app_key, TheMealDB key
+	AppID   string // Edamam app_id
+	BaseURL string // API root, overridable to point at a mock server
+	Path    string // catalog file for the local provider
+	// DailyQuota caps requests to the provider per UTC day; 0 for unlimited
+	DailyQuota int
+}
+
+// ProviderRecipe is a recipe as a provider returned it. Data holds the
+// provider's own record, which Normalize turns into a Meal.
+type ProviderRecipe struct {
+	Provider  string          `json:"provider"`
+	ID        string          `json:"id"`
+	Title     string          `json:"title"`
+	ImageURL  string          `json:"image_url"`
+	SourceURL string          `json:"source_url"`
+	Data      json.RawMessage `json:"-"`
+}
+
+// RecipeProvider searches and fetches recipes from one source
+type RecipeProvider interface {
+	Name() string
+	Search(ctx context.Context, query string, limit int) ([]ProviderRecipe, error)
+	Recipe(ctx context.Context, id string) (ProviderRecipe, error)
+	// Normalize maps a recipe from this provider onto a Meal with its ingredient lines and steps
+	Normalize(recipe ProviderRecipe) (ImportedRecipe, error)
+}
+
+// RecipeProviders holds the configured recipe providers
+type RecipeProviders struct {
+	providers map[string]RecipeProvider
+	clients   map[string]*ProviderClient
+}
+
+// NewRecipeProviders builds providers from config, each remote provider with
+// its own ProviderClient. Providers missing their credentials or catalog file
+// are skipped and logged; there is no fallback data.
+func NewRecipeProviders(configs []RecipeProviderConfig, options ProviderClientOptions) *RecipeProviders {
+	options = options.withDefaults()
+	registry := &RecipeProviders{providers: map[string]RecipeProvider{}, clients: map[string]*ProviderClient{}}
+
+	for _, config := range configs {
+		if config.Name == "" {
+			config.Name = config.Kind
+		}
+
+		client := NewProviderClient(config.Name, config.DailyQuota, options)
+		var provider RecipeProvider
+		var err error
+		switch config.Kind {
+		case RecipeProviderSpoonacular:
+			provider, err = newSpoonacularProvider(config, client)
+		case RecipeProviderEdamam:
+			provider, err = newEdamamProvider(config, client)
+		case RecipeProviderTheMealDB:
+			provider = newTheMealDBProvider(config, client)
+		case RecipeProviderLocal:
+			provider, err = newLocalRecipeProvider(config)
+		default:
+			err = fmt.Errorf("unsupported provider kind %q", config.Kind)
+		}
+
+		if err != nil {
+			log.Printf("Skipping recipe provider %s: %v", config.Name, err)
+			continue
+		}
+		registry.providers[config.Name] = provider
+		if config.Kind != RecipeProviderLocal {
+			registry.clients[config.Name] = client
+		}
+	}
+
+	return registry
+}
+
+// Provider returns the provider registered under name
+func (r *RecipeProviders) Provider(name string) (RecipeProvider, error) {
+	if r == nil {
+		return nil, ErrUnknownRecipeProvider
+	}
+	provider, ok := r.providers[name]
+	if !ok {
+		return nil, ErrUnknownRecipeProvider
+	}
+	return provider, nil
+}
+
+// ProviderNames lists the enabled providers in alphabetical order
+func (r *RecipeProviders) ProviderNames() []string {
+	names := []string{}
+	if r == nil {
+		return names
+	}
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Usage reports today's request counts and circuit state of the remote providers
+func (r *RecipeProviders) Usage() ([]ProviderUsage, error) {
+	usage := []ProviderUsage{}
+	if r == nil {
+		return usage, nil
+	}
+	for _, name := range r.ProviderNames() {
+		client, ok := r.clients[name]
+		if !ok {
+			continue
+		}
+		entry, err := client.Usage()
+		if err != nil {
+			return nil, err
+		}
+		usage = append(usage, entry)
+	}
+	return usage, nil
+}
+
+// providerRecipe wraps a provider's record, keeping it for Normalize
+func providerRecipe(provider, id, title, imageURL, sourceURL string, record interface{}) (ProviderRecipe, error) {
+	data, err := json.Marshal(record)
+	return ProviderRecipe{
+		Provider:  provider,
+		ID:        id,
+		Title:     title,
+		ImageURL:  imageURL,
+		SourceURL: sourceURL,
+		Data:      data,
+	}, err
+}
+
+// providerSourceURL is the page a provider recipe came from, or a provider:id
+// reference when it has none, so re-imports are recognized either way
+func providerSourceURL(recipe ProviderRecipe) string {
+	if recipe.SourceURL != "" {
+		return recipe.SourceURL
+	}
+	return recipe.Provider + ":" + recipe.ID
+}
@@ -0,0 +1,349 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"food-app/models"
+)
+
+// fixtureAPI serves testdata/providers files by request path and records the queries it saw
+type fixtureAPI struct {
+	*httptest.Server
+	queries map[string]url.Values
+}
+
+func newFixtureAPI(t *testing.T, files map[string]string) *fixtureAPI {
+	t.Helper()
+
+	api := &fixtureAPI{queries: map[string]url.Values{}}
+	api.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		api.queries[r.URL.Path] = r.URL.Query()
+		file, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		body, err := os.ReadFile(filepath.Join("testdata", "providers", file))
+		if err != nil {
+			t.Errorf("read fixture: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+	t.Cleanup(api.Close)
+	return api
+}
+
+// fixtureProvider builds a single provider from config against the fixture API
+func fixtureProvider(t *testing.T, api *fixtureAPI, config RecipeProviderConfig) RecipeProvider {
+	t.Helper()
+
+	config.BaseURL = api.URL
+	providers := NewRecipeProviders([]RecipeProviderConfig{config}, ProviderClientOptions{
+		HTTPClient: api.Client(),
+		MaxRetries: -1,
+		CacheTTL:   -1,
+	})
+	provider, err := providers.Provider(config.Kind)
+	if err != nil {
+		t.Fatalf("provider %s was not registered: %v", config.Kind, err)
+	}
+	return provider
+}
+
+// normalized searches and normalizes every result
+func normalized(t *testing.T, provider RecipeProvider, query string, limit int) []ImportedRecipe {
+	t.Helper()
+
+	recipes, err := provider.Search(context.Background(), query, limit)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	imported := make([]ImportedRecipe, 0, len(recipes))
+	for _, recipe := range recipes {
+		if recipe.Provider != provider.Name() {
+			t.Errorf("%s recipe %s claims provider %q", provider.Name(), recipe.ID, recipe.Provider)
+		}
+		meal, err := provider.Normalize(recipe)
+		if err != nil {
+			t.Fatalf("Normalize %s: %v", recipe.ID, err)
+		}
+		imported = append(imported, meal)
+	}
+	return imported
+}
+
+func expectRecipe(t *testing.T, got ImportedRecipe, want models.Meal, lines, steps []string) {
+	t.Helper()
+
+	meal := got.Meal
+	summary := models.Meal{
+		Name: meal.Name, Description: meal.Description, ImageURL: meal.ImageURL,
+		PrepTime: meal.PrepTime, CookTime: meal.CookTime, Servings: meal.Servings,
+		Difficulty: meal.Difficulty, Cuisine: meal.Cuisine, MealType: meal.MealType,
+		NutritionInfo: meal.NutritionInfo, SourceURL: meal.SourceURL,
+		DietaryTags: meal.DietaryTags, Allergens: meal.Allergens,
+	}
+	if !reflect.DeepEqual(summary, want) {
+		t.Errorf("meal = %+v\nwant %+v", summary, want)
+	}
+	if !reflect.DeepEqual(got.IngredientLines, lines) {
+		t.Errorf("%s lines = %q, want %q", meal.Name, got.IngredientLines, lines)
+	}
+	if !reflect.DeepEqual(got.Steps, steps) {
+		t.Errorf("%s steps = %q, want %q", meal.Name, got.Steps, steps)
+	}
+}
+
+func TestSpoonacularProvider(t *testing.T) {
+	api := newFixtureAPI(t, map[string]string{
+		"/recipes/complexSearch":      "spoonacular_search.json",
+		"/recipes/716406/information": "spoonacular_recipe.json",
+	})
+	provider := fixtureProvider(t, api, RecipeProviderConfig{Kind: RecipeProviderSpoonacular, APIKey: "secret"})
+
+	recipes := normalized(t, provider, "soup", 5)
+	query := api.queries["/recipes/complexSearch"]
+	if query.Get("apiKey") != "secret" || query.Get("query") != "soup" || query.Get("number") != "5" {
+		t.Errorf("search query = %v", query)
+	}
+	if len(recipes) != 2 {
+		t.Fatalf("got %d recipes, want 2", len(recipes))
+	}
+	expectRecipe(t, recipes[0], models.Meal{
+		Name:          "Red Lentil Soup with Chicken and Turnips",
+		Description:   "A hearty soup.",
+		ImageURL:      "https://img.spoonacular.com/recipes/715415-556x370.jpg",
+		PrepTime:      10,
+		CookTime:      45,
+		Servings:      8,
+		Difficulty:    models.DifficultyMedium,
+		Cuisine:       "mediterranean",
+		MealType:      "lunch",
+		NutritionInfo: models.NutritionInfo{Calories: 477.1, Protein: 27.5, Sodium: 616.3},
+		SourceURL:     "https://example.com/red-lentil-soup",
+		DietaryTags:   models.StringArray{"gluten-free", "dairy-free"},
+		Allergens:     models.StringArray{},
+	}, []string{"2 cups red lentils", "1 lb chicken breast, diced"}, []string{"Rinse the lentils.", "Simmer with the stock."})
+
+	// Without split times, analyzed steps or servings the fallbacks apply
+	expectRecipe(t, recipes[1], models.Meal{
+		Name:        "Asparagus and Pea Soup",
+		PrepTime:    20,
+		Servings:    4,
+		Difficulty:  models.DifficultyMedium,
+		Cuisine:     "various",
+		MealType:    "dinner",
+		SourceURL:   "spoonacular:716406",
+		DietaryTags: models.StringArray{},
+		Allergens:   models.StringArray{},
+	}, []string{}, []string{"Blanch the asparagus.", "Blend with the peas."})
+
+	recipe, err := provider.Recipe(context.Background(), "716406")
+	if err != nil || recipe.ID != "716406" || recipe.Title != "Asparagus and Pea Soup" {
+		t.Errorf("Recipe = %+v, %v", recipe, err)
+	}
+	for _, id := range []string{"999", "not-a-number"} {
+		if _, err := provider.Recipe(context.Background(), id); !errors.Is(err, ErrRecipeNotFound) {
+			t.Errorf("Recipe(%q) = %v, want ErrRecipeNotFound", id, err)
+		}
+	}
+}
+
+func TestEdamamProvider(t *testing.T) {
+	api := newFixtureAPI(t, map[string]string{
+		"/api/recipes/v2": "edamam_search.json",
+		"/api/recipes/v2/8275bb28647abcedef0baaf2dcf34f8b": "edamam_recipe.json",
+	})
+	provider := fixtureProvider(t, api, RecipeProviderConfig{Kind: RecipeProviderEdamam, AppID: "app", APIKey: "secret"})
+
+	recipes := normalized(t, provider, "chicken", 1)
+	query := api.queries["/api/recipes/v2"]
+	if query.Get("app_id") != "app" || query.Get("app_key") != "secret" || query.Get("q") != "chicken" || query.Get("type") != "public" {
+		t.Errorf("search query = %v", query)
+	}
+	if len(recipes) != 1 {
+		t.Fatalf("got %d recipes, want the limit of 1", len(recipes))
+	}
+	// Totals are divided by the yield; only diet-like health labels are kept
+	expectRecipe(t, recipes[0], models.Meal{
+		Name:          "Chicken Vesuvio",
+		Description:   "Method at https://example.com/chicken-vesuvio",
+		ImageURL:      "https://edamam-product-images.example/chicken-vesuvio.jpg",
+		PrepTime:      60,
+		Servings:      4,
+		Difficulty:    models.DifficultyMedium,
+		Cuisine:       "italian",
+		MealType:      "lunch",
+		NutritionInfo: models.NutritionInfo{Calories: 1057.01, Protein: 58.03, Sodium: 500},
+		SourceURL:     "https://example.com/chicken-vesuvio",
+		DietaryTags:   models.StringArray{"low-carb", "gluten-free", "dairy-free"},
+		Allergens:     models.StringArray{},
+	}, []string{"1/2 cup olive oil", "4 chicken thighs"}, []string{})
+
+	recipe, err := provider.Recipe(context.Background(), "8275bb28647abcedef0baaf2dcf34f8b")
+	if err != nil || recipe.ID != "8275bb28647abcedef0baaf2dcf34f8b" {
+		t.Fatalf("Recipe = %+v, %v", recipe, err)
+	}
+	imported, err := provider.Normalize(recipe)
+	if err != nil || imported.Meal.Servings != 4 || imported.Meal.MealType != "breakfast" || imported.Meal.SourceURL != "edamam:"+recipe.ID {
+		t.Errorf("Normalize = %+v, %v", imported.Meal, err)
+	}
+	if _, err := provider.Recipe(context.Background(), "missing"); !errors.Is(err, ErrRecipeNotFound) {
+		t.Errorf("Recipe(missing) = %v, want ErrRecipeNotFound", err)
+	}
+}
+
+func TestTheMealDBProvider(t *testing.T) {
+	api := newFixtureAPI(t, map[string]string{
+		"/api/json/v1/1/search.php":   "themealdb_search.json",
+		"/api/json/v1/1/lookup.php":   "themealdb_empty.json",
+		"/api/json/v1/key/search.php": "themealdb_search.json",
+	})
+	provider := fixtureProvider(t, api, RecipeProviderConfig{Kind: RecipeProviderTheMealDB})
+
+	recipes := normalized(t, provider, "chicken", 10)
+	if query := api.queries["/api/json/v1/1/search.php"]; query.Get("s") != "chicken" {
+		t.Errorf("search query = %v", query)
+	}
+	if len(recipes) != 2 {
+		t.Fatalf("got %d recipes, want 2", len(recipes))
+	}
+	expectRecipe(t, recipes[0], models.Meal{
+		Name:        "Teriyaki Chicken Casserole",
+		ImageURL:    "https://www.themealdb.com/images/media/meals/wvpsxx1468256321.jpg",
+		Servings:    4,
+		Difficulty:  models.DifficultyMedium,
+		Cuisine:     "japanese",
+		MealType:    "dinner",
+		SourceURL:   "themealdb:52772",
+		DietaryTags: models.StringArray{"meat", "casserole"},
+		Allergens:   models.StringArray{},
+	}, []string{"3/4 cup soy sauce", "water"},
+		[]string{"Preheat oven to 350F.", "Combine the soy sauce and sugar.", "Bake for 30 minutes."})
+	expectRecipe(t, recipes[1], models.Meal{
+		Name:        "Baingan Bharta",
+		Servings:    4,
+		Difficulty:  models.DifficultyMedium,
+		Cuisine:     "various",
+		MealType:    "dinner",
+		SourceURL:   "https://example.com/baingan-bharta",
+		DietaryTags: models.StringArray{"vegetarian"},
+		Allergens:   models.StringArray{},
+	}, []string{"1 large aubergine"}, []string{"Roast the aubergine."})
+
+	// Unknown IDs come back as a 200 with no meals
+	if _, err := provider.Recipe(context.Background(), "1"); !errors.Is(err, ErrRecipeNotFound) {
+		t.Errorf("Recipe of an unknown ID = %v, want ErrRecipeNotFound", err)
+	}
+
+	keyed := fixtureProvider(t, api, RecipeProviderConfig{Kind: RecipeProviderTheMealDB, APIKey: "key"})
+	if recipes, err := keyed.Search(context.Background(), "chicken", 1); err != nil || len(recipes) != 1 {
+		t.Errorf("search with an API key = %d recipes, %v", len(recipes), err)
+	}
+}
+
+func TestLocalRecipeProvider(t *testing.T) {
+	providers := NewRecipeProviders([]RecipeProviderConfig{
+		{Kind: RecipeProviderLocal, Path: filepath.Join("testdata", "providers", "local_catalog.json")},
+	}, ProviderClientOptions{})
+	provider, err := providers.Provider(RecipeProviderLocal)
+	if err != nil {
+		t.Fatalf("local provider was not registered: %v", err)
+	}
+
+	for _, tt := range []struct {
+		query string
+		limit int
+		want  []string
+	}{
+		{"MEXICAN", 10, []string{"bean-tacos"}},
+		{"vegetarian", 10, []string{"bean-tacos", "oat-porridge"}},
+		{"vegetarian", 1, []string{"bean-tacos"}},
+		{"oats", 10, []string{"oat-porridge"}},
+		{"sushi", 10, []string{}},
+	} {
+		recipes, err := provider.Search(context.Background(), tt.query, tt.limit)
+		ids := []string{}
+		for _, recipe := range recipes {
+			ids = append(ids, recipe.ID)
+		}
+		if err != nil || !reflect.DeepEqual(ids, tt.want) {
+			t.Errorf("Search(%q, %d) = %q, %v, want %q", tt.query, tt.limit, ids, err, tt.want)
+		}
+	}
+
+	recipes := normalized(t, provider, "", 10)
+	expectRecipe(t, recipes[0], models.Meal{
+		Name:          "Bean Tacos",
+		Description:   "Weeknight tacos",
+		PrepTime:      10,
+		CookTime:      15,
+		Servings:      4,
+		Difficulty:    "easy",
+		Cuisine:       "mexican",
+		MealType:      "dinner",
+		NutritionInfo: models.NutritionInfo{Calories: 420},
+		SourceURL:     "local:bean-tacos",
+		DietaryTags:   models.StringArray{"vegetarian"},
+		Allergens:     models.StringArray{"gluten"},
+	}, []string{"1.5 cup Black Beans, drained", "8 Tortillas", "Salsa"}, []string{"Warm the beans.", "Fill the tortillas."})
+	if steps := recipes[1].Steps; !reflect.DeepEqual(steps, []string{"Simmer the oats in milk."}) {
+		t.Errorf("plain-text instructions became %q", steps)
+	}
+
+	if recipe, err := provider.Recipe(context.Background(), "oat-porridge"); err != nil || recipe.Title != "Oat Porridge" {
+		t.Errorf("Recipe(oat-porridge) = %+v, %v", recipe, err)
+	}
+	if _, err := provider.Recipe(context.Background(), "sushi"); !errors.Is(err, ErrRecipeNotFound) {
+		t.Errorf("Recipe(sushi) = %v, want ErrRecipeNotFound", err)
+	}
+}
+
+func TestNewRecipeProvidersSelectsConfiguredProviders(t *testing.T) {
+	catalog := filepath.Join("testdata", "providers", "local_catalog.json")
+	providers := NewRecipeProviders([]RecipeProviderConfig{
+		{Kind: RecipeProviderSpoonacular},                                    // no API key
+		{Kind: RecipeProviderEdamam, APIKey: "secret"},                       // no app ID
+		{Kind: RecipeProviderLocal, Path: filepath.Join("testdata", "none")}, // no file
+		{Kind: "allrecipes"},                                                 // unsupported
+		{Name: "mealdb", Kind: RecipeProviderTheMealDB},
+		{Name: "spoon", Kind: RecipeProviderSpoonacular, APIKey: "secret", DailyQuota: 150},
+		{Name: "house", Kind: RecipeProviderLocal, Path: catalog},
+	}, ProviderClientOptions{})
+
+	if names := providers.ProviderNames(); !reflect.DeepEqual(names, []string{"house", "mealdb", "spoon"}) {
+		t.Errorf("ProviderNames = %q", names)
+	}
+	for _, name := range []string{"house", "mealdb", "spoon"} {
+		if provider, err := providers.Provider(name); err != nil || provider.Name() != name {
+			t.Errorf("Provider(%q) = %v, %v", name, provider, err)
+		}
+	}
+	for _, name := range []string{"spoonacular", "edamam", "local", "allrecipes"} {
+		if _, err := providers.Provider(name); !errors.Is(err, ErrUnknownRecipeProvider) {
+			t.Errorf("Provider(%q) = %v, want ErrUnknownRecipeProvider", name, err)
+		}
+	}
+
+	// Only remote providers have request usage
+	usage, err := providers.Usage()
+	if err != nil || len(usage) != 2 || usage[0].Provider != "mealdb" || usage[1].Provider != "spoon" || usage[1].DailyQuota != 150 {
+		t.Errorf("Usage = %+v, %v", usage, err)
+	}
+
+	var none *RecipeProviders
+	if _, err := none.Provider("spoon"); !errors.Is(err, ErrUnknownRecipeProvider) || len(none.ProviderNames()) != 0 {
+		t.Errorf("a nil registry should have no providers")
+	}
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"food-app/models"
+)
+
+// spoonacularProvider implements RecipeProvider for the Spoonacular API
+type spoonacularProvider struct {
+	name    string
+	apiKey  string
+	baseURL string
+	client  *ProviderClient
+}
+
+// spoonacularRecipe is the part of Spoonacular's recipe information we use
+type spoonacularRecipe struct {
+	ID                   int      `json:"id"`
+	Title                string   `json:"title"`
+	Image                string   `json:"image"`
+	SourceURL            string   `json:"sourceUrl"`
+	Summary              string   `json:"summary"`
+	ReadyInMinutes       int      `json:"readyInMinutes"`
+	PreparationMinutes   int      `json:"preparationMinutes"`
+	CookingMinutes       int      `json:"cookingMinutes"`
+	Servings             int      `json:"servings"`
+	Cuisines             []string `json:"cuisines"`
+	DishTypes            []string `json:"dishTypes"`
+	Diets                []string `json:"diets"`
+	Instructions         string   `json:"instructions"`
+	AnalyzedInstructions []struct {
+		Steps []struct {
+			Step string `json:"step"`
+		} `json:"steps"`
+	} `json:"analyzedInstructions"`
+	ExtendedIngredients []struct {
+		Original string `json:"original"`
+	} `json:"extendedIngredients"`
+	Nutrition struct {
+		Nutrients []struct {
+			Name   string  `json:"name"`
+			Amount float64 `json:"amount"`
+			Unit   string  `json:"unit"`
+		} `json:"nutrients"`
+	} `json:"nutrition"`
+}
+
+func newSpoonacularProvider(config RecipeProviderConfig, client *ProviderClient) (*spoonacularProvider, error) {
+	if config.APIKey == "" {
+		return nil, errors.New("API key is required")
+	}
+	baseURL := config.BaseURL
+	if baseURL == "" {
+		baseURL = "https://api.spoonacular.com"
+	}
+	return &spoonacularProvider{name: config.Name, apiKey: config.APIKey, baseURL: strings.TrimRight(baseURL, "/"), client: client}, nil
+}
+
+func (p *spoonacularProvider) Name() string {
+	return p.name
+}
+
+func (p *spoonacularProvider) Search(ctx context.Context, query string, limit int) ([]ProviderRecipe, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("number", strconv.Itoa(limit))
+	params.Set("addRecipeInformation", "true")
+	params.Set("addRecipeNutrition", "true")
+	params.Set("fillIngredients", "true")
+	params.Set("apiKey", p.apiKey)
+
+	var result struct {
+		Results []spoonacularRecipe `json:"results"`
+	}
+	if err := p.client.GetJSON(ctx, p.baseURL+"/recipes/complexSearch?"+params.Encode(), &result); err != nil {
+		return nil, err
+	}
+
+	recipes := make([]ProviderRecipe, 0, len(result.Results))
+	for _, record := range result.Results {
+		recipe, err := p.wrap(record)
+		if err != nil {
+			return nil, err
+		}
+		recipes = append(recipes, recipe)
+	}
+	return recipes, nil
+}
+
+func (p *spoonacularProvider) Recipe(ctx context.Context, id string) (ProviderRecipe, error) {
+	if _, err := strconv.Atoi(id); err != nil {
+		return ProviderRecipe{}, ErrRecipeNotFound
+	}
+	params := url.Values{}
+	params.Set("includeNutrition", "true")
+	params.Set("apiKey", p.apiKey)
+
+	var record spoonacularRecipe
+	requestURL := p.baseURL + "/recipes/" + url.PathEscape(id) + "/information?" + params.Encode()
+	if err := p.client.GetJSON(ctx, requestURL, &record); err != nil {
+		return ProviderRecipe{}, err
+	}
+	return p.wrap(record)
+}
+
+func (p *spoonacularProvider) wrap(record spoonacularRecipe) (ProviderRecipe, error) {
+	return providerRecipe(p.name, strconv.Itoa(record.ID), record.Title, record.Image, record.SourceURL, record)
+}
+
+func (p *spoonacularProvider) Normalize(recipe ProviderRecipe) (ImportedRecipe, error) {
+	var record spoonacularRecipe
+	if err := json.Unmarshal(recipe.Data, &record); err != nil {
+		return ImportedRecipe{}, err
+	}
+
+	steps := []string{}
+	for _, group := range record.AnalyzedInstructions {
+		for _, step := range group.Steps {
+			if text := cleanText(step.Step); text != "" {
+				steps = append(steps, text)
+			}
+		}
+	}
+	if len(steps) == 0 {
+		steps = recipeSteps(record.Instructions)
+	}
+	instructions, _ := json.Marshal(steps)
+
+	nutrition := models.NutritionInfo{}
+	for _, nutrient := range record.Nutrition.Nutrients {
+		switch nutrient.Name {
+		case "Calories":
+			nutrition.Calories = nutrient.Amount
+		case "Protein":
+			nutrition.Protein = nutrient.Amount
+		case "Carbohydrates":
+			nutrition.Carbohydrates = nutrient.Amount
+		case "Fat":
+			nutrition.Fat = nutrient.Amount
+		case "Fiber":
+			nutrition.Fiber = nutrient.Amount
+		case "Sugar":
+			nutrition.Sugar = nutrient.Amount
+		case "Sodium":
+			nutrition.Sodium = nutrient.Amount
+		}
+	}
+
+	prepTime, cookTime := record.PreparationMinutes, record.CookingMinutes
+	if prepTime <= 0 && cookTime <= 0 {
+		prepTime, cookTime = record.ReadyInMinutes, 0
+	}
+	servings := record.Servings
+	if servings <= 0 {
+		servings = 4
+	}
+
+	cuisine := "various"
+	if len(record.Cuisines) > 0 {
+		cuisine = strings.ToLower(record.Cuisines[0])
+	}
+
+	lines := make([]string, 0, len(record.ExtendedIngredients))
+	for _, ingredient := range record.ExtendedIngredients {
+		if line := cleanText(ingredient.Original); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	meal := models.Meal{
+		Name:          cleanText(record.Title),
+		Description:   cleanText(record.Summary),
+		ImageURL:      record.Image,
+		PrepTime:      max(prepTime, 0),
+		CookTime:      max(cookTime, 0),
+		Servings:      servings,
+		Difficulty:    models.DifficultyMedium,
+		Cuisine:       cuisine,
+		MealType:      recipeMealType(record.DishTypes),
+		Instructions:  string(instructions),
+		NutritionInfo: nutrition,
+		DietaryTags:   providerTags(record.Diets),
+		Allergens:     models.StringArray{},
+		SourceURL:     providerSourceURL(recipe),
+	}
+	return ImportedRecipe{Meal: meal, IngredientLines: lines, Steps: steps}, nil
+}
+
+// providerTags lower-cases and hyphenates labels such as "Gluten Free" or "Low-Carb"
+func providerTags(labels []string) models.StringArray {
+	tags := models.StringArray{}
+	for _, label := range labels {
+		if tag := strings.Join(strings.Fields(strings.ToLower(label)), "-"); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
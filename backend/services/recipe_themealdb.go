@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"food-app/models"
+)
+
+// theMealDBProvider implements RecipeProvider for TheMealDB
+type theMealDBProvider struct {
+	name   string
+	apiURL string // base URL with the API version and key
+	client *ProviderClient
+}
+
+// theMealDBRecipe is a TheMealDB meal. Ingredients and measures come as
+// numbered fields strIngredient1..20 and strMeasure1..20, so the record is
+// kept as a map.
+type theMealDBRecipe map[string]interface{}
+
+func (r theMealDBRecipe) field(name string) string {
+	value, _ := r[name].(string)
+	return strings.TrimSpace(value)
+}
+
+func newTheMealDBProvider(config RecipeProviderConfig, client *ProviderClient) *theMealDBProvider {
+	baseURL := config.BaseURL
+	if baseURL == "" {
+		baseURL = "https://www.themealdb.com"
+	}
+	key := config.APIKey
+	if key == "" {
+		key = "1" // TheMealDB's public development key
+	}
+	return &theMealDBProvider{
+		name:   config.Name,
+		apiURL: strings.TrimRight(baseURL, "/") + "/api/json/v1/" + url.PathEscape(key),
+		client: client,
+	}
+}
+
+func (p *theMealDBProvider) Name() string {
+	return p.name
+}
+
+func (p *theMealDBProvider) Search(ctx context.Context, query string, limit int) ([]ProviderRecipe, error) {
+	records, err := p.fetch(ctx, "/search.php?"+url.Values{"s": {query}}.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	recipes := []ProviderRecipe{}
+	for _, record := range records {
+		if len(recipes) == limit {
+			break
+		}
+		recipe, err := p.wrap(record)
+		if err != nil {
+			return nil, err
+		}
+		recipes = append(recipes, recipe)
+	}
+	return recipes, nil
+}
+
+func (p *theMealDBProvider) Recipe(ctx context.Context, id string) (ProviderRecipe, error) {
+	records, err := p.fetch(ctx, "/lookup.php?"+url.Values{"i": {id}}.Encode())
+	if err != nil {
+		return ProviderRecipe{}, err
+	}
+	// An unknown ID is a 200 with "meals": null
+	if len(records) == 0 {
+		return ProviderRecipe{}, ErrRecipeNotFound
+	}
+	return p.wrap(records[0])
+}
+
+func (p *theMealDBProvider) fetch(ctx context.Context, path string) ([]theMealDBRecipe, error) {
+	var result struct {
+		Meals []theMealDBRecipe `json:"meals"`
+	}
+	if err := p.client.GetJSON(ctx, p.apiURL+path, &result); err != nil {
+		return nil, err
+	}
+	return result.Meals, nil
+}
+
+func (p *theMealDBProvider) wrap(record theMealDBRecipe) (ProviderRecipe, error) {
+	return providerRecipe(p.name, record.field("idMeal"), record.field("strMeal"), record.field("strMealThumb"), record.field("strSource"), record)
+}
+
+func (p *theMealDBProvider) Normalize(recipe ProviderRecipe) (ImportedRecipe, error) {
+	var record theMealDBRecipe
+	if err := json.Unmarshal(recipe.Data, &record); err != nil {
+		return ImportedRecipe{}, err
+	}
+
+	steps := recipeSteps(record.field("strInstructions"))
+	instructions, _ := json.Marshal(steps)
+
+	lines := []string{}
+	for i := 1; i <= 20; i++ {
+		ingredient := record.field(fmt.Sprintf("strIngredient%d", i))
+		if ingredient == "" {
+			continue
+		}
+		line := ingredient
+		if measure := record.field(fmt.Sprintf("strMeasure%d", i)); measure != "" {
+			line = measure + " " + ingredient
+		}
+		lines = append(lines, cleanText(line))
+	}
+
+	category := record.field("strCategory")
+	tags := providerTags(strings.Split(record.field("strTags"), ","))
+	if strings.EqualFold(category, "vegan") || strings.EqualFold(category, "vegetarian") {
+		tags = append(tags, strings.ToLower(category))
+	}
+
+	cuisine := strings.ToLower(record.field("strArea"))
+	if cuisine == "" || cuisine == "unknown" {
+		cuisine = "various"
+	}
+
+	meal := models.Meal{
+		Name:         record.field("strMeal"),
+		ImageURL:     record.field("strMealThumb"),
+		Servings:     4, // TheMealDB does not give yields
+		Difficulty:   models.DifficultyMedium,
+		Cuisine:      cuisine,
+		MealType:     recipeMealType([]string{category}),
+		Instructions: string(instructions),
+		DietaryTags:  tags,
+		Allergens:    models.StringArray{},
+		SourceURL:    providerSourceURL(recipe),
+	}
+	return ImportedRecipe{Meal: meal, IngredientLines: lines, Steps: steps}, nil
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Shopping list export formats
+const (
+	ExportText     = "txt"
+	ExportMarkdown = "md"
+	ExportCSV      = "csv"
+	ExportPDF      = "pdf"
+	ExportICS      = "ics" // VTODO reminders
+)
+
+// ExportFormats maps each export format to its content type
+var ExportFormats = map[string]string{
+	ExportText:     "text/plain; charset=utf-8",
+	ExportMarkdown: "text/markdown; charset=utf-8",
+	ExportCSV:      "text/csv; charset=utf-8",
+	ExportPDF:      "application/pdf",
+	ExportICS:      "text/calendar; charset=utf-8",
+}
+
+// ExportItem is one shopping list line ready for export
+type ExportItem struct {
+	ID        uint
+	Name      string
+	Quantity  float64
+	Unit      string
+	Notes     string
+	Purchased bool
+}
+
+// Amount is the item's quantity in display units, e.g. "1.5 kg"
+func (i ExportItem) Amount() string {
+	return FormatQuantity(i.Quantity, i.Unit)
+}
+
+// Label is the item's name followed by its amount
+func (i ExportItem) Label() string {
+	if amount := i.Amount(); amount != "" {
+		return i.Name + " (" + amount + ")"
+	}
+	return i.Name
+}
+
+// ExportGroup is a category or aisle of an exported list
+type ExportGroup struct {
+	Name  string
+	Items []ExportItem
+}
+
+// ExportList is a grouped shopping list, independent of the storage models
+type ExportList struct {
+	ID     uint
+	Title  string
+	Groups []ExportGroup
+}
+
+// WriteShoppingList writes the list in one of the ExportFormats
+func WriteShoppingList(w io.Writer, format string, list ExportList) error {
+	switch format {
+	case ExportText:
+		return writeShoppingListText(w, list)
+	case ExportMarkdown:
+		return writeShoppingListMarkdown(w, list)
+	case ExportCSV:
+		return writeShoppingListCSV(w, list)
+	case ExportPDF:
+		return writeShoppingListPDF(w, list)
+	case ExportICS:
+		return writeShoppingListICS(w, list)
+	}
+	return fmt.Errorf("unknown export format %q", format)
+}
+
+func writeShoppingListText(w io.Writer, list ExportList) error {
+	if _, err := fmt.Fprintf(w, "%s\n", list.Title); err != nil {
+		return err
+	}
+	for _, group := range list.Groups {
+		if _, err := fmt.Fprintf(w, "\n%s\n", group.Name); err != nil {
+			return err
+		}
+		for _, item := range group.Items {
+			mark := " "
+			if item.Purchased {
+				mark = "x"
+			}
+			line := fmt.Sprintf("  [%s] %s", mark, oneLine(item.Label()))
+			if item.Notes != "" {
+				line += " - " + oneLine(item.Notes)
+			}
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// oneLine joins the lines of text with spaces, so an item stays on its own line
+func oneLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// markdownEscaper escapes the characters that would format text as Markdown or
+// HTML, and keeps it on one line
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "#", `\#`, "|", `\|`, "~", `\~`,
+	"\r\n", " ", "\n", " ", "\r", " ",
+)
+
+// markdownEscape makes user text safe to place inside a Markdown line
+func markdownEscape(text string) string {
+	return markdownEscaper.Replace(text)
+}
+
+func writeShoppingListMarkdown(w io.Writer, list ExportList) error {
+	if _, err := fmt.Fprintf(w, "# %s\n", markdownEscape(list.Title)); err != nil {
+		return err
+	}
+	for _, group := range list.Groups {
+		if _, err := fmt.Fprintf(w, "\n## %s\n\n", markdownEscape(group.Name)); err != nil {
+			return err
+		}
+		for _, item := range group.Items {
+			mark := " "
+			if item.Purchased {
+				mark = "x"
+			}
+			line := fmt.Sprintf("- [%s] %s", mark, markdownEscape(item.Name))
+			if amount := item.Amount(); amount != "" {
+				line += " (" + amount + ")"
+			}
+			if item.Notes != "" {
+				line += " _" + markdownEscape(item.Notes) + "_"
+			}
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeShoppingListCSV(w io.Writer, list ExportList) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"group", "item", "quantity", "unit", "purchased", "notes"})
+	for _, group := range list.Groups {
+		for _, item := range group.Items {
+			quantity, unit := DisplayQuantity(item.Quantity, item.Unit)
+			out.Write([]string{
+				group.Name,
+				item.Name,
+				strconv.FormatFloat(RoundQuantity(quantity), 'f', -1, 64),
+				unit,
+				strconv.FormatBool(item.Purchased),
+				item.Notes,
+			})
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+func writeShoppingListPDF(w io.Writer, list ExportList) error {
+	doc := NewPDFDocument()
+	doc.Heading(list.Title, 18)
+	for _, group := range list.Groups {
+		doc.Space(6)
+		doc.Heading(group.Name, 13)
+		for _, item := range group.Items {
+			doc.Checkbox(item.Label(), 11, item.Purchased)
+			if item.Notes != "" {
+				doc.Text(item.Notes, 9, 16)
+			}
+		}
+	}
+	_, err := doc.WriteTo(w)
+	return err
+}
+
+// writeShoppingListICS writes one VTODO per item, which Apple Reminders and most
+// task apps import as a list of to-dos categorized by group
+func writeShoppingListICS(w io.Writer, list ExportList) error {
+	iw := &icalWriter{w: w}
+	now := time.Now()
+
+	iw.property("BEGIN", "VCALENDAR")
+	iw.property("VERSION", "2.0")
+	iw.property("PRODID", "-//food-app//Shopping List//EN")
+	iw.text("X-WR-CALNAME", list.Title)
+	for _, group := range list.Groups {
+		for _, item := range group.Items {
+			iw.property("BEGIN", "VTODO")
+			iw.property("UID", fmt.Sprintf("shopping-item-%d-%d@food-app", list.ID, item.ID))
+			iw.timestamp("DTSTAMP", now)
+			iw.text("SUMMARY", item.Label())
+			iw.text("CATEGORIES", group.Name)
+			if item.Notes != "" {
+				iw.text("DESCRIPTION", item.Notes)
+			}
+			if item.Purchased {
+				iw.property("STATUS", "COMPLETED")
+			} else {
+				iw.property("STATUS", "NEEDS-ACTION")
+			}
+			iw.property("END", "VTODO")
+		}
+	}
+	iw.property("END", "VCALENDAR")
+	return iw.Err()
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the testdata/export golden files")
+
+// awkwardList has text that each format must escape, fold or wrap
+func awkwardList() ExportList {
+	return ExportList{
+		ID:    7,
+		Title: "Week of Oct 19, 2026 *draft*",
+		Groups: []ExportGroup{
+			{Name: "Meat, fish & eggs", Items: []ExportItem{
+				{ID: 1, Name: `Chicken thighs, "bone-in"`, Quantity: 680.4, Unit: "g", Notes: "from the butcher;\nask for skin on"},
+				{ID: 2, Name: "*Free-range* eggs_large [dozen]", Quantity: 12, Unit: "piece", Purchased: true},
+			}},
+			{Name: "Dairy", Items: []ExportItem{
+				{ID: 3, Name: "Crème fraîche, full-fat, the thick Normandy kind your recipe asks for by name, from the deli counter", Quantity: 2, Unit: "cup"},
+				{ID: 4, Name: `Back\slash <b>cheese</b> | #1 ~ever~`, Notes: "`raw` milk"},
+			}},
+		},
+	}
+}
+
+// dtstamp is the only line of an export that changes between runs
+var dtstamp = regexp.MustCompile(`DTSTAMP:\d{8}T\d{6}Z`)
+
+func TestShoppingListExportsMatchGoldenFiles(t *testing.T) {
+	for _, format := range []string{ExportText, ExportMarkdown, ExportCSV, ExportICS} {
+		t.Run(format, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := WriteShoppingList(&out, format, awkwardList()); err != nil {
+				t.Fatalf("WriteShoppingList: %v", err)
+			}
+			got := dtstamp.ReplaceAll(out.Bytes(), []byte("DTSTAMP:20261019T120000Z"))
+
+			path := filepath.Join("testdata", "export", "list."+format)
+			if *updateGolden {
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("read golden file (run with -update to create it): %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s export differs from %s:\n%s", format, path, got)
+			}
+		})
+	}
+}
+
+func TestICalLinesFoldWithinOctetLimit(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteShoppingList(&out, ExportICS, awkwardList()); err != nil {
+		t.Fatalf("WriteShoppingList: %v", err)
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line of %d octets: %q", len(line), line)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("folding split a character: %q", line)
+		}
+	}
+	unfolded := strings.ReplaceAll(out.String(), "\r\n ", "")
+	if !strings.Contains(unfolded, `SUMMARY:Crème fraîche\, full-fat\, the thick Normandy kind your recipe asks for by name\, from the deli counter (2 cup)`) {
+		t.Errorf("folded summary does not unfold to the escaped name:\n%s", unfolded)
+	}
+}
+
+func TestPDFWrapsLongLines(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteShoppingList(&out, ExportPDF, awkwardList()); err != nil {
+		t.Fatalf("WriteShoppingList: %v", err)
+	}
+
+	shown := regexp.MustCompile(`/(F\d) ([\d.]+) Tf ([\d.]+) [\d.]+ Td \((.*)\) Tj`)
+	var text []string
+	for _, match := range shown.FindAllStringSubmatch(out.String(), -1) {
+		size, _ := strconv.ParseFloat(match[2], 64)
+		x, _ := strconv.ParseFloat(match[3], 64)
+		if right := x + pdfTextWidth(match[4], match[1], size); right > pdfPageWidth-pdfMargin+0.01 {
+			t.Errorf("%q runs to %.1fpt, past the right margin", match[4], right)
+		}
+		text = append(text, match[4])
+	}
+	joined := strings.Join(text, " ")
+	if !strings.Contains(joined, "Cr\xe8me fra\xeeche, full-fat, the thick Normandy kind") || !strings.Contains(joined, "deli counter \\(2 cup\\)") {
+		t.Errorf("wrapped name lost words: %q", text)
+	}
+}
+
+func TestWrapPDFTextSplitsOverlongWords(t *testing.T) {
+	lines := wrapPDFText("a "+strings.Repeat("w", 40)+" b", "F1", 10, 100)
+	for _, line := range lines {
+		if width := pdfTextWidth(line, "F1", 10); width > 100 {
+			t.Errorf("line %q is %.1fpt wide", line, width)
+		}
+	}
+	if got := strings.Join(lines, ""); got != "a"+strings.Repeat("w", 40)+" b" {
+		t.Errorf("lines = %q", lines)
+	}
+	if lines := wrapPDFText("", "F1", 10, 100); len(lines) != 1 || lines[0] != "" {
+		t.Errorf("empty text wrapped to %q", lines)
+	}
+}
@@ -0,0 +1,348 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/github"
+)
+
+// Supported social login provider kinds
+const (
+	ProviderKindOIDC   = "oidc"
+	ProviderKindGitHub = "github"
+)
+
+// ErrUnknownProvider is returned when a provider name is not configured
+var ErrUnknownProvider = errors.New("unknown login provider")
+
+// SocialProviderConfig describes one external login provider
+type SocialProviderConfig struct {
+	Name         string // name used in URLs, e.g. google, github, oidc
+	Kind         string // oidc or github
+	IssuerURL    string // OIDC issuer, used for discovery
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	// GitHub endpoints, overridable to point at a mock server
+	AuthURL  string
+	TokenURL string
+	APIURL   string
+}
+
+// ExternalIdentity is the normalized profile returned by a login provider
+type ExternalIdentity struct {
+	Provider      string `json:"provider"`
+	Subject       string `json:"subject"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+	GivenName     string `json:"given_name"`
+	FamilyName    string `json:"family_name"`
+	Username      string `json:"username"`
+}
+
+// SocialProvider performs the authorization code flow against one provider
+type SocialProvider interface {
+	Name() string
+	AuthCodeURL(state, nonce string) string
+	Exchange(ctx context.Context, code, nonce string) (*ExternalIdentity, error)
+}
+
+// SocialLoginService holds the configured login providers
+type SocialLoginService struct {
+	providers map[string]SocialProvider
+}
+
+// NewSocialLoginService builds providers from config. OIDC providers are
+// discovered immediately; a provider that fails discovery is skipped and logged.
+// Pass a context created with oidc.ClientContext to use a custom HTTP client.
+func NewSocialLoginService(ctx context.Context, configs []SocialProviderConfig) *SocialLoginService {
+	service := &SocialLoginService{providers: map[string]SocialProvider{}}
+
+	for _, config := range configs {
+		if config.ClientID == "" {
+			continue
+		}
+
+		var provider SocialProvider
+		var err error
+		switch config.Kind {
+		case ProviderKindOIDC:
+			provider, err = newOIDCProvider(ctx, config)
+		case ProviderKindGitHub:
+			provider = newGitHubProvider(ctx, config)
+		default:
+			err = fmt.Errorf("unsupported provider kind %q", config.Kind)
+		}
+
+		if err != nil {
+			log.Printf("Skipping login provider %s: %v", config.Name, err)
+			continue
+		}
+		service.providers[config.Name] = provider
+	}
+
+	return service
+}
+
+// Provider returns the provider registered under name
+func (s *SocialLoginService) Provider(name string) (SocialProvider, error) {
+	if s == nil {
+		return nil, ErrUnknownProvider
+	}
+	provider, ok := s.providers[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return provider, nil
+}
+
+// ProviderNames lists the enabled providers in alphabetical order
+func (s *SocialLoginService) ProviderNames() []string {
+	names := []string{}
+	if s == nil {
+		return names
+	}
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// oidcProvider implements SocialProvider for any OpenID Connect issuer (Google included)
+type oidcProvider struct {
+	name     string
+	oauth    *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+	client   *http.Client
+}
+
+func newOIDCProvider(ctx context.Context, config SocialProviderConfig) (*oidcProvider, error) {
+	if config.IssuerURL == "" {
+		return nil, errors.New("issuer URL is required")
+	}
+
+	provider, err := oidc.NewProvider(ctx, config.IssuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("discovery failed: %v", err)
+	}
+
+	scopes := config.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{oidc.ScopeOpenID, "email", "profile"}
+	}
+
+	return &oidcProvider{
+		name: config.Name,
+		oauth: &oauth2.Config{
+			ClientID:     config.ClientID,
+			ClientSecret: config.ClientSecret,
+			RedirectURL:  config.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       scopes,
+		},
+		verifier: provider.Verifier(&oidc.Config{ClientID: config.ClientID}),
+		client:   contextClient(ctx),
+	}, nil
+}
+
+func (p *oidcProvider) Name() string {
+	return p.name
+}
+
+func (p *oidcProvider) AuthCodeURL(state, nonce string) string {
+	return p.oauth.AuthCodeURL(state, oidc.Nonce(nonce))
+}
+
+func (p *oidcProvider) Exchange(ctx context.Context, code, nonce string) (*ExternalIdentity, error) {
+	if p.client != nil {
+		ctx = oidc.ClientContext(ctx, p.client)
+	}
+
+	token, err := p.oauth.Exchange(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code: %v", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("token response did not include an id_token")
+	}
+
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id_token: %v", err)
+	}
+	if idToken.Nonce != nonce {
+		return nil, errors.New("id_token nonce mismatch")
+	}
+
+	var claims struct {
+		Email             string `json:"email"`
+		EmailVerified     bool   `json:"email_verified"`
+		Name              string `json:"name"`
+		GivenName         string `json:"given_name"`
+		FamilyName        string `json:"family_name"`
+		PreferredUsername string `json:"preferred_username"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("failed to decode id_token claims: %v", err)
+	}
+
+	return &ExternalIdentity{
+		Provider:      p.name,
+		Subject:       idToken.Subject,
+		Email:         strings.ToLower(claims.Email),
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+		GivenName:     claims.GivenName,
+		FamilyName:    claims.FamilyName,
+		Username:      claims.PreferredUsername,
+	}, nil
+}
+
+// gitHubProvider implements SocialProvider using GitHub's OAuth2 flow and REST API,
+// since GitHub does not issue OpenID Connect id_tokens
+type gitHubProvider struct {
+	name   string
+	oauth  *oauth2.Config
+	apiURL string
+	client *http.Client
+}
+
+func newGitHubProvider(ctx context.Context, config SocialProviderConfig) *gitHubProvider {
+	endpoint := github.Endpoint
+	if config.AuthURL != "" {
+		endpoint.AuthURL = config.AuthURL
+	}
+	if config.TokenURL != "" {
+		endpoint.TokenURL = config.TokenURL
+	}
+
+	apiURL := config.APIURL
+	if apiURL == "" {
+		apiURL = "https://api.github.com"
+	}
+
+	scopes := config.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"read:user", "user:email"}
+	}
+
+	return &gitHubProvider{
+		name: config.Name,
+		oauth: &oauth2.Config{
+			ClientID:     config.ClientID,
+			ClientSecret: config.ClientSecret,
+			RedirectURL:  config.RedirectURL,
+			Endpoint:     endpoint,
+			Scopes:       scopes,
+		},
+		apiURL: strings.TrimRight(apiURL, "/"),
+		client: contextClient(ctx),
+	}
+}
+
+func (p *gitHubProvider) Name() string {
+	return p.name
+}
+
+func (p *gitHubProvider) AuthCodeURL(state, nonce string) string {
+	return p.oauth.AuthCodeURL(state)
+}
+
+func (p *gitHubProvider) Exchange(ctx context.Context, code, nonce string) (*ExternalIdentity, error) {
+	if p.client != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, p.client)
+	}
+
+	token, err := p.oauth.Exchange(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code: %v", err)
+	}
+	client := p.oauth.Client(ctx, token)
+
+	var profile struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err := getJSON(client, p.apiURL+"/user", &profile); err != nil {
+		return nil, err
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(client, p.apiURL+"/user/emails", &emails); err != nil {
+		return nil, err
+	}
+
+	identity := &ExternalIdentity{
+		Provider: p.name,
+		Subject:  strconv.FormatInt(profile.ID, 10),
+		Name:     profile.Name,
+		Username: profile.Login,
+	}
+	for _, email := range emails {
+		if email.Primary {
+			identity.Email = strings.ToLower(email.Email)
+			identity.EmailVerified = email.Verified
+			break
+		}
+	}
+
+	if firstName, lastName, found := strings.Cut(profile.Name, " "); found {
+		identity.GivenName = firstName
+		identity.FamilyName = lastName
+	} else {
+		identity.GivenName = profile.Name
+	}
+
+	return identity, nil
+}
+
+// contextClient returns the HTTP client stored with oidc.ClientContext, if any
+func contextClient(ctx context.Context) *http.Client {
+	if client, ok := ctx.Value(oauth2.HTTPClient).(*http.Client); ok {
+		return client
+	}
+	return nil
+}
+
+func getJSON(client *http.Client, url string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %v", err)
+	}
+	return nil
+}
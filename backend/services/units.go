@@ -0,0 +1,171 @@
+package services
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Unit dimensions; quantities only convert within one dimension
+const (
+	DimensionMass   = "mass"
+	DimensionVolume = "volume"
+	DimensionCount  = "count"
+)
+
+type unitInfo struct {
+	dimension string
+	factor    float64 // size in the dimension's base unit (grams, millilitres, pieces)
+}
+
+var units = map[string]unitInfo{
+	"g":     {DimensionMass, 1},
+	"kg":    {DimensionMass, 1000},
+	"mg":    {DimensionMass, 0.001},
+	"oz":    {DimensionMass, 28.3495},
+	"lb":    {DimensionMass, 453.592},
+	"ml":    {DimensionVolume, 1},
+	"cl":    {DimensionVolume, 10},
+	"dl":    {DimensionVolume, 100},
+	"l":     {DimensionVolume, 1000},
+	"pinch": {DimensionVolume, 0.308},
+	"dash":  {DimensionVolume, 0.616},
+	"tsp":   {DimensionVolume, 4.92892},
+	"tbsp":  {DimensionVolume, 14.7868},
+	"floz":  {DimensionVolume, 29.5735},
+	"cup":   {DimensionVolume, 236.588},
+	"pint":  {DimensionVolume, 473.176},
+	"qt":    {DimensionVolume, 946.353},
+	"gal":   {DimensionVolume, 3785.41},
+	"piece": {DimensionCount, 1},
+}
+
+var unitAliases = map[string]string{
+	"gram": "g", "grams": "g", "gr": "g",
+	"kilogram": "kg", "kilograms": "kg", "kgs": "kg",
+	"milligram": "mg", "milligrams": "mg",
+	"ounce": "oz", "ounces": "oz",
+	"pound": "lb", "pounds": "lb", "lbs": "lb",
+	"milliliter": "ml", "milliliters": "ml", "millilitre": "ml", "millilitres": "ml",
+	"liter": "l", "liters": "l", "litre": "l", "litres": "l",
+	"teaspoon": "tsp", "teaspoons": "tsp", "tsps": "tsp",
+	"tablespoon": "tbsp", "tablespoons": "tbsp", "tbsps": "tbsp", "tbs": "tbsp",
+	"fl oz": "floz", "fluid ounce": "floz", "fluid ounces": "floz",
+	"cups": "cup", "c": "cup",
+	"pints": "pint", "pt": "pint",
+	"quart": "qt", "quarts": "qt",
+	"gallon": "gal", "gallons": "gal",
+	"pieces": "piece", "pc": "piece", "pcs": "piece", "each": "piece", "ea": "piece",
+	"whole": "piece", "medium": "piece", "large": "piece", "small": "piece",
+	"head": "piece", "heads": "piece", "bunch": "piece", "bunches": "piece",
+	"clove": "piece", "cloves": "piece", "fillet": "piece", "fillets": "piece",
+	"can": "piece", "cans": "piece", "tin": "piece", "tins": "piece", "jar": "piece", "jars": "piece",
+	"package": "piece", "packages": "piece", "pkg": "piece", "packet": "piece", "packets": "piece",
+	"bag": "piece", "bags": "piece", "box": "piece", "boxes": "piece", "bottle": "piece", "bottles": "piece",
+	"slice": "piece", "slices": "piece", "sprig": "piece", "sprigs": "piece", "stalk": "piece", "stalks": "piece",
+	"centiliter": "cl", "centiliters": "cl", "centilitre": "cl", "centilitres": "cl",
+	"deciliter": "dl", "deciliters": "dl", "decilitre": "dl", "decilitres": "dl",
+	"pinches": "pinch", "dashes": "dash",
+}
+
+// NormalizeUnit maps spellings like "Tablespoons" or "lbs" to a canonical unit.
+// Unknown units are returned lower-cased and trimmed.
+func NormalizeUnit(unit string) string {
+	unit = strings.ToLower(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(unit), ".")))
+	if canonical, ok := unitAliases[unit]; ok {
+		return canonical
+	}
+	return unit
+}
+
+// UnitDimension returns mass, volume or count, or "" for an unknown unit
+func UnitDimension(unit string) string {
+	return units[NormalizeUnit(unit)].dimension
+}
+
+// ConvertQuantity converts between units of the same dimension.
+// Identical units always convert; ok is false when the dimensions differ or a unit is unknown.
+func ConvertQuantity(quantity float64, from, to string) (float64, bool) {
+	from, to = NormalizeUnit(from), NormalizeUnit(to)
+	if from == to {
+		return quantity, true
+	}
+
+	fromInfo, fromOK := units[from]
+	toInfo, toOK := units[to]
+	if !fromOK || !toOK || fromInfo.dimension != toInfo.dimension {
+		return 0, false
+	}
+
+	return quantity * fromInfo.factor / toInfo.factor, true
+}
+
+// displaySteps lists, per unit, the next larger unit and the quantity at which to switch to it
+var displaySteps = map[string]struct {
+	unit      string
+	threshold float64
+}{
+	"mg":   {"g", 1000},
+	"g":    {"kg", 1000},
+	"ml":   {"l", 1000},
+	"tsp":  {"tbsp", 3},
+	"tbsp": {"cup", 4},
+	"oz":   {"lb", 16},
+}
+
+// DisplayQuantity scales a quantity into the largest unit that keeps it at or above
+// one step, e.g. 1500 g becomes 1.5 kg and 6 tsp becomes 2 tbsp
+func DisplayQuantity(quantity float64, unit string) (float64, string) {
+	unit = NormalizeUnit(unit)
+	for {
+		step, ok := displaySteps[unit]
+		if !ok {
+			return quantity, unit
+		}
+		converted, ok := ConvertQuantity(quantity, unit, step.unit)
+		if !ok || quantity < step.threshold {
+			return quantity, unit
+		}
+		quantity, unit = converted, step.unit
+	}
+}
+
+// RoundQuantity rounds a quantity to two decimals for display
+func RoundQuantity(quantity float64) float64 {
+	return math.Round(quantity*100) / 100
+}
+
+// kitchenFractions are shown instead of decimals for spoon and cup measures
+var kitchenFractions = []struct {
+	value float64
+	text  string
+}{
+	{0.25, "1/4"}, {1.0 / 3, "1/3"}, {0.5, "1/2"}, {2.0 / 3, "2/3"}, {0.75, "3/4"},
+}
+
+// FormatQuantity renders a quantity in display units, such as "1.5 kg", "1 1/2 cup"
+// or "3" for pieces. Zero quantities render as "".
+func FormatQuantity(quantity float64, unit string) string {
+	if quantity <= 0 {
+		return ""
+	}
+	quantity, unit = DisplayQuantity(quantity, unit)
+
+	text := strconv.FormatFloat(RoundQuantity(quantity), 'f', -1, 64)
+	if unit == "tsp" || unit == "tbsp" || unit == "cup" {
+		whole, fraction := math.Modf(quantity)
+		for _, kitchen := range kitchenFractions {
+			if math.Abs(fraction-kitchen.value) < 0.02 {
+				text = kitchen.text
+				if whole > 0 {
+					text = strconv.Itoa(int(whole)) + " " + kitchen.text
+				}
+			}
+		}
+	}
+
+	if unit == "" || unit == "piece" {
+		return text
+	}
+	return text + " " + unit
+}
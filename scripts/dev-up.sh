@@ -25,6 +25,7 @@ fi
 # Start backend
 echo "Starting Go backend..."
 cd backend
+export APP_ENV=development
 go mod tidy
 go run . > ../logs/backend.log 2>&1 &
 echo $! > ../.pids/backend.pid